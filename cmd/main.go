@@ -1,12 +1,30 @@
 package main
 
 import (
+	"log"
+	"os"
+
+	"github.com/VanVodkaer/CS2Panel/config"
 	"github.com/VanVodkaer/CS2Panel/docker"
 	"github.com/VanVodkaer/CS2Panel/server"
 	"github.com/VanVodkaer/CS2Panel/util"
 )
 
 func main() {
+	// 加载配置文件并初始化日志
+	if err := config.Init(); err != nil {
+		log.Fatalf("加载配置文件失败: %v", err)
+	}
+	util.InitLogger()
+
+	// 连接 Docker Daemon
+	if err := docker.Init(); err != nil {
+		util.Error("Docker Daemon 连接失败", err)
+		os.Exit(1)
+	}
+	// 延迟关闭 Docker 客户端
+	defer docker.Cli.Close()
+
 	// 创建并初始化 Web 应用
 	app, err := server.ServerNewApp()
 	if err != nil {
@@ -18,8 +36,5 @@ func main() {
 	// 启动 Web 服务器
 	app.ServerStart()
 
-	// 延迟关闭 Docker 客户端
-	defer docker.Cli.Close()
-
 	util.Warn("程序已退出")
 }
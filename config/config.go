@@ -7,20 +7,19 @@ import (
 	"github.com/spf13/viper"
 )
 
-// 全局变量 GlobalConfig
-var GlobalConfig *Config
+// 全局变量 GlobalConfig，调用 Init 之前为零值配置
+var GlobalConfig = &Config{}
 
-// 初始化全局变量
-func init() {
-	// 从配置文件加载日志配置
+// Init 从配置文件加载全局配置，由 main 在启动时调用
+// 测试中可以不调用 Init，直接为 GlobalConfig 赋值
+func Init() error {
 	cfg, err := LoadConfig()
 	if err != nil {
-		log.Fatalf("加载配置文件失败: %v", err)
-	} else {
-		GlobalConfig = cfg
-		log.Printf("配置文件加载成功: %+v\n", cfg)
+		return err
 	}
-
+	GlobalConfig = cfg
+	log.Printf("配置文件加载成功: %+v\n", cfg.Redacted())
+	return nil
 }
 
 // Config 结构体存储应用程序的配置
@@ -35,6 +34,12 @@ type Config struct {
 		PanelDataDir  string `mapstructure:"panel_data_dir"`
 		WebServer     bool   `mapstructure:"web_server"`
 		WebServerPort int    `mapstructure:"web_server_port"`
+
+		CorsOrigins   []string `mapstructure:"cors_origins"`
+		SessionTTL    int      `mapstructure:"session_ttl"`
+		AdminPassword string   `mapstructure:"admin_password"`
+		SecureCookie  bool     `mapstructure:"secure_cookie"`
+		MasterKey     string   `mapstructure:"master_key"`
 	} `mapstructure:"server"`
 
 	Docker struct {
@@ -42,6 +47,7 @@ type Config struct {
 		Tag        string `mapstructure:"tag"`
 		VolumeName string `mapstructure:"volume_name"`
 		Prefix     string `mapstructure:"prefix"`
+		PanelID    string `mapstructure:"panel_id"`
 		MaxRetries int    `mapstructure:"max_retries"`
 		RetryDelay int    `mapstructure:"retry_delay"`
 		CSDataDir  string `mapstructure:"cs_data_dir"`
@@ -56,11 +62,42 @@ type Config struct {
 		LogCompress   bool   `mapstructure:"log_compress"`
 	} `mapstructure:"util"`
 
+	RateLimit struct {
+		ClientRate     float64 `mapstructure:"client_rate"`
+		ClientBurst    int     `mapstructure:"client_burst"`
+		ServerRate     float64 `mapstructure:"server_rate"`
+		ServerBurst    int     `mapstructure:"server_burst"`
+		StatusCacheTTL int     `mapstructure:"status_cache_ttl"`
+		LoginRate      float64 `mapstructure:"login_rate"`
+		LoginBurst     int     `mapstructure:"login_burst"`
+	} `mapstructure:"rate_limit"`
+
 	Game struct {
 		SRCDS_TOKEN   string `mapstructure:"srcds_token"`
 		RCON_PASSWORD string `mapstructure:"rcon_password"`
 		Address       string `mapstructure:"address"`
+
+		DriftCheckInterval int    `mapstructure:"drift_check_interval"`
+		MapFetchOnStart    bool   `mapstructure:"map_fetch_on_start"`
+		SteamAPIURL        string `mapstructure:"steam_api_url"`
+	}
+}
+
+// redacted 非空的敏感配置在日志中显示的值
+const redacted = "******"
+
+// Redacted 返回隐去密码、令牌与密钥的配置副本，用于打印日志
+func (c Config) Redacted() Config {
+	mask := func(v *string) {
+		if *v != "" {
+			*v = redacted
+		}
 	}
+	mask(&c.Server.AdminPassword)
+	mask(&c.Server.MasterKey)
+	mask(&c.Game.SRCDS_TOKEN)
+	mask(&c.Game.RCON_PASSWORD)
+	return c
 }
 
 // LoadConfig 加载配置文件
@@ -75,6 +112,14 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("docker.image_name", "joedwards32/cs2")
 	viper.SetDefault("docker.tag", "latest")
+	viper.SetDefault("game.map_fetch_on_start", true)
+	viper.SetDefault("rate_limit.client_rate", 5)
+	viper.SetDefault("rate_limit.client_burst", 20)
+	viper.SetDefault("rate_limit.server_rate", 10)
+	viper.SetDefault("rate_limit.server_burst", 30)
+	viper.SetDefault("rate_limit.status_cache_ttl", 1000)
+	viper.SetDefault("rate_limit.login_rate", 0.2)
+	viper.SetDefault("rate_limit.login_burst", 10)
 
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
@@ -87,5 +132,10 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("无法解组配置: %w", err)
 	}
 
+	// 未配置面板 ID 时使用容器名称前缀
+	if config.Docker.PanelID == "" {
+		config.Docker.PanelID = config.Docker.Prefix
+	}
+
 	return &config, nil
 }
@@ -3,7 +3,6 @@ package docker
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/VanVodkaer/CS2Panel/config"
@@ -15,26 +14,24 @@ import (
 // 全局变量 Cli 为 Docker 客户端对象
 var Cli *client.Client
 
-// init 初始化 Docker 客户端对象
-func init() {
+// Init 初始化 Docker 客户端对象并确认 Daemon 可用，需要在 config.Init 之后调用
+// 测试中可以不调用 Init，直接为 Cli 赋值指向替身服务的客户端
+func Init() error {
 	// 初始化 Docker 客户端对象
 	var err error
 	Cli, err = client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
-		util.Error("初始化 Docker 客户端失败", err)
-		return
-	} else {
-		util.Info("初始化 Docker 客户端成功")
+		return fmt.Errorf("初始化 Docker 客户端失败: %w", err)
 	}
+	util.Info("初始化 Docker 客户端成功")
 
 	// 测试 Docker Daemon 连接（包含重试机制）
 	if err := TestDockerConnection(); err != nil {
-		util.Error("Docker Daemon 连接失败", err)
-		os.Exit(1)
+		return err
 	}
 
 	ensureDockerVolume(config.GlobalConfig.Docker.VolumeName)
-
+	return nil
 }
 
 // TestDockerConnection 封装 Docker Daemon 连接测试的逻辑
@@ -0,0 +1,568 @@
+package openapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 契约测试不导入 server 包，而是解析源码：
+// routes.go 中的路由、openapi_func.go 中的接口说明表以及各处理函数实际绑定的请求结构体与返回的响应结构体，三者必须一致
+// 实际响应是否符合文档由 server 包中的 openapi_test.go 通过 httptest 检查
+
+// serverDir server 包源码目录
+const serverDir = "../server"
+
+// route 注册的路由
+type route struct {
+	method     string // 大写的 HTTP 方法，Any 表示任意方法
+	path       string // 相对 /api 的路径
+	handler    string
+	permission string // Require/RequireServer 的权限常量名，AdminRequired 为 permAdmin
+	auth       bool   // 是否要求认证
+	session    bool   // 是否要求登录会话
+}
+
+// specOp 接口说明表中的一项
+type specOp struct {
+	id, method, path   string
+	permission         string
+	params, body, form string // 结构体类型名
+	response, raw      string
+	public, session    bool
+}
+
+// handlerContract 处理函数实际的请求与响应
+type handlerContract struct {
+	bindKind  string          // Body、Params 或 Form
+	bindType  string          // 绑定的结构体类型名
+	responses map[string]bool // c.JSON 返回的结构体类型名
+}
+
+// serverPackage 解析后的 server 包
+type serverPackage struct {
+	files map[string]*ast.File
+	funcs map[string]*ast.FuncDecl
+	owner map[string]string // 函数名 -> 所在文件
+}
+
+func parseServer(t *testing.T) *serverPackage {
+	t.Helper()
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(serverDir, "*.go"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("找不到 server 包源码: %v", err)
+	}
+	pkg := &serverPackage{files: make(map[string]*ast.File), funcs: make(map[string]*ast.FuncDecl), owner: make(map[string]string)}
+	for _, p := range paths {
+		if strings.HasSuffix(p, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, p, nil, 0)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", p, err)
+		}
+		pkg.files[filepath.Base(p)] = f
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				pkg.funcs[fn.Name.Name] = fn
+				pkg.owner[fn.Name.Name] = filepath.Base(p)
+			}
+		}
+	}
+	return pkg
+}
+
+// stringLit 字符串字面量的值
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// exprName 标识符或选择器的名称，如 PermFileRead、http.MethodGet
+func exprName(e ast.Expr) string {
+	switch v := e.(type) {
+	case *ast.Ident:
+		return v.Name
+	case *ast.SelectorExpr:
+		return exprName(v.X) + "." + v.Sel.Name
+	case *ast.StarExpr:
+		return exprName(v.X)
+	}
+	return ""
+}
+
+// middlewarePermission 从中间件参数中读取权限要求
+func middlewarePermission(args []ast.Expr, r *route) {
+	for _, arg := range args {
+		call, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		switch exprName(call.Fun) {
+		case "Require", "RequireServer":
+			r.permission = exprName(call.Args[0])
+		case "AdminRequired":
+			r.permission = "permAdmin"
+		case "AuthRequired":
+			r.auth = true
+		case "SessionRequired":
+			r.session = true
+		}
+	}
+}
+
+// parseRoutes 读取 ServerSetRouter 中注册的 /api 路由
+func parseRoutes(t *testing.T, pkg *serverPackage) []route {
+	t.Helper()
+	fn := pkg.funcs["ServerSetRouter"]
+	if fn == nil {
+		t.Fatal("找不到 ServerSetRouter")
+	}
+
+	groups := map[string]route{"router": {}} // 变量 -> 前缀与中间件
+	var routes []route
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.AssignStmt:
+			call, ok := s.Rhs[0].(*ast.CallExpr)
+			sel, ok2 := call.Fun.(*ast.SelectorExpr)
+			if !ok || !ok2 || sel.Sel.Name != "Group" {
+				return true
+			}
+			parent := groups[exprName(sel.X)]
+			prefix, _ := stringLit(call.Args[0])
+			g := parent
+			g.path = parent.path + prefix
+			middlewarePermission(call.Args[1:], &g)
+			groups[s.Lhs[0].(*ast.Ident).Name] = g
+		case *ast.CallExpr:
+			sel, ok := s.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			method := strings.ToUpper(sel.Sel.Name)
+			switch method {
+			case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, "ANY":
+			default:
+				return true
+			}
+			g, ok := groups[exprName(sel.X)]
+			if !ok {
+				return true
+			}
+			p, _ := stringLit(s.Args[0])
+			r := g
+			r.method = method
+			r.path = strings.TrimPrefix(g.path+p, "/api")
+			r.handler = exprName(s.Args[len(s.Args)-1])
+			middlewarePermission(s.Args[1:len(s.Args)-1], &r)
+			routes = append(routes, r)
+		}
+		return true
+	})
+	return routes
+}
+
+// compositeType 结构体字面量的类型名，如 AuthLoginRequest{} -> AuthLoginRequest
+func compositeType(e ast.Expr) string {
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		e = u.X
+	}
+	if lit, ok := e.(*ast.CompositeLit); ok {
+		return exprName(lit.Type)
+	}
+	return ""
+}
+
+// parseSpec 读取 apiOperations 接口说明表
+func parseSpec(t *testing.T, pkg *serverPackage) []specOp {
+	t.Helper()
+	var table *ast.CompositeLit
+	for _, decl := range pkg.files["openapi_func.go"].Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if vs.Names[0].Name == "apiOperations" {
+				table = vs.Values[0].(*ast.CompositeLit)
+			}
+		}
+	}
+	if table == nil {
+		t.Fatal("找不到 apiOperations")
+	}
+
+	var ops []specOp
+	for _, elt := range table.Elts {
+		var op specOp
+		for _, field := range elt.(*ast.CompositeLit).Elts {
+			kv := field.(*ast.KeyValueExpr)
+			switch key := kv.Key.(*ast.Ident).Name; key {
+			case "ID":
+				op.id, _ = stringLit(kv.Value)
+			case "Method":
+				op.method = strings.ToUpper(strings.TrimPrefix(exprName(kv.Value), "http.Method"))
+			case "Path":
+				op.path, _ = stringLit(kv.Value)
+			case "Permission":
+				op.permission = exprName(kv.Value)
+			case "Params":
+				op.params = compositeType(kv.Value)
+			case "Body":
+				op.body = compositeType(kv.Value)
+			case "Form":
+				op.form = compositeType(kv.Value)
+			case "Response":
+				op.response = compositeType(kv.Value)
+			case "Raw":
+				op.raw, _ = stringLit(kv.Value)
+			case "Public":
+				op.public = exprName(kv.Value) == "true"
+			case "Security":
+				op.session = exprName(kv.Value) == "sessionOnly"
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// localTypes 函数内变量的类型：var x T、x := T{...}、x, err := f(...)（f 为同包函数时取对应返回值的类型）
+func localTypes(pkg *serverPackage, fn *ast.FuncDecl) map[string]string {
+	types := make(map[string]string)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.DeclStmt:
+			if gen, ok := s.Decl.(*ast.GenDecl); ok && gen.Tok == token.VAR {
+				for _, spec := range gen.Specs {
+					if vs, ok := spec.(*ast.ValueSpec); ok && vs.Type != nil {
+						for _, name := range vs.Names {
+							types[name.Name] = exprName(vs.Type)
+						}
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			if s.Tok != token.DEFINE {
+				break
+			}
+			if len(s.Lhs) == len(s.Rhs) {
+				for i, lhs := range s.Lhs {
+					if typ := compositeType(s.Rhs[i]); typ != "" {
+						types[lhs.(*ast.Ident).Name] = typ
+					}
+				}
+				break
+			}
+			for i, typ := range resultTypes(pkg, s.Rhs[0]) {
+				if i < len(s.Lhs) {
+					types[s.Lhs[i].(*ast.Ident).Name] = typ
+				}
+			}
+		}
+		return true
+	})
+	return types
+}
+
+// resultTypes 同包函数调用的返回值类型
+func resultTypes(pkg *serverPackage, e ast.Expr) []string {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok || pkg.funcs[ident.Name] == nil || pkg.funcs[ident.Name].Type.Results == nil {
+		return nil
+	}
+	var types []string
+	for _, field := range pkg.funcs[ident.Name].Type.Results.List {
+		for range max(len(field.Names), 1) {
+			types = append(types, exprName(field.Type))
+		}
+	}
+	return types
+}
+
+// parseHandler 读取处理函数绑定的请求结构体与返回的响应结构体
+// 处理函数把 gin.Context 交给同包的辅助函数生成响应时，一并检查辅助函数；error.go 中的错误响应由 Builder 统一描述，不在此检查
+func parseHandler(pkg *serverPackage, name string) handlerContract {
+	hc := handlerContract{responses: make(map[string]bool)}
+	visited := make(map[string]bool)
+
+	var walk func(name string)
+	walk = func(name string) {
+		fn := pkg.funcs[name]
+		if fn == nil || visited[name] || pkg.owner[name] == "error.go" {
+			return
+		}
+		visited[name] = true
+		types := localTypes(pkg, fn)
+
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			switch exprName(call.Fun) {
+			case "c.ShouldBindJSON", "c.ShouldBindQuery", "c.ShouldBind":
+				arg := call.Args[0].(*ast.UnaryExpr).X
+				hc.bindType = types[exprName(arg)]
+				hc.bindKind = map[string]string{
+					"c.ShouldBindJSON": "Body", "c.ShouldBindQuery": "Params", "c.ShouldBind": "Form",
+				}[exprName(call.Fun)]
+			case "c.JSON":
+				typ := compositeType(call.Args[1])
+				if typ == "" {
+					typ = types[exprName(call.Args[1])]
+				}
+				hc.responses[typ] = true
+			default:
+				// 接收 c 的同包函数
+				if ident, ok := call.Fun.(*ast.Ident); ok {
+					for _, arg := range call.Args {
+						if exprName(arg) == "c" {
+							walk(ident.Name)
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	walk(name)
+	return hc
+}
+
+// TestContract 检查路由、接口说明表与处理函数三者一致
+func TestContract(t *testing.T) {
+	pkg := parseServer(t)
+	routes := parseRoutes(t, pkg)
+	ops := parseSpec(t, pkg)
+	if len(routes) == 0 || len(ops) == 0 {
+		t.Fatalf("路由 %d 个，接口说明 %d 个", len(routes), len(ops))
+	}
+
+	byID := make(map[string]specOp)
+	for _, op := range ops {
+		if _, dup := byID[op.id]; dup {
+			t.Errorf("接口说明 %s 重复", op.id)
+		}
+		byID[op.id] = op
+	}
+
+	seen := make(map[string]bool)
+	for _, r := range routes {
+		id := strings.TrimSuffix(r.handler, "Handler")
+		op, ok := byID[id]
+		if !ok {
+			t.Errorf("路由 %s %s 没有接口说明 %s", r.method, r.path, id)
+			continue
+		}
+		seen[id] = true
+
+		if op.path != r.path || (r.method != "ANY" && op.method != r.method) {
+			t.Errorf("%s: 接口说明为 %s %s，路由为 %s %s", id, op.method, op.path, r.method, r.path)
+		}
+		if op.permission != r.permission {
+			t.Errorf("%s: 接口说明的权限为 %q，路由为 %q", id, op.permission, r.permission)
+		}
+		if op.session != r.session {
+			t.Errorf("%s: 接口说明只接受会话为 %v，路由为 %v", id, op.session, r.session)
+		}
+		if op.public == r.auth {
+			t.Errorf("%s: 接口说明的 Public 为 %v 与路由不符", id, op.public)
+		}
+
+		hc := parseHandler(pkg, r.handler)
+		declared := map[string]string{"Body": op.body, "Params": op.params, "Form": op.form}
+		for kind, typ := range declared {
+			want := ""
+			if kind == hc.bindKind {
+				want = hc.bindType
+			}
+			if typ != want {
+				t.Errorf("%s: 接口说明的 %s 为 %q，处理函数绑定 %q", id, kind, typ, want)
+			}
+		}
+
+		var responses []string
+		for typ := range hc.responses {
+			responses = append(responses, typ)
+		}
+		sort.Strings(responses)
+		switch {
+		case len(responses) > 1:
+			t.Errorf("%s: 处理函数返回多种响应结构体 %v", id, responses)
+		case len(responses) == 1 && responses[0] == "":
+			t.Errorf("%s: 处理函数返回的响应不是具名结构体", id)
+		case len(responses) == 1 && responses[0] != op.response:
+			t.Errorf("%s: 接口说明的响应为 %q，处理函数返回 %q", id, op.response, responses[0])
+		case len(responses) == 0 && op.response != "":
+			t.Errorf("%s: 接口说明的响应为 %q，处理函数没有返回 JSON", id, op.response)
+		case len(responses) == 0 && op.raw == "" && id != "openAPI":
+			t.Errorf("%s: 处理函数没有返回 JSON，接口说明需要填写 Raw", id)
+		}
+	}
+	for _, op := range ops {
+		if !seen[op.id] {
+			t.Errorf("接口说明 %s %s %s 没有对应的路由", op.id, op.method, op.path)
+		}
+	}
+}
+
+// TestNoAnonymousResponses 处理函数不再使用 gin.H 或函数内定义的结构体作为请求与响应
+func TestNoAnonymousResponses(t *testing.T) {
+	pkg := parseServer(t)
+	for name, f := range pkg.files {
+		if !strings.HasSuffix(name, "_handler.go") {
+			continue
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch v := n.(type) {
+			case *ast.SelectorExpr:
+				if exprName(v) == "gin.H" {
+					t.Errorf("%s 中使用了 gin.H", name)
+				}
+			case *ast.FuncDecl:
+				if v.Body == nil {
+					return false
+				}
+				ast.Inspect(v.Body, func(n ast.Node) bool {
+					if ts, ok := n.(*ast.TypeSpec); ok {
+						t.Errorf("%s 的 %s 中定义了类型 %s", name, v.Name.Name, ts.Name.Name)
+					}
+					return true
+				})
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// 以下为生成器本身的测试
+
+type testEmbedded struct {
+	Page int `json:"page"`
+}
+
+type testItem struct {
+	Name string `json:"name"`
+}
+
+type testRequest struct {
+	Name  string   `json:"name" binding:"required"`
+	Mode  string   `json:"mode" binding:"omitempty,oneof=a b"`
+	Count int      `json:"count" binding:"omitempty,min=1,max=10"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type testQuery struct {
+	Server string `uri:"name"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1"`
+	Class  string `form:"class" binding:"required"`
+}
+
+type testResponse struct {
+	testEmbedded
+	Items   []testItem      `json:"items"`
+	Extra   *testItem       `json:"extra,omitempty"`
+	Raw     json.RawMessage `json:"raw"`
+	Ignored string          `json:"-"`
+}
+
+type testError struct {
+	Code string `json:"code"`
+}
+
+// TestBuilder 检查结构体标签转换为模式的结果
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"}, "/api", testError{}, map[string]*SecurityScheme{
+		"token": {Type: "http", Scheme: "bearer"},
+	})
+	b.Add(Operation{ID: "create", Method: http.MethodPost, Path: "/servers/:name/items", Params: testQuery{}, Body: testRequest{}, Response: testResponse{}})
+	b.Add(Operation{ID: "login", Method: http.MethodPost, Path: "/login", Public: true, Statuses: []int{http.StatusAccepted}})
+	doc := b.Document()
+
+	op := (*doc.Paths["/servers/{name}/items"])["post"]
+	if op == nil {
+		t.Fatalf("缺少接口，路径: %v", reflect.ValueOf(doc.Paths).MapKeys())
+	}
+	params := make(map[string]Parameter)
+	for _, p := range op.Parameters {
+		params[p.In+":"+p.Name] = p
+	}
+	if p := params["path:name"]; !p.Required {
+		t.Errorf("路径参数 name 应为必填: %+v", p)
+	}
+	if p := params["query:class"]; !p.Required {
+		t.Errorf("查询参数 class 应为必填: %+v", p)
+	}
+	if p := params["query:limit"]; p.Required || p.Schema.Minimum == nil || *p.Schema.Minimum != 1 {
+		t.Errorf("查询参数 limit 应为可选且最小值为 1: %+v", p)
+	}
+
+	req := doc.Components.Schemas["testRequest"]
+	if req == nil || !reflect.DeepEqual(req.Required, []string{"name"}) {
+		t.Fatalf("testRequest 的必填字段错误: %+v", req)
+	}
+	if !reflect.DeepEqual(req.Properties["mode"].Enum, []any{"a", "b"}) {
+		t.Errorf("mode 的枚举错误: %+v", req.Properties["mode"])
+	}
+	if c := req.Properties["count"]; c.Minimum == nil || c.Maximum == nil || *c.Maximum != 10 {
+		t.Errorf("count 的范围错误: %+v", c)
+	}
+
+	resp := doc.Components.Schemas["testResponse"]
+	if resp == nil {
+		t.Fatal("缺少 testResponse")
+	}
+	if _, ok := resp.Properties["page"]; !ok {
+		t.Error("嵌入结构体的字段应展开")
+	}
+	if _, ok := resp.Properties["Ignored"]; ok {
+		t.Error(`json:"-" 的字段不应出现`)
+	}
+	if got := resp.Properties["items"].Items.Ref; got != "#/components/schemas/testItem" {
+		t.Errorf("items 的元素应引用 testItem: %q", got)
+	}
+	if !reflect.DeepEqual(resp.Required, []string{"items", "page"}) {
+		t.Errorf("testResponse 的必填字段错误: %v", resp.Required)
+	}
+
+	if got := op.Responses["400"].Content["application/json"].Schema.Ref; got != "#/components/schemas/testError" {
+		t.Errorf("错误响应应引用 testError: %q", got)
+	}
+	login := (*doc.Paths["/login"])["post"]
+	if len(login.Security) != 0 || login.Responses["202"] == nil {
+		t.Errorf("公开接口不应要求认证且应包含 202: %+v", login)
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatalf("序列化文档失败: %v", err)
+	}
+}
+
+// TestContractSourceExists 契约测试依赖 server 包源码的相对位置
+func TestContractSourceExists(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(serverDir, "routes.go")); err != nil {
+		t.Fatalf("找不到 server/routes.go: %v", err)
+	}
+}
@@ -0,0 +1,481 @@
+// Package openapi 根据接口的请求与响应结构体生成 OpenAPI 3 文档
+//
+// 结构体字段按 json 标签生成请求体与响应的模式，按 form 标签生成查询参数，按 uri 标签生成路径参数；
+// binding 标签中的 required、oneof、min、max 会写入模式。本包只依赖标准库，可以在没有 Docker 与配置文件的环境中测试
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version 生成的文档遵循的 OpenAPI 版本
+const Version = "3.0.3"
+
+// Document OpenAPI 文档
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info 文档信息
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server 接口地址
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem 一个路径下各 HTTP 方法的操作，键为小写的方法名
+type PathItem map[string]*OperationObject
+
+// OperationObject 一个接口
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+	Permission  string                `json:"x-permission,omitempty"` // 访问接口所需的面板权限
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// Parameter 查询或路径参数
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// MediaType 某种内容类型的模式
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response 响应
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header 响应头
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema JSON 模式（OpenAPI 3.0 子集）
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// Components 可复用的模式与认证方式
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 认证方式
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Operation 描述一个接口，由调用方按路由逐个登记
+type Operation struct {
+	ID         string // 操作 ID，与处理函数同名（去掉 Handler 后缀）
+	Method     string // HTTP 方法
+	Path       string // 相对 Server 的路径，路径参数使用 gin 的 :name 写法
+	Summary    string
+	Tag        string
+	Permission string   // 所需权限，为空表示登录即可
+	Public     bool     // 是否无需登录
+	Security   []string // 可用的认证方式，为空时可使用全部认证方式
+	Deprecated bool
+
+	Params   any    // 查询与路径参数结构体（form、uri 标签）
+	Body     any    // JSON 请求体结构体
+	Form     any    // multipart/form-data 请求体结构体（form 标签）
+	Response any    // 成功时的 JSON 响应结构体，为空表示没有响应体
+	Raw      string // 成功时返回非 JSON 内容的类型，如 application/octet-stream
+	Statuses []int  // 200 以外的成功状态，如 202、207，响应体与 200 相同
+}
+
+// Builder 逐个登记接口并生成文档
+type Builder struct {
+	doc      *Document
+	security []map[string][]string
+	errors   map[string]*Response
+	names    map[reflect.Type]string // 已登记的具名结构体
+}
+
+// NewBuilder 创建文档；errorResponse 为所有错误响应共用的结构体，security 为需要登录的接口可用的认证方式
+func NewBuilder(info Info, serverURL string, errorResponse any, schemes map[string]*SecurityScheme) *Builder {
+	b := &Builder{names: make(map[reflect.Type]string), doc: &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Servers:    []Server{{URL: serverURL}},
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema), SecuritySchemes: schemes},
+	}}
+
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.security = append(b.security, map[string][]string{name: {}})
+	}
+
+	errSchema := b.SchemaOf(reflect.TypeOf(errorResponse))
+	b.errors = make(map[string]*Response)
+	for _, status := range []int{
+		http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict,
+		http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout,
+	} {
+		resp := &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{"application/json": {Schema: errSchema}},
+		}
+		if status == http.StatusTooManyRequests {
+			resp.Headers = map[string]*Header{"Retry-After": {Description: "可重试的秒数", Schema: &Schema{Type: "integer"}}}
+		}
+		b.errors[strconv.Itoa(status)] = resp
+	}
+	return b
+}
+
+// pathParamRegex gin 路径参数
+var pathParamRegex = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// OpenAPIPath 把 gin 路径转换为 OpenAPI 路径，如 /servers/:name -> /servers/{name}
+func OpenAPIPath(p string) string {
+	return pathParamRegex.ReplaceAllString(p, "{$1}")
+}
+
+// Add 登记一个接口
+func (b *Builder) Add(op Operation) {
+	o := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Permission:  op.Permission,
+		Deprecated:  op.Deprecated,
+		Responses:   make(map[string]*Response),
+		Security:    b.security,
+	}
+	if op.Tag != "" {
+		o.Tags = []string{op.Tag}
+	}
+	switch {
+	case op.Public:
+		o.Security = []map[string][]string{}
+	case len(op.Security) > 0:
+		o.Security = nil
+		for _, name := range op.Security {
+			o.Security = append(o.Security, map[string][]string{name: {}})
+		}
+	}
+
+	if op.Params != nil {
+		o.Parameters = b.parameters(reflect.TypeOf(op.Params))
+	}
+	// 路径中出现但参数结构体未声明的路径参数
+	for _, m := range pathParamRegex.FindAllStringSubmatch(op.Path, -1) {
+		declared := false
+		for _, p := range o.Parameters {
+			declared = declared || (p.In == "path" && p.Name == m[1])
+		}
+		if !declared {
+			o.Parameters = append(o.Parameters, Parameter{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	switch {
+	case op.Body != nil:
+		o.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"application/json": {Schema: b.SchemaOf(reflect.TypeOf(op.Body))},
+		}}
+	case op.Form != nil:
+		o.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{
+			"multipart/form-data": {Schema: b.formSchema(reflect.TypeOf(op.Form))},
+		}}
+	}
+
+	ok := &Response{Description: "成功"}
+	switch {
+	case op.Raw != "":
+		ok.Content = map[string]*MediaType{op.Raw: {Schema: &Schema{Type: "string", Format: "binary"}}}
+	case op.Response != nil:
+		ok.Content = map[string]*MediaType{"application/json": {Schema: b.SchemaOf(reflect.TypeOf(op.Response))}}
+	}
+	o.Responses["200"] = ok
+	for _, status := range op.Statuses {
+		o.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status), Content: ok.Content}
+	}
+	for status, resp := range b.errors {
+		o.Responses[status] = resp
+	}
+
+	path := OpenAPIPath(op.Path)
+	item, exists := b.doc.Paths[path]
+	if !exists {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	(*item)[strings.ToLower(op.Method)] = o
+}
+
+// Document 返回生成的文档
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// parameters 查询与路径参数
+func (b *Builder) parameters(t reflect.Type) []Parameter {
+	var params []Parameter
+	eachField(t, func(f reflect.StructField) {
+		in, name := "query", tagName(f.Tag.Get("form"))
+		if uri := tagName(f.Tag.Get("uri")); uri != "" {
+			in, name = "path", uri
+		}
+		if name == "" {
+			return
+		}
+		schema := b.SchemaOf(f.Type)
+		applyBinding(schema, f.Tag.Get("binding"))
+		params = append(params, Parameter{Name: name, In: in, Required: in == "path" || isRequired(f), Schema: schema})
+	})
+	return params
+}
+
+// formSchema multipart 表单的模式，文件字段为二进制字符串
+func (b *Builder) formSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	eachField(t, func(f reflect.StructField) {
+		name := tagName(f.Tag.Get("form"))
+		if name == "" {
+			return
+		}
+		var fs *Schema
+		if strings.HasSuffix(f.Type.String(), "multipart.FileHeader") {
+			fs = &Schema{Type: "string", Format: "binary"}
+		} else {
+			fs = b.SchemaOf(f.Type)
+			applyBinding(fs, f.Tag.Get("binding"))
+		}
+		s.Properties[name] = fs
+		if isRequired(f) {
+			s.Required = append(s.Required, name)
+		}
+	})
+	return s
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// SchemaOf 生成类型的模式，具名结构体登记到 components 并返回引用
+func (b *Builder) SchemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		s = &Schema{}
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			s = &Schema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			s = &Schema{Type: "integer", Format: "int32"}
+		case reflect.Int64, reflect.Uint64:
+			s = &Schema{Type: "integer", Format: "int64"}
+		case reflect.Float32, reflect.Float64:
+			s = &Schema{Type: "number"}
+		case reflect.String:
+			s = &Schema{Type: "string"}
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				s = &Schema{Type: "string", Format: "byte"}
+			} else {
+				s = &Schema{Type: "array", Items: b.SchemaOf(t.Elem())}
+			}
+		case reflect.Map:
+			s = &Schema{Type: "object", AdditionalProperties: b.SchemaOf(t.Elem())}
+		case reflect.Struct:
+			if t.Name() == "" {
+				s = b.structSchema(t)
+			} else {
+				s = b.ref(t)
+			}
+		default:
+			s = &Schema{}
+		}
+	}
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+// ref 登记具名结构体并返回引用，不同包的同名类型以包名区分
+func (b *Builder) ref(t reflect.Type) *Schema {
+	name, ok := b.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := b.doc.Components.Schemas[name]; taken {
+			pkg := path.Base(t.PkgPath())
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		b.names[t] = name
+		b.doc.Components.Schemas[name] = &Schema{} // 先占位，支持递归类型
+		b.doc.Components.Schemas[name] = b.structSchema(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// SchemaName 具名结构体在 components 中的名称，未登记时返回空
+func (b *Builder) SchemaName(t reflect.Type) string {
+	return b.names[t]
+}
+
+// structSchema 结构体的模式，按 json 标签生成属性，匿名嵌入的结构体展开
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	eachField(t, func(f reflect.StructField) {
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			return
+		}
+		name := tagName(tag)
+		if name == "" {
+			name = f.Name
+		}
+		fs := b.SchemaOf(f.Type)
+		if fs.Ref == "" {
+			applyBinding(fs, f.Tag.Get("binding"))
+		}
+		s.Properties[name] = fs
+		if isRequired(f) || (!strings.Contains(tag, ",omitempty") && f.Tag.Get("binding") == "" && isResponseField(f)) {
+			s.Required = append(s.Required, name)
+		}
+	})
+	sort.Strings(s.Required)
+	return s
+}
+
+// isResponseField 没有 binding 标签、不带 omitempty 的非指针字段在响应中总会出现
+func isResponseField(f reflect.StructField) bool {
+	return f.Tag.Get("form") == "" && f.Tag.Get("uri") == "" && f.Type.Kind() != reflect.Pointer &&
+		f.Type.Kind() != reflect.Interface && f.Type != rawMessageType
+}
+
+// eachField 遍历导出字段，匿名嵌入的结构体展开
+func eachField(t reflect.Type, fn func(f reflect.StructField)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && tagName(f.Tag.Get("json")) == "" {
+			eachField(f.Type, fn)
+			continue
+		}
+		if f.IsExported() {
+			fn(f)
+		}
+	}
+}
+
+// tagName 取标签中逗号前的名称
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
+
+// isRequired binding 标签是否要求必填
+func isRequired(f reflect.StructField) bool {
+	for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyBinding 把 binding 标签中的 oneof、min、max 写入模式
+func applyBinding(s *Schema, binding string) {
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				s.Enum = append(s.Enum, v)
+			}
+		case "min", "max":
+			if s.Type != "integer" && s.Type != "number" {
+				continue
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			if key == "min" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		}
+	}
+}
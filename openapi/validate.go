@@ -0,0 +1,189 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidateResponse 检查接口的实际响应是否符合文档：状态码已登记，JSON 响应体符合对应的模式
+// path 为相对 Server 的 OpenAPI 路径，如 /v2/servers/{name}
+func (d *Document) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	item, ok := d.Paths[path]
+	if !ok {
+		return fmt.Errorf("文档中没有路径 %s", path)
+	}
+	op, ok := (*item)[strings.ToLower(method)]
+	if !ok {
+		return fmt.Errorf("文档中没有接口 %s %s", method, path)
+	}
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("接口 %s 没有登记状态 %d", op.OperationID, status)
+	}
+
+	if len(resp.Content) == 0 {
+		if len(bytes.TrimSpace(body)) > 0 && status != http.StatusNoContent {
+			return fmt.Errorf("接口 %s 的 %d 响应没有登记响应体", op.OperationID, status)
+		}
+		return nil
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	media, ok := resp.Content[strings.TrimSpace(mediaType)]
+	if !ok {
+		return fmt.Errorf("接口 %s 的 %d 响应没有登记内容类型 %q", op.OperationID, status, contentType)
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("接口 %s 的响应体不是有效的 JSON: %w", op.OperationID, err)
+	}
+	if err := d.Validate(media.Schema, value); err != nil {
+		return fmt.Errorf("接口 %s 的 %d 响应不符合文档: %w", op.OperationID, status, err)
+	}
+	return nil
+}
+
+// Validate 检查解码后的 JSON 值是否符合模式，数字需使用 json.Number 解码
+// Go 的 nil 切片、映射与指针序列化为 null，因此数组、对象与引用的模式也接受 null
+func (d *Document) Validate(s *Schema, value any) error {
+	return d.validate(s, value, "")
+}
+
+func (d *Document) validate(s *Schema, value any, at string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		ref, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s 引用了不存在的模式 %s", location(at), s.Ref)
+		}
+		if value == nil {
+			return nil
+		}
+		return d.validate(ref, value, at)
+	}
+	if value == nil {
+		if s.Nullable || s.Type == "" || s.Type == "array" || s.Type == "object" {
+			return nil
+		}
+		return fmt.Errorf("%s 不能为 null", location(at))
+	}
+
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, e := range s.Enum {
+			allowed = allowed || fmt.Sprint(e) == fmt.Sprint(value)
+		}
+		if !allowed {
+			return fmt.Errorf("%s 的值 %v 不在 %v 中", location(at), value, s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return typeError(s, value, at)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(s, value, at)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(s, value, at)
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return typeError(s, value, at)
+		}
+		if s.Type == "integer" && f != math.Trunc(f) {
+			return typeError(s, value, at)
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			return fmt.Errorf("%s 的值 %v 小于 %v", location(at), n, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return fmt.Errorf("%s 的值 %v 大于 %v", location(at), n, *s.Maximum)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return typeError(s, value, at)
+		}
+		for i, item := range items {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return typeError(s, value, at)
+		}
+		return d.validateObject(s, obj, at)
+	}
+	return nil
+}
+
+// validateObject 检查必填属性与各属性的值；没有 additionalProperties 的模式不允许出现未登记的属性
+func (d *Document) validateObject(s *Schema, obj map[string]any, at string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s 缺少必填属性 %s", location(at), name)
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := at + "." + name
+		if ps, ok := s.Properties[name]; ok {
+			if err := d.validate(ps, obj[name], field); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.AdditionalProperties != nil {
+			if err := d.validate(s.AdditionalProperties, obj[name], field); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.Properties != nil {
+			return fmt.Errorf("%s 是文档中没有的属性", location(field))
+		}
+	}
+	return nil
+}
+
+// typeError 值的类型与模式不符
+func typeError(s *Schema, value any, at string) error {
+	return fmt.Errorf("%s 应为 %s，实际为 %T", location(at), s.Type, value)
+}
+
+// location 错误信息中值的位置
+func location(at string) string {
+	if at == "" {
+		return "响应体"
+	}
+	return "属性 " + strings.TrimPrefix(at, ".")
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateResponse(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+		Note  *string  `json:"note,omitempty"`
+	}
+	type listResponse struct {
+		Items []item `json:"items"`
+	}
+	type errorResponse struct {
+		Code  string `json:"code"`
+		Error string `json:"error"`
+	}
+
+	b := NewBuilder(Info{Title: "test", Version: "1"}, "/api", errorResponse{}, nil)
+	b.Add(Operation{ID: "itemList", Method: http.MethodGet, Path: "/items/:name", Response: listResponse{}, Statuses: []int{http.StatusMultiStatus}})
+	doc := b.Document()
+
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		err    string // 为空表示应通过
+	}{
+		{"ok", 200, `{"items":[{"name":"a","count":1,"tags":["x"]}]}`, ""},
+		{"nil slices", 207, `{"items":[{"name":"a","count":1,"tags":null}]}`, ""},
+		{"nullable pointer", 200, `{"items":[{"name":"a","count":1,"tags":[],"note":null}]}`, ""},
+		{"error model", 404, `{"code":"not_found","error":"x"}`, ""},
+		{"missing required", 200, `{"items":[{"name":"a","tags":[]}]}`, "缺少必填属性 count"},
+		{"wrong type", 200, `{"items":[{"name":"a","count":"1","tags":[]}]}`, "items[0].count 应为 integer"},
+		{"fraction", 200, `{"items":[{"name":"a","count":1.5,"tags":[]}]}`, "应为 integer"},
+		{"undocumented field", 200, `{"items":[],"extra":1}`, "extra 是文档中没有的属性"},
+		{"unregistered status", 202, `{"items":[]}`, "没有登记状态 202"},
+		{"error model mismatch", 500, `{"code":"internal_error"}`, "缺少必填属性 error"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := doc.ValidateResponse(http.MethodGet, "/items/{name}", tc.status, "application/json; charset=utf-8", []byte(tc.body))
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("应通过，实际: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("应报错 %q，实际: %v", tc.err, err)
+			}
+		})
+	}
+}
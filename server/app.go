@@ -17,36 +17,55 @@ type App struct {
 	Config *config.Config
 }
 
-// 初始化 Gin 模式
-func init() {
+// ServerNewApp 创建并初始化一个新的 Web 应用
+func ServerNewApp() (*App, error) {
+	// 初始化 Gin 模式
 	if config.GlobalConfig.Env.Mode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
-}
-
-// ServerNewApp 创建并初始化一个新的 Web 应用
-func ServerNewApp() (*App, error) {
 	return &App{
 		Config: config.GlobalConfig,
 	}, nil
 }
 
+// corsOrigins 过滤跨域来源配置，忽略与会话 Cookie 不兼容的 *
+func corsOrigins(origins []string) []string {
+	result := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			util.Warn("cors_origins 不支持 *，已忽略，请填写具体的前端地址")
+			continue
+		}
+		result = append(result, origin)
+	}
+	return result
+}
+
 // ServerStart 启动 API 和 Web 服务（根据配置判断是否分端口）
 func (app *App) ServerStart() {
 	cfg := app.Config
 
+	// 首次启动时创建管理员
+	if err := bootstrapAdmin(); err != nil {
+		util.Error("创建初始管理员失败", err)
+		os.Exit(1)
+	}
+
 	// 启动 API 服务
 	go func() {
 		router := gin.Default()
-		// 允许所有跨域请求
-		router.Use(cors.New(cors.Config{
-			AllowOrigins:     []string{"*"},                            // 允许所有域
-			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"}, // 允许的 HTTP 方法
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-			ExposeHeaders:    []string{"Content-Length"},
-			AllowCredentials: true,           // 是否允许带 Cookie
-			MaxAge:           12 * time.Hour, // 预检请求的有效期
-		}))
+		router.Use(RequestID())
+		// 只允许配置的来源跨域访问，会话 Cookie 不能与 * 同时使用
+		if origins := corsOrigins(cfg.Server.CorsOrigins); len(origins) > 0 {
+			router.Use(cors.New(cors.Config{
+				AllowOrigins:     origins,
+				AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"}, // 允许的 HTTP 方法
+				AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", requestIDHeader},
+				ExposeHeaders:    []string{"Content-Length", "Retry-After", requestIDHeader},
+				AllowCredentials: true,           // 是否允许带 Cookie
+				MaxAge:           12 * time.Hour, // 预检请求的有效期
+			}))
+		}
 		// 注册 API 路由
 		ServerSetRouter(router)
 
@@ -83,16 +102,33 @@ func (app *App) ServerStart() {
 		util.Info("Web 服务与 API 服务共用同一端口")
 	}
 
-	// 启动后更新一次地图
-	if err := fetchCurrentMaps(); err != nil {
-		util.Error("地图更新失败: %v", err)
-	} else {
-		util.Info("地图更新成功")
+	// 为旧版本创建的容器补充面板标签
+	if err := migrateContainerLabels(); err != nil {
+		util.Error("容器标签迁移失败", err)
 	}
-	if err := fetchFormerMaps(); err != nil {
-		util.Error("历史地图更新失败: %v", err)
-	} else {
-		util.Info("历史地图更新成功")
+
+	// 启动配置偏移后台检查
+	if cfg.Game.DriftCheckInterval > 0 {
+		go startDriftChecker(time.Duration(cfg.Game.DriftCheckInterval) * time.Second)
+	}
+
+	// 启动已启用的地图轮换
+	startRotationControllers()
+
+	// 启动后从 Wiki 刷新一次地图元数据，失败时继续使用本地已保存的数据或内置快照
+	if cfg.Game.MapFetchOnStart {
+		result, err := RefreshMapMetadata()
+		switch {
+		case err != nil:
+			util.Warn("地图元数据更新失败，使用本地数据: " + err.Error())
+		case result.NotModified:
+			util.Info("地图元数据未变化")
+		default:
+			for _, warning := range result.Warnings {
+				util.Warn(warning)
+			}
+			util.Info(fmt.Sprintf("地图元数据已更新: %v", result.Updated))
+		}
 	}
 
 	// 阻塞主线程，防止退出
@@ -0,0 +1,460 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// auditFile 审计日志在面板数据目录下的文件名，每行一条 JSON 记录
+	auditFile = "audit.jsonl"
+	// auditActorSystem 面板后台任务（地图轮换等）的操作者
+	auditActorSystem = "system"
+	// auditActionRcon 后台任务发送 RCON 命令的操作名
+	auditActionRcon = "rcon.command"
+	// maxAuditParamsSize 记录的请求参数上限，超出时不记录参数
+	maxAuditParamsSize = 4096
+	// defaultAuditLimit 查询默认返回的记录数
+	defaultAuditLimit = 100
+	// maxAuditLimit 查询最多返回的记录数，导出不受限制
+	maxAuditLimit = 1000
+	// contextAuditActorKey 无需登录的接口在 gin.Context 中保存审计记录操作者的键，由处理函数设置
+	contextAuditActorKey = "audit_actor"
+	// contextAuditNoParamsKey gin.Context 中标记不记录请求参数的键
+	contextAuditNoParamsKey = "audit_no_params"
+)
+
+// 审计记录的结果
+const (
+	AuditOutcomeSuccess = "success" // 成功
+	AuditOutcomeError   = "error"   // 失败
+	AuditOutcomeDenied  = "denied"  // 被拒绝（未登录或没有权限）
+)
+
+// auditSensitiveKey 请求参数中需要隐去的字段名
+var auditSensitiveKey = regexp.MustCompile(`(?i)pass|pw|token|secret|key`)
+
+// auditCommandKey 请求参数中的控制台命令字段，逐条隐去密码类命令的参数
+var auditCommandKey = regexp.MustCompile(`(?i)^(cmds?|commands?)$`)
+
+// AuditCommand 请求中发送的一条 RCON 命令
+type AuditCommand struct {
+	Server     string `json:"server"`
+	Command    string `json:"command"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AuditRecord 一条审计记录：一次修改操作的请求，或一条后台任务发送的 RCON 命令
+type AuditRecord struct {
+	ID         string          `json:"id"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Time       time.Time       `json:"time"`
+	Actor      string          `json:"actor"`           // 操作用户，后台任务为 system
+	Token      string          `json:"token,omitempty"` // 使用 API 令牌时的令牌名称
+	IP         string          `json:"ip,omitempty"`
+	Method     string          `json:"method,omitempty"`
+	Action     string          `json:"action"` // 接口路径，如 /docker/container/start
+	Servers    []string        `json:"servers,omitempty"`
+	Params     json.RawMessage `json:"params,omitempty"` // 请求参数，敏感字段已隐去
+	Commands   []AuditCommand  `json:"commands,omitempty"`
+	Outcome    string          `json:"outcome"`
+	Status     int             `json:"status,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+}
+
+// AuditFilter 审计记录的查询条件，字段为空时不过滤
+type AuditFilter struct {
+	Server  string    `form:"server"`
+	User    string    `form:"user"`
+	Action  string    `form:"action"` // 接口路径前缀
+	Outcome string    `form:"outcome" binding:"omitempty,oneof=success error denied"`
+	Since   time.Time `form:"since"` // RFC 3339
+	Until   time.Time `form:"until"`
+	Limit   int       `form:"limit" binding:"omitempty,min=1"`
+
+	visible func(r AuditRecord) bool // 当前用户能否查看记录，为空时不限制
+}
+
+// Match 记录是否符合查询条件
+func (f AuditFilter) Match(r AuditRecord) bool {
+	if f.User != "" && r.Actor != f.User {
+		return false
+	}
+	if f.Action != "" && !strings.HasPrefix(r.Action, f.Action) {
+		return false
+	}
+	if f.Outcome != "" && r.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Time.After(f.Until) {
+		return false
+	}
+	if f.Server != "" && !slices.Contains(r.auditServers(), f.Server) {
+		return false
+	}
+	return f.visible == nil || f.visible(r)
+}
+
+// auditServers 记录涉及的所有服务器：请求的目标服务器与发送命令的服务器
+func (r AuditRecord) auditServers() []string {
+	servers := slices.Clone(r.Servers)
+	for _, cmd := range r.Commands {
+		if !slices.Contains(servers, cmd.Server) {
+			servers = append(servers, cmd.Server)
+		}
+	}
+	return servers
+}
+
+// auditVisibility 按当前用户的 audit.view 权限范围过滤记录：涉及的服务器都有权限时可见，
+// 不涉及服务器的记录（登录、用户管理等）只有在所有服务器上拥有 audit.view 权限时可见
+func auditVisibility(c *gin.Context) (func(r AuditRecord) bool, error) {
+	a, err := authorizer(c)
+	if err != nil {
+		return nil, err
+	}
+	if a.AllowedEverywhere(PermAuditView) {
+		return nil, nil
+	}
+	return func(r AuditRecord) bool {
+		servers := r.auditServers()
+		if len(servers) == 0 {
+			return false
+		}
+		for _, server := range servers {
+			if !a.Allowed(PermAuditView, server) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// AuditStore 审计日志，追加写入面板数据目录下的 JSONL 文件
+type AuditStore struct {
+	mu sync.Mutex
+}
+
+// 全局审计日志
+var auditStore = &AuditStore{}
+
+// Append 追加一条记录
+func (s *AuditStore) Append(r AuditRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("序列化审计记录失败: %w", err)
+	}
+	filePath, err := panelDataPath(auditFile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	f, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// scan 按时间顺序遍历开始时已写入的记录，fn 返回 false 时停止；无法解析的行会被跳过
+// 只在打开文件并读取大小时持有锁，导出时下载缓慢不会阻塞审计记录的写入
+func (s *AuditStore) scan(fn func(r AuditRecord, line []byte) bool) error {
+	filePath, err := panelDataPath(auditFile)
+	if err != nil {
+		return err
+	}
+
+	f, size, err := s.open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	defer f.Close()
+
+	// Append 在锁内写入完整的行，读到此时的大小为止不会读到写了一半的记录
+	scanner := bufio.NewScanner(io.LimitReader(f, size))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var r AuditRecord
+		if json.Unmarshal(scanner.Bytes(), &r) != nil {
+			continue
+		}
+		if !fn(r, scanner.Bytes()) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// open 打开审计日志并读取当前大小
+func (s *AuditStore) open(filePath string) (*os.File, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// Query 查询符合条件的记录，按时间倒序返回最近的 limit 条
+func (s *AuditStore) Query(f AuditFilter) ([]AuditRecord, error) {
+	limit := min(util.DefaultIfEmpty(f.Limit, defaultAuditLimit), maxAuditLimit)
+
+	records := make([]AuditRecord, 0)
+	err := s.scan(func(r AuditRecord, _ []byte) bool {
+		if f.Match(r) {
+			records = append(records, r)
+			if len(records) > limit {
+				records = records[1:]
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(records)
+	return records, nil
+}
+
+// Export 按时间顺序把符合条件的记录以 JSONL 写入 w，不限制条数
+func (s *AuditStore) Export(w io.Writer, f AuditFilter) error {
+	var writeErr error
+	err := s.scan(func(r AuditRecord, line []byte) bool {
+		if !f.Match(r) {
+			return true
+		}
+		// line 指向扫描器的缓冲区，不能直接 append
+		if _, writeErr = w.Write(line); writeErr == nil {
+			_, writeErr = w.Write([]byte{'\n'})
+		}
+		return writeErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// auditTrail 请求处理期间正在填写的审计记录，批量操作会并发追加命令
+type auditTrail struct {
+	mu     sync.Mutex
+	record AuditRecord
+}
+
+// auditContextKey context 中保存审计记录的键
+type auditContextKey struct{}
+
+// auditTrailFrom 获取 ctx 携带的审计记录
+func auditTrailFrom(ctx context.Context) *auditTrail {
+	trail, _ := ctx.Value(auditContextKey{}).(*auditTrail)
+	return trail
+}
+
+// isReadCommand 只读取状态或 cvar 当前值的命令，后台任务发送这些命令时不记录
+func isReadCommand(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) <= 1 || fields[0] == "status" || fields[0] == "status_json"
+}
+
+// auditRconCommand 记录一条 RCON 命令：请求中发送的归入该请求的记录，
+// 后台任务发送的修改类命令单独记录，操作者为 system
+func auditRconCommand(ctx context.Context, server, command string, err error, d time.Duration) {
+	cmd := AuditCommand{Server: server, Command: redactCommand(command), DurationMs: d.Milliseconds()}
+	if err != nil {
+		cmd.Error = err.Error()
+	}
+
+	if trail := auditTrailFrom(ctx); trail != nil {
+		trail.mu.Lock()
+		trail.record.Commands = append(trail.record.Commands, cmd)
+		trail.mu.Unlock()
+		return
+	}
+	if isReadCommand(command) {
+		return
+	}
+
+	record := AuditRecord{
+		Time: time.Now().Add(-d), Actor: auditActorSystem, Action: auditActionRcon,
+		Servers: []string{server}, Commands: []AuditCommand{cmd}, Outcome: AuditOutcomeSuccess, DurationMs: cmd.DurationMs,
+	}
+	if err != nil {
+		record.Outcome = AuditOutcomeError
+		record.Error = cmd.Error
+	}
+	appendAudit(record)
+}
+
+// appendAudit 补全 ID 并写入记录，写入失败只记录日志
+func appendAudit(record AuditRecord) {
+	id, err := randomToken(9)
+	if err == nil {
+		record.ID = id
+	}
+	if err := auditStore.Append(record); err != nil {
+		util.Error("写入审计日志失败", err)
+	}
+}
+
+// auditParams 提取请求参数并隐去敏感字段，不是 JSON 或过大时不记录
+func auditParams(body []byte) json.RawMessage {
+	if len(body) == 0 || len(body) > maxAuditParamsSize {
+		return nil
+	}
+	var v any
+	if json.Unmarshal(body, &v) != nil {
+		return nil
+	}
+	data, err := json.Marshal(redactParams(v))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// redactParams 递归隐去敏感字段的值与命令字段中的密码
+func redactParams(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			switch {
+			case auditSensitiveKey.MatchString(k):
+				val[k] = "***"
+			case auditCommandKey.MatchString(k):
+				val[k] = redactCommandParam(item)
+			default:
+				val[k] = redactParams(item)
+			}
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = redactParams(item)
+		}
+	}
+	return v
+}
+
+// redactCommandParam 隐去命令字段中的密码，字段可以是单条命令或命令列表
+func redactCommandParam(v any) any {
+	switch val := v.(type) {
+	case string:
+		return redactCommand(val)
+	case []any:
+		for i, item := range val {
+			if cmd, ok := item.(string); ok {
+				val[i] = redactCommand(cmd)
+			}
+		}
+	}
+	return v
+}
+
+// AuditLog 记录所有修改类请求的中间件，需在 AuthRequired 之后、权限检查之前使用，
+// 被拒绝的请求同样会记录；请求中发送的 RCON 命令通过 ctx 归入同一条记录
+func AuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		u, _ := currentUser(c)
+		trail := &auditTrail{record: AuditRecord{
+			Time:      start,
+			RequestID: requestID(c),
+			Actor:     u.Username,
+			IP:        c.ClientIP(),
+			Method:    c.Request.Method,
+			Action:    strings.TrimPrefix(c.FullPath(), "/api"),
+		}}
+		// 请求体无效时仍然记录，目标服务器留空，请求随后会被 RequireServer 拒绝
+		trail.record.Servers, _ = requestServers(c)
+		if t, ok := currentToken(c); ok {
+			trail.record.Token = t.Name
+		}
+		if c.ContentType() == gin.MIMEJSON && !c.GetBool(contextAuditNoParamsKey) {
+			body, _ := peekBody(c)
+			trail.record.Params = auditParams(body)
+		}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), auditContextKey{}, trail))
+
+		c.Next()
+
+		trail.mu.Lock()
+		record := trail.record
+		trail.mu.Unlock()
+
+		if record.Actor == "" {
+			record.Actor = c.GetString(contextAuditActorKey)
+		}
+		record.Status = c.Writer.Status()
+		record.DurationMs = time.Since(start).Milliseconds()
+		switch {
+		case record.Status == http.StatusUnauthorized || record.Status == http.StatusForbidden:
+			record.Outcome = AuditOutcomeDenied
+		case record.Status >= http.StatusBadRequest:
+			record.Outcome = AuditOutcomeError
+		default:
+			record.Outcome = AuditOutcomeSuccess
+		}
+		if last := c.Errors.Last(); last != nil {
+			record.Error = last.Error()
+		} else if idx := slices.IndexFunc(record.Commands, func(cmd AuditCommand) bool { return cmd.Error != "" }); idx >= 0 {
+			record.Error = record.Commands[idx].Error
+		}
+		appendAudit(record)
+	}
+}
+
+// AuthAuditLog 记录登录与退出登录的中间件，操作者为请求中的用户名或会话所属的用户；
+// 请求参数中有密码，一律不记录
+func AuthAuditLog() gin.HandlerFunc {
+	audit := AuditLog()
+	return func(c *gin.Context) {
+		c.Set(contextAuditNoParamsKey, true)
+		audit(c)
+	}
+}
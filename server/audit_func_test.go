@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 限定了服务器的 audit.view 只能看到这些服务器的记录，不涉及服务器的记录需要不限服务器的权限
+func TestAuditListScope(t *testing.T) {
+	router := testRouter()
+	if err := roleStore.Save(Role{Name: "auditor", Permissions: []string{PermAuditView}}); err != nil {
+		t.Fatal(err)
+	}
+	scoped := userSession(t, router, "audit-scoped", RoleBinding{Role: "auditor", Servers: []string{"one"}})
+	global := userSession(t, router, "audit-global", RoleBinding{Role: "auditor"})
+
+	action := "/test/audit-scope"
+	for _, r := range []AuditRecord{
+		{ID: "one", Servers: []string{"one"}},
+		{ID: "two", Servers: []string{"two"}},
+		{ID: "both", Servers: []string{"one", "two"}},
+		{ID: "command", Commands: []AuditCommand{{Server: "one", Command: "status"}}},
+		{ID: "command-two", Servers: []string{"one"}, Commands: []AuditCommand{{Server: "two", Command: "status"}}},
+		{ID: "none"},
+	} {
+		r.Time, r.Action, r.Outcome = time.Now(), action, AuditOutcomeSuccess
+		if err := auditStore.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		session *http.Cookie
+		want    []string
+	}{
+		{"scoped", scoped, []string{"one", "command"}},
+		{"global", global, []string{"one", "two", "both", "command", "command-two", "none"}},
+		{"admin", adminSession(t, router), []string{"one", "two", "both", "command", "command-two", "none"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveJSON(router, http.MethodGet, "/api/audit/list?action="+action, nil, tc.session)
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 %d: %s", w.Code, w.Body.String())
+			}
+			var resp AuditListResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, r := range resp.Records {
+				ids = append(ids, r.ID)
+			}
+			slices.Reverse(ids)
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("查询结果 %v，应为 %v", ids, tc.want)
+			}
+
+			w = serveJSON(router, http.MethodGet, "/api/audit/export?action="+action, nil, tc.session)
+			ids = nil
+			scanner := bufio.NewScanner(w.Body)
+			for scanner.Scan() {
+				var r AuditRecord
+				if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, r.ID)
+			}
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("导出结果 %v，应为 %v", ids, tc.want)
+			}
+		})
+	}
+}
+
+// 请求参数中命令字段里的密码同样隐去
+func TestAuditParamsRedactsCommands(t *testing.T) {
+	body := `{"name":"one","cmds":["status","sv_password hunter2","say hi; rcon_password hunter2"],"cmd":"tv_password hunter2","rcon_pw":"x"}`
+	params := string(auditParams([]byte(body)))
+	if strings.Contains(params, "hunter2") || strings.Contains(params, `"x"`) {
+		t.Fatalf("审计参数中仍有密码: %s", params)
+	}
+	var v struct {
+		Cmds []string `json:"cmds"`
+		Cmd  string   `json:"cmd"`
+	}
+	if err := json.Unmarshal([]byte(params), &v); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"status", "sv_password ******", "say hi; rcon_password ******"}
+	if !slices.Equal(v.Cmds, want) || v.Cmd != "tv_password ******" {
+		t.Errorf("隐去后的命令不正确: %s", params)
+	}
+}
+
+// blockingWriter 第一次写入时阻塞，直到 release 关闭
+type blockingWriter struct {
+	writing chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.writing) })
+	<-w.release
+	return len(p), nil
+}
+
+// 导出时下载停滞不会阻塞审计记录的写入
+func TestAuditExportDoesNotBlockAppend(t *testing.T) {
+	action := "/test/audit-export-block"
+	if err := auditStore.Append(AuditRecord{ID: "before", Time: time.Now(), Action: action}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	exported := make(chan error, 1)
+	go func() { exported <- auditStore.Export(w, AuditFilter{Action: action}) }()
+	<-w.writing
+
+	appended := make(chan error, 1)
+	go func() { appended <- auditStore.Append(AuditRecord{ID: "during", Time: time.Now(), Action: action}) }()
+	select {
+	case err := <-appended:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("导出期间写入审计记录被阻塞")
+	}
+
+	close(w.release)
+	if err := <-exported; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 登录（包括失败的登录）与退出登录记入审计日志，记录用户名但不记录密码
+func TestAuditLogin(t *testing.T) {
+	router := testRouter()
+	const username = "audit-login"
+	if _, err := userStore.Create(username, testAdminPassword, false); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serveJSON(router, http.MethodPost, "/api/auth/login", AuthLoginRequest{Username: username, Password: "wrong-password"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("密码错误时状态码应为 401，实际 %d", w.Code)
+	}
+	session := loginSession(t, router, username, testAdminPassword)
+	if w := serveJSON(router, http.MethodPost, "/api/auth/logout", nil, session); w.Code != http.StatusOK {
+		t.Fatalf("退出登录失败: %d %s", w.Code, w.Body.String())
+	}
+
+	records, err := auditStore.Query(AuditFilter{User: username, Action: "/auth/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range records {
+		got = append(got, r.Action+" "+r.Outcome)
+		if r.Params != nil {
+			t.Errorf("%s 不应记录请求参数: %s", r.Action, r.Params)
+		}
+		if data, _ := json.Marshal(r); strings.Contains(string(data), "wrong-password") || strings.Contains(string(data), testAdminPassword) {
+			t.Errorf("审计记录中出现了密码: %s", data)
+		}
+	}
+	slices.Reverse(got)
+	want := []string{"/auth/login denied", "/auth/login success", "/auth/logout success"}
+	if !slices.Equal(got, want) {
+		t.Errorf("审计记录 %v，应为 %v", got, want)
+	}
+}
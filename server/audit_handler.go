@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditListResponse 审计日志查询的响应
+type AuditListResponse struct {
+	Records []AuditRecord `json:"records"`
+}
+
+// auditListHandler 处理查询审计日志的请求，按时间倒序返回当前用户有权查看的记录
+func auditListHandler(c *gin.Context) {
+	var req AuditFilter
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	visible, err := auditVisibility(c)
+	if err != nil {
+		handleErrorResponse(c, "查询审计日志失败", err)
+		return
+	}
+	req.visible = visible
+
+	records, err := auditStore.Query(req)
+	if err != nil {
+		handleErrorResponse(c, "查询审计日志失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditListResponse{
+		Records: records,
+	})
+}
+
+// auditExportHandler 处理导出审计日志的请求，以 JSONL 格式按时间顺序下载全部符合条件的记录
+func auditExportHandler(c *gin.Context) {
+	var req AuditFilter
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	visible, err := auditVisibility(c)
+	if err != nil {
+		handleErrorResponse(c, "导出审计日志失败", err)
+		return
+	}
+	req.visible = visible
+
+	filename := fmt.Sprintf("audit-%s.jsonl", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+	if err := auditStore.Export(c.Writer, req); err != nil {
+		// 响应头已发送，只能记录日志
+		util.Error("导出审计日志失败", err)
+	}
+}
@@ -0,0 +1,500 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// SessionCookieName 会话 Cookie 名称
+	SessionCookieName = "cs2panel_session"
+	// contextUserKey gin.Context 中保存当前用户的键
+	contextUserKey = "user"
+
+	// userFile 用户账户在面板数据目录下的文件名
+	userFile = "users.json"
+	// sessionFile 会话在面板数据目录下的文件名，只保存令牌的哈希
+	sessionFile = "sessions.json"
+	// initialPasswordFile 首次启动生成的管理员密码，修改密码后删除
+	initialPasswordFile = "initial_admin_password"
+	// bootstrapAdminName 首次启动创建的管理员用户名
+	bootstrapAdminName = "admin"
+	// defaultSessionTTL 未配置时会话的有效期
+	defaultSessionTTL = 24 * time.Hour
+	// minPasswordLength 密码最短长度
+	minPasswordLength = 8
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码错误
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	// ErrUnauthorized 未登录或会话已过期
+	ErrUnauthorized = errors.New("未登录或登录已过期")
+	// ErrUserNotFound 用户不存在
+	ErrUserNotFound = errors.New("用户不存在")
+)
+
+// usernameRegex 用户名格式
+var usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+// dummyPasswordHash 用户不存在时用于比较的哈希，使响应时间与用户存在时一致
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("cs2panel-dummy-password"), bcrypt.DefaultCost)
+
+// User 面板用户
+type User struct {
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"password_hash"`
+	Admin        bool      `json:"admin"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// UserInfo 返回给客户端的用户信息，不含密码哈希
+type UserInfo struct {
+	Username  string    `json:"username"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Info 用户的公开信息
+func (u User) Info() UserInfo {
+	return UserInfo{Username: u.Username, Admin: u.Admin, CreatedAt: u.CreatedAt}
+}
+
+// validatePassword 检查密码强度
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return invalidf("密码至少需要 %d 个字符", minPasswordLength)
+	}
+	if len(password) > 72 {
+		return invalidf("密码不能超过 72 个字节")
+	}
+	return nil
+}
+
+// UserStore 管理面板用户：用户名 -> 用户
+type UserStore struct {
+	mu sync.Mutex
+}
+
+// 全局用户存储
+var userStore = &UserStore{}
+
+// load 读取所有用户，调用方需持有锁
+func (s *UserStore) load() (map[string]User, error) {
+	users := make(map[string]User)
+	if _, err := loadPanelJSON(&users, userFile); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// save 保存所有用户，调用方需持有锁；文件包含密码哈希，只允许面板进程读取
+func (s *UserStore) save(users map[string]User) error {
+	data, err := marshalPanelJSON(users)
+	if err != nil {
+		return err
+	}
+	return writePanelFile(data, 0600, userFile)
+}
+
+// List 列出所有用户，按用户名排序
+func (s *UserStore) List() ([]UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]UserInfo, 0, len(users))
+	for _, u := range users {
+		result = append(result, u.Info())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	return result, nil
+}
+
+// Get 按用户名获取用户
+func (s *UserStore) Get(username string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return User{}, err
+	}
+	u, ok := users[username]
+	if !ok {
+		return User{}, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	return u, nil
+}
+
+// Create 创建用户
+func (s *UserStore) Create(username, password string, admin bool) (User, error) {
+	if !usernameRegex.MatchString(username) {
+		return User{}, invalidf("无效的用户名 %q，需为 3-32 位字母、数字或 _ . -", username)
+	}
+	if err := validatePassword(password); err != nil {
+		return User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return User{}, err
+	}
+	if _, ok := users[username]; ok {
+		return User{}, conflictf("用户 %s 已存在", username)
+	}
+	now := time.Now()
+	u := User{Username: username, PasswordHash: string(hash), Admin: admin, CreatedAt: now, UpdatedAt: now}
+	users[username] = u
+	return u, s.save(users)
+}
+
+// SetPassword 修改用户密码
+func (s *UserStore) SetPassword(username, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("生成密码哈希失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+	u, ok := users[username]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	u.PasswordHash = string(hash)
+	u.UpdatedAt = time.Now()
+	users[username] = u
+	return s.save(users)
+}
+
+// Delete 删除用户，不能删除最后一个管理员
+func (s *UserStore) Delete(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users, err := s.load()
+	if err != nil {
+		return err
+	}
+	u, ok := users[username]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+	if u.Admin {
+		admins := 0
+		for _, other := range users {
+			if other.Admin {
+				admins++
+			}
+		}
+		if admins <= 1 {
+			return conflictf("不能删除最后一个管理员")
+		}
+	}
+	delete(users, username)
+	return s.save(users)
+}
+
+// Authenticate 校验用户名与密码
+func (s *UserStore) Authenticate(username, password string) (User, error) {
+	u, err := s.Get(username)
+	if errors.Is(err, ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return User{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return u, nil
+}
+
+// Session 登录会话
+type Session struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionStore 管理登录会话：令牌哈希 -> 会话，内存中缓存并持久化到面板数据目录
+type SessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+// 全局会话存储
+var sessionStore = &SessionStore{}
+
+// hashToken 令牌的 SHA-256，令牌本身不落盘
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken 生成 URL 安全的随机字符串
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// sessionTTL 会话有效期
+func sessionTTL() time.Duration {
+	if hours := config.GlobalConfig.Server.SessionTTL; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+	return defaultSessionTTL
+}
+
+// loadLocked 首次使用时从文件加载会话，调用方需持有锁
+func (s *SessionStore) loadLocked() error {
+	if s.sessions != nil {
+		return nil
+	}
+	sessions := make(map[string]Session)
+	if _, err := loadPanelJSON(&sessions, sessionFile); err != nil {
+		return err
+	}
+	s.sessions = sessions
+	return nil
+}
+
+// saveLocked 清理过期会话并保存，调用方需持有锁
+func (s *SessionStore) saveLocked() error {
+	now := time.Now()
+	for key, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	data, err := marshalPanelJSON(s.sessions)
+	if err != nil {
+		return err
+	}
+	return writePanelFile(data, 0600, sessionFile)
+}
+
+// Create 为用户创建会话，返回令牌
+func (s *SessionStore) Create(username string) (string, Session, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", Session{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return "", Session{}, err
+	}
+	now := time.Now()
+	session := Session{Username: username, CreatedAt: now, ExpiresAt: now.Add(sessionTTL())}
+	s.sessions[hashToken(token)] = session
+	return token, session, s.saveLocked()
+}
+
+// Lookup 查找未过期的会话
+func (s *SessionStore) Lookup(token string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		util.Error("读取会话失败", err)
+		return Session{}, false
+	}
+	session, ok := s.sessions[hashToken(token)]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return Session{}, false
+	}
+	return session, true
+}
+
+// Delete 删除会话
+func (s *SessionStore) Delete(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	delete(s.sessions, hashToken(token))
+	return s.saveLocked()
+}
+
+// DeleteUser 删除用户的所有会话，修改密码或删除用户时调用
+func (s *SessionStore) DeleteUser(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	for key, session := range s.sessions {
+		if session.Username == username {
+			delete(s.sessions, key)
+		}
+	}
+	return s.saveLocked()
+}
+
+// bootstrapAdmin 首次启动没有任何用户时创建管理员
+// 使用配置中的 admin_password，未配置时生成随机密码写入面板数据目录下的 initial_admin_password
+func bootstrapAdmin() error {
+	users, err := userStore.List()
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return nil
+	}
+
+	password := config.GlobalConfig.Server.AdminPassword
+	generated := password == ""
+	if generated {
+		if password, err = randomToken(12); err != nil {
+			return err
+		}
+	}
+	if _, err := userStore.Create(bootstrapAdminName, password, true); err != nil {
+		return err
+	}
+
+	if generated {
+		if err := writePanelFile([]byte(password+"\n"), 0600, initialPasswordFile); err != nil {
+			return err
+		}
+		path, _ := panelDataPath(initialPasswordFile)
+		util.Warn(fmt.Sprintf("已创建初始管理员 %s，密码保存在 %s，请登录后修改密码", bootstrapAdminName, path))
+	} else {
+		util.Info("已使用配置中的密码创建初始管理员 " + bootstrapAdminName)
+	}
+	return nil
+}
+
+// sessionToken 从请求 Cookie 中读取会话令牌
+func sessionToken(c *gin.Context) string {
+	token, err := c.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// setSessionCookie 设置或清除会话 Cookie
+func setSessionCookie(c *gin.Context, token string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(SessionCookieName, token, maxAge, "/", "", config.GlobalConfig.Server.SecureCookie, true)
+}
+
+// AuthRequired 校验会话或 API 令牌的中间件，通过后将当前用户保存到 gin.Context
+// 请求带有 Authorization: Bearer 时只按 API 令牌校验，不再回退到 Cookie
+func AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := ""
+		if bearer := bearerToken(c); bearer != "" {
+			t, ok := tokenStore.Authenticate(bearer)
+			if !ok {
+				abortWithError(c, "无效或已过期的 API 令牌", ErrUnauthorized, nil)
+				return
+			}
+			c.Set(contextTokenKey, t)
+			username = t.Owner
+		} else {
+			session, ok := sessionStore.Lookup(sessionToken(c))
+			if !ok {
+				abortWithError(c, ErrUnauthorized.Error(), ErrUnauthorized, nil)
+				return
+			}
+			username = session.Username
+		}
+
+		u, err := userStore.Get(username)
+		if err != nil {
+			abortWithError(c, ErrUnauthorized.Error(), ErrUnauthorized, nil)
+			return
+		}
+		c.Set(contextUserKey, u)
+		var token *APIToken
+		if t, ok := currentToken(c); ok {
+			token = &t
+		}
+		// 请求中发送的 RCON 命令按操作者的策略检查
+		c.Request = c.Request.WithContext(withRconSubject(c.Request.Context(), u, token))
+		c.Next()
+	}
+}
+
+// AdminRequired 只允许管理员访问的中间件，需在 AuthRequired 之后使用
+func AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if u, ok := currentUser(c); !ok || !u.Admin {
+			abortWithError(c, "需要管理员权限", ErrForbidden, nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentUser 获取当前请求的用户
+func currentUser(c *gin.Context) (User, bool) {
+	v, ok := c.Get(contextUserKey)
+	if !ok {
+		return User{}, false
+	}
+	u, ok := v.(User)
+	return u, ok
+}
+
+// ChangePassword 修改密码并使该用户的所有会话失效
+func ChangePassword(username, password string) error {
+	if err := userStore.SetPassword(username, password); err != nil {
+		return err
+	}
+	if username == bootstrapAdminName {
+		if path, err := panelDataPath(initialPasswordFile); err == nil {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				util.Warn("删除初始管理员密码文件失败: " + err.Error())
+			}
+		}
+	}
+	return sessionStore.DeleteUser(username)
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// startSession 为用户创建会话并写入 Cookie
+func startSession(c *gin.Context, username string) error {
+	token, _, err := sessionStore.Create(username)
+	if err != nil {
+		return err
+	}
+	setSessionCookie(c, token, int(sessionTTL().Seconds()))
+	return nil
+}
+
+// AuthUserResponse 登录或创建用户的响应
+type AuthUserResponse struct {
+	Message string   `json:"message"`
+	User    UserInfo `json:"user"`
+}
+
+// AuthLoginRequest 登录的请求参数
+type AuthLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// authLoginHandler 处理登录请求，成功后通过 Cookie 下发会话
+func authLoginHandler(c *gin.Context) {
+	var req AuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	c.Set(contextAuditActorKey, req.Username)
+	if !allowLogin(c, req.Username) {
+		util.Warn("登录尝试过于频繁 用户: " + req.Username + " 来源: " + c.ClientIP())
+		return
+	}
+
+	u, err := userStore.Authenticate(req.Username, req.Password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		util.Warn("登录失败 用户: " + req.Username + " 来源: " + c.ClientIP())
+		handleErrorResponse(c, "登录失败", err)
+		return
+	}
+	if err != nil {
+		handleErrorResponse(c, "登录失败", err)
+		return
+	}
+	if err := startSession(c, u.Username); err != nil {
+		handleErrorResponse(c, "创建会话失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthUserResponse{
+		Message: "登录成功",
+		User:    u.Info(),
+	})
+
+	util.Info("登录成功 用户: " + u.Username + " 来源: " + c.ClientIP())
+}
+
+// authLogoutHandler 处理退出登录请求
+func authLogoutHandler(c *gin.Context) {
+	if token := sessionToken(c); token != "" {
+		if session, ok := sessionStore.Lookup(token); ok {
+			c.Set(contextAuditActorKey, session.Username)
+		}
+		if err := sessionStore.Delete(token); err != nil {
+			handleErrorResponse(c, "退出登录失败", err)
+			return
+		}
+	}
+	setSessionCookie(c, "", -1)
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "已退出登录",
+	})
+}
+
+// AuthMeResponse 当前登录用户的响应
+type AuthMeResponse struct {
+	User        UserInfo      `json:"user"`
+	Permissions []string      `json:"permissions"` // 任一角色分配包含的权限，不论分配限定了哪些服务器（管理员为 *）；各服务器上的权限以 bindings 为准
+	Bindings    []RoleBinding `json:"bindings"`
+	Token       *APITokenInfo `json:"token,omitempty"` // 使用 API 令牌访问时返回令牌信息
+}
+
+// authMeHandler 处理获取当前登录用户的请求
+func authMeHandler(c *gin.Context) {
+	u, _ := currentUser(c)
+	a, err := authorizer(c)
+	if err != nil {
+		handleErrorResponse(c, "读取角色失败", err)
+		return
+	}
+	bindings, err := roleStore.Bindings(u.Username)
+	if err != nil {
+		handleErrorResponse(c, "读取角色失败", err)
+		return
+	}
+
+	resp := AuthMeResponse{
+		User:        u.Info(),
+		Permissions: a.Permissions(),
+		Bindings:    bindings,
+	}
+	if t, ok := currentToken(c); ok {
+		info := t.Info()
+		resp.Token = &info
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AuthPasswordRequest 修改自己密码的请求参数
+type AuthPasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// authPasswordHandler 处理修改自己密码的请求，修改后其他会话失效
+func authPasswordHandler(c *gin.Context) {
+	var req AuthPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	u, _ := currentUser(c)
+	if _, err := userStore.Authenticate(u.Username, req.OldPassword); err != nil {
+		handleErrorResponse(c, "原密码错误", err)
+		return
+	}
+	if err := ChangePassword(u.Username, req.NewPassword); err != nil {
+		handleErrorResponse(c, "修改密码失败", err)
+		return
+	}
+	if err := startSession(c, u.Username); err != nil {
+		handleErrorResponse(c, "创建会话失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "修改密码成功",
+	})
+
+	util.Info("修改密码成功 用户: " + u.Username)
+}
+
+// AuthUserListResponse 用户列表的响应
+type AuthUserListResponse struct {
+	Users []UserInfo `json:"users"`
+}
+
+// authUserListHandler 处理获取用户列表的请求
+func authUserListHandler(c *gin.Context) {
+	users, err := userStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取用户列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthUserListResponse{
+		Users: users,
+	})
+}
+
+// AuthUserCreateRequest 创建用户的请求参数
+type AuthUserCreateRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Admin    bool   `json:"admin"`
+}
+
+// authUserCreateHandler 处理创建用户的请求
+func authUserCreateHandler(c *gin.Context) {
+	var req AuthUserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	u, err := userStore.Create(req.Username, req.Password, req.Admin)
+	if err != nil {
+		handleErrorResponse(c, "创建用户失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthUserResponse{
+		Message: "创建用户成功",
+		User:    u.Info(),
+	})
+
+	util.Info("创建用户成功 用户: " + u.Username)
+}
+
+// AuthUserPasswordRequest 管理员重置用户密码的请求参数
+type AuthUserPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// authUserPasswordHandler 处理管理员重置用户密码的请求
+func authUserPasswordHandler(c *gin.Context) {
+	var req AuthUserPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := ChangePassword(req.Username, req.Password); err != nil {
+		handleErrorResponse(c, "重置密码失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "重置密码成功",
+	})
+
+	util.Info("重置密码成功 用户: " + req.Username)
+}
+
+// AuthUserDeleteRequest 删除用户的请求参数
+type AuthUserDeleteRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// authUserDeleteHandler 处理删除用户的请求
+func authUserDeleteHandler(c *gin.Context) {
+	var req AuthUserDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := userStore.Delete(req.Username); err != nil {
+		handleErrorResponse(c, "删除用户失败", err)
+		return
+	}
+	if err := sessionStore.DeleteUser(req.Username); err != nil {
+		util.Error("删除用户会话失败", err)
+	}
+	if err := roleStore.SetBindings(req.Username, nil); err != nil {
+		util.Error("删除用户角色分配失败", err)
+	}
+	if err := tokenStore.DeleteOwner(req.Username); err != nil {
+		util.Error("删除用户令牌失败", err)
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "删除用户成功",
+	})
+
+	util.Info("删除用户成功 用户: " + req.Username)
+}
@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types/container"
+)
+
+// 批量操作中单个服务器的结果状态
+const (
+	BatchStatusSuccess = "success" // 操作成功
+	BatchStatusError   = "error"   // 操作失败
+	BatchStatusAlready = "already" // 已处于目标状态，无需操作
+)
+
+// BatchRequest 批量操作的公共请求参数：name 或 names 至少提供其一
+type BatchRequest struct {
+	Name     string   `json:"name"`
+	Names    []string `json:"names"`
+	Parallel int      `json:"parallel"` // 并发数，默认 1 即逐个执行
+}
+
+// Targets 收集待处理的服务器名称并去重
+func (r BatchRequest) Targets() ([]string, error) {
+	names := r.Names
+	if len(names) == 0 && r.Name != "" {
+		names = []string{r.Name}
+	}
+	if len(names) == 0 {
+		return nil, invalidf("必须提供 name 或 names 参数")
+	}
+
+	seen := make(map[string]bool, len(names))
+	targets := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			targets = append(targets, name)
+		}
+	}
+	return targets, nil
+}
+
+// BatchResult 批量操作中单个服务器的结果
+type BatchResult struct {
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	Responses []string `json:"responses,omitempty"` // 启动后执行命令的响应
+
+	err error // 失败的原因，单个服务器的接口据此返回对应的错误码
+}
+
+// batchError 生成失败结果并记录日志
+func batchError(name, message string, err error) BatchResult {
+	util.Error(fmt.Sprintf("%s 容器: %s", message, name), err)
+	return BatchResult{Name: name, Status: BatchStatusError, Error: fmt.Sprintf("%s: %v", message, err), err: err}
+}
+
+// runBatch 对所有目标执行操作，单个失败不影响其余目标
+// parallel 限制同时执行的数量，结果顺序与 targets 一致
+func runBatch(targets []string, parallel int, fn func(name string) BatchResult) []BatchResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]BatchResult, len(targets))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, name := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = fn(name)
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// batchStatusCode 全部成功时返回 200，存在失败时返回 207
+func batchStatusCode(results []BatchResult) int {
+	for _, r := range results {
+		if r.Status == BatchStatusError {
+			return http.StatusMultiStatus
+		}
+	}
+	return http.StatusOK
+}
+
+// succeededNames 返回成功或已处于目标状态的服务器名称
+func succeededNames(results []BatchResult) []string {
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Status != BatchStatusError {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// isRunningState 判断容器状态是否需要停止
+func isRunningState(state string) bool {
+	return state == "running" || state == "paused" || state == "restarting"
+}
+
+// startServer 启动服务器容器，已运行时跳过启动；cmds 不为空时在启动后执行命令
+func startServer(ctx context.Context, name string, cmds []string) BatchResult {
+	ctr, err := ResolveContainer(ctx, name)
+	if err != nil {
+		return batchError(name, "启动容器失败", err)
+	}
+
+	result := BatchResult{Name: name, Status: BatchStatusSuccess}
+	if ctr.State == "running" {
+		result.Status = BatchStatusAlready
+	} else {
+		// 已轮换的 RCON 密码先写入环境变量，重建后的容器保持停止
+		if synced, err := syncPendingSecrets(ctx, name); err != nil {
+			return batchError(name, "更新 RCON 密码失败", err)
+		} else if synced {
+			if ctr, err = ResolveContainer(ctx, name); err != nil {
+				return batchError(name, "启动容器失败", err)
+			}
+		}
+		if err := docker.Cli.ContainerStart(context.Background(), ctr.ID, container.StartOptions{}); err != nil {
+			return batchError(name, "启动容器失败", err)
+		}
+		util.Info(fmt.Sprintf("容器启动成功 容器 ID: %s", name))
+	}
+
+	if len(cmds) > 0 {
+		responses, err := ExecRconCommands(ctx, name, cmds)
+		if err != nil {
+			return batchError(name, "执行命令失败", err)
+		}
+		redacted := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			redacted[i] = redactCommand(cmd)
+		}
+		util.Info(fmt.Sprintf("执行命令成功 容器: %s 命令: %v 响应: %v", name, redacted, responses))
+		result.Responses = responses
+	}
+	return result
+}
+
+// stopServer 停止服务器容器，未运行时跳过
+func stopServer(name string) BatchResult {
+	ctr, err := ResolveContainer(context.Background(), name)
+	if err != nil {
+		return batchError(name, "停止容器失败", err)
+	}
+	if !isRunningState(ctr.State) {
+		return BatchResult{Name: name, Status: BatchStatusAlready}
+	}
+
+	if err := docker.Cli.ContainerStop(context.Background(), ctr.ID, container.StopOptions{}); err != nil {
+		return batchError(name, "停止容器失败", err)
+	}
+	util.Info(fmt.Sprintf("容器停止成功 容器 ID: %s", name))
+	return BatchResult{Name: name, Status: BatchStatusSuccess}
+}
+
+// restartServer 重启服务器容器
+func restartServer(name string) BatchResult {
+	ctr, err := ResolveContainer(context.Background(), name)
+	if err != nil {
+		return batchError(name, "重启容器失败", err)
+	}
+
+	// 已轮换的 RCON 密码需要写入环境变量，重建容器即完成重启
+	if synced, err := syncPendingSecrets(context.Background(), name); err != nil {
+		return batchError(name, "更新 RCON 密码失败", err)
+	} else if synced {
+		util.Info(fmt.Sprintf("容器重启成功 容器 ID: %s", name))
+		return BatchResult{Name: name, Status: BatchStatusSuccess}
+	}
+
+	// 重启容器（如需传超时时间可自行拓展 RestartOptions）
+	if err := docker.Cli.ContainerRestart(context.Background(), ctr.ID, container.StopOptions{}); err != nil {
+		return batchError(name, "重启容器失败", err)
+	}
+	util.Info(fmt.Sprintf("容器重启成功 容器 ID: %s", name))
+	return BatchResult{Name: name, Status: BatchStatusSuccess}
+}
+
+// removeServer 删除服务器容器，运行中时先停止，容器不存在时视为已删除
+func removeServer(name string) BatchResult {
+	ctr, err := ResolveContainer(context.Background(), name)
+	if errors.Is(err, ErrContainerNotFound) {
+		if err := aliasStore.Delete(name); err != nil {
+			util.Error(fmt.Sprintf("删除服务器 %s 的别名失败", name), err)
+		}
+		return BatchResult{Name: name, Status: BatchStatusAlready}
+	}
+	if err != nil {
+		return batchError(name, "删除容器失败", err)
+	}
+
+	if isRunningState(ctr.State) {
+		if err := docker.Cli.ContainerStop(context.Background(), ctr.ID, container.StopOptions{}); err != nil {
+			return batchError(name, "停止容器失败", err)
+		}
+	}
+	if err := docker.Cli.ContainerRemove(context.Background(), ctr.ID, container.RemoveOptions{}); err != nil {
+		return batchError(name, "删除容器失败", err)
+	}
+
+	// 导入的容器删除后同时删除别名
+	if err := aliasStore.Delete(name); err != nil {
+		util.Error(fmt.Sprintf("删除服务器 %s 的别名失败", name), err)
+	}
+	if err := secretStore.Delete(name); err != nil {
+		util.Error(fmt.Sprintf("删除服务器 %s 的密钥失败", name), err)
+	}
+	rconPool.Remove(name)
+	util.Info(fmt.Sprintf("容器删除成功 容器 ID: %s", name))
+	return BatchResult{Name: name, Status: BatchStatusSuccess}
+}
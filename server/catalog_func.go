@@ -0,0 +1,293 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/docker/docker/errdefs"
+)
+
+const (
+	// officialMapDir 游戏自带地图 VPK 所在目录
+	officialMapDir = gameRootDir + "/maps"
+	// workshopContentDir 创意工坊内容目录，每个物品一个以 ID 命名的子目录
+	workshopContentDir = gameInstallDir + "/steamapps/workshop/content/730"
+	// mapScanTTL 已安装地图扫描结果的缓存时间
+	mapScanTTL = time.Minute
+)
+
+// ErrNoVolumeContainer 没有可用于访问游戏卷的服务器容器
+var ErrNoVolumeContainer = errors.New("没有可用于访问游戏卷的服务器容器")
+
+// nonMapVPKs maps 目录下不是地图的 VPK
+var nonMapVPKs = map[string]bool{
+	"graphics_settings": true,
+	"lobby_mapveto":     true,
+}
+
+// MapCatalogEntry 地图目录中的一项，合并地图元数据与游戏卷上实际安装的地图
+type MapCatalogEntry struct {
+	MapInfo
+	Class        string `json:"class,omitempty"`         // current 或 former，仅元数据中存在的地图
+	Installed    bool   `json:"installed"`               // 游戏卷上存在对应 VPK
+	Official     bool   `json:"official"`                // 地图元数据中收录的官方地图
+	Workshop     bool   `json:"workshop"`                // 创意工坊地图
+	WorkshopID   string `json:"workshop_id,omitempty"`   // 创意工坊物品 ID
+	WorkshopType string `json:"workshop_type,omitempty"` // map 或 collection
+}
+
+// InstalledMap 游戏卷上扫描到的地图
+type InstalledMap struct {
+	InternalName string
+	WorkshopID   string
+}
+
+// mapScanCache 缓存最近一次扫描结果，避免每次请求都在容器内执行命令
+var mapScanCache struct {
+	sync.Mutex
+	at   time.Time
+	maps []InstalledMap
+}
+
+// mapNameFromVPK 从 VPK 文件名得到地图名，不是地图时返回 false
+func mapNameFromVPK(file string) (string, bool) {
+	if !strings.HasSuffix(strings.ToLower(file), ".vpk") {
+		return "", false
+	}
+	name := strings.ToLower(strings.TrimSuffix(path.Base(file), path.Ext(file)))
+	// 多文件 VPK 只有 _dir 是索引文件，其余分卷跳过
+	if strings.HasSuffix(name, "_dir") {
+		name = strings.TrimSuffix(name, "_dir")
+	} else if i := strings.LastIndex(name, "_"); i >= 0 && len(name)-i == 4 && strings.Trim(name[i+1:], "0123456789") == "" {
+		return "", false
+	}
+	if name == "" || nonMapVPKs[name] || strings.HasSuffix(name, "_vanity") {
+		return "", false
+	}
+	return name, true
+}
+
+// volumeContainer 选择一个用于访问共享游戏卷的容器，优先使用运行中的容器
+func volumeContainer(ctx context.Context) (PanelContainer, error) {
+	containers, err := ListPanelContainers(ctx)
+	if err != nil {
+		return PanelContainer{}, err
+	}
+	if len(containers) == 0 {
+		return PanelContainer{}, ErrNoVolumeContainer
+	}
+	for _, c := range containers {
+		if c.State == "running" {
+			return c, nil
+		}
+	}
+	return containers[0], nil
+}
+
+// findVPKs 列出容器内目录下指定深度的 VPK 文件，返回相对于该目录的路径，目录不存在时返回空
+func findVPKs(ctx context.Context, ctr PanelContainer, dir string, depth int) ([]string, error) {
+	if _, err := docker.Cli.ContainerStatPath(ctx, ctr.ID, dir); err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	d := fmt.Sprint(depth)
+	output, err := runServerCommand(ctx, ctr.Container, []string{
+		"find", dir, "-mindepth", d, "-maxdepth", d, "-type", "f", "-iname", "*.vpk", "-printf", `%P\n`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描目录 %s 失败: %w", dir, err)
+	}
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// ScanInstalledMaps 扫描游戏卷上的官方地图与创意工坊地图，refresh 为 true 时忽略缓存
+func ScanInstalledMaps(ctx context.Context, refresh bool) ([]InstalledMap, error) {
+	mapScanCache.Lock()
+	defer mapScanCache.Unlock()
+
+	if !refresh && !mapScanCache.at.IsZero() && time.Since(mapScanCache.at) < mapScanTTL {
+		return mapScanCache.maps, nil
+	}
+
+	ctr, err := volumeContainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []InstalledMap
+	seen := make(map[InstalledMap]bool)
+	add := func(m InstalledMap) {
+		if !seen[m] {
+			seen[m] = true
+			result = append(result, m)
+		}
+	}
+
+	official, err := findVPKs(ctx, ctr, officialMapDir, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range official {
+		if name, ok := mapNameFromVPK(file); ok {
+			add(InstalledMap{InternalName: name})
+		}
+	}
+
+	workshop, err := findVPKs(ctx, ctr, workshopContentDir, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range workshop {
+		id, file, ok := strings.Cut(file, "/")
+		if !ok {
+			continue
+		}
+		if name, ok := mapNameFromVPK(file); ok {
+			add(InstalledMap{InternalName: name, WorkshopID: id})
+		}
+	}
+
+	mapScanCache.at = time.Now()
+	mapScanCache.maps = result
+	return result, nil
+}
+
+// BuildMapCatalog 合并地图元数据与已安装地图
+// 扫描失败时仍返回元数据中的地图（均标记为未安装），并返回扫描错误
+func BuildMapCatalog(ctx context.Context, refresh bool) ([]MapCatalogEntry, error) {
+	catalog := make([]MapCatalogEntry, 0)
+	index := make(map[string]int)
+
+	for _, mc := range mapClasses {
+		maps, err := getMapList(mc.Class)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range maps {
+			key := strings.ToLower(m.InternalName)
+			if _, ok := index[key]; ok || key == "" {
+				continue
+			}
+			index[key] = len(catalog)
+			catalog = append(catalog, MapCatalogEntry{MapInfo: m, Class: mc.Class, Official: true})
+		}
+	}
+
+	// 登记的创意工坊物品以名称作为 internal_name，切换地图时按名称解析
+	registered, err := workshopStore.List()
+	if err != nil {
+		return nil, err
+	}
+	var workshop []MapCatalogEntry
+	workshopIndex := make(map[string]int)
+	for _, item := range registered {
+		workshopIndex[item.ID] = len(workshop)
+		workshop = append(workshop, MapCatalogEntry{
+			MapInfo: MapInfo{
+				Name:          item.DisplayName(),
+				InternalName:  item.Name,
+				PlayableModes: []string{},
+			},
+			Workshop:     true,
+			WorkshopID:   item.ID,
+			WorkshopType: item.Type,
+		})
+	}
+
+	installed, scanErr := ScanInstalledMaps(ctx, refresh)
+
+	var extra []MapCatalogEntry
+	installedIDs := make(map[string]bool)
+	for _, m := range installed {
+		if m.WorkshopID == "" {
+			if i, ok := index[m.InternalName]; ok {
+				catalog[i].Installed = true
+				continue
+			}
+		} else {
+			installedIDs[m.WorkshopID] = true
+			if _, ok := workshopIndex[m.WorkshopID]; ok {
+				continue
+			}
+		}
+		entry := MapCatalogEntry{
+			MapInfo: MapInfo{
+				Name:          m.InternalName,
+				InternalName:  m.InternalName,
+				PlayableModes: []string{},
+			},
+			Installed:  true,
+			Workshop:   m.WorkshopID != "",
+			WorkshopID: m.WorkshopID,
+		}
+		if entry.Workshop {
+			entry.WorkshopType = WorkshopTypeMap
+		}
+		extra = append(extra, entry)
+	}
+
+	// 合集中所有地图都已下载时视为已安装
+	for i, item := range registered {
+		if item.Type == WorkshopTypeCollection {
+			workshop[i].Installed = len(item.Children) > 0
+			for _, child := range item.Children {
+				if !installedIDs[child] {
+					workshop[i].Installed = false
+					break
+				}
+			}
+		} else {
+			workshop[i].Installed = installedIDs[item.ID]
+		}
+	}
+	extra = append(extra, workshop...)
+
+	sort.Slice(extra, func(i, j int) bool {
+		if extra[i].Workshop != extra[j].Workshop {
+			return !extra[i].Workshop
+		}
+		return extra[i].InternalName < extra[j].InternalName
+	})
+
+	return append(catalog, extra...), scanErr
+}
+
+// FilterMapCatalog 按分类筛选地图目录，class 为空时返回全部
+func FilterMapCatalog(catalog []MapCatalogEntry, class string) []MapCatalogEntry {
+	if class == "" {
+		return catalog
+	}
+	result := make([]MapCatalogEntry, 0)
+	for _, m := range catalog {
+		var match bool
+		switch class {
+		case "current", "former":
+			match = m.Class == class
+		case "installed":
+			match = m.Installed
+		case "official":
+			match = m.Official
+		case "workshop":
+			match = m.Workshop
+		}
+		if match {
+			result = append(result, m)
+		}
+	}
+	return result
+}
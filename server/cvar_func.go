@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cvarNameRegex cvar 名称允许的字符
+var cvarNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// CvarCheck 单个 cvar 的期望值与实际值比较结果
+type CvarCheck struct {
+	Cvar     string `json:"cvar"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"` // 读取失败的原因，此时 OK 为 false，但不代表与期望值不一致
+
+	err error // 读取失败的原因
+}
+
+// validateCvars 校验 cvar 名称与值，值中不允许出现引号、分号与换行，避免拼接出额外命令
+func validateCvars(cvars map[string]string) error {
+	for name, value := range cvars {
+		if !cvarNameRegex.MatchString(name) {
+			return invalidf("无效的 cvar 名称 %q", name)
+		}
+		if strings.ContainsAny(value, "\";\r\n") {
+			return invalidf("cvar %s 的值包含非法字符", name)
+		}
+	}
+	return nil
+}
+
+// CvarCommand 生成设置 cvar 的命令，值包含空白时加引号
+func CvarCommand(name, value string) string {
+	if value == "" || strings.ContainsAny(value, " \t") {
+		return fmt.Sprintf("%s \"%s\"", name, value)
+	}
+	return name + " " + value
+}
+
+// CvarCommands 按名称排序生成设置一组 cvar 的命令
+func CvarCommands(cvars map[string]string) []string {
+	names := make([]string, 0, len(cvars))
+	for name := range cvars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	commands := make([]string, 0, len(names))
+	for _, name := range names {
+		commands = append(commands, CvarCommand(name, cvars[name]))
+	}
+	return commands
+}
+
+// ParseCvarValue 从控制台对 cvar 查询的响应中解析当前值
+// 兼容 CS2 的 `name = value` 与旧格式 `"name" = "value" ( def. "0" )`
+func ParseCvarValue(name, response string) (string, error) {
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		rest, ok := strings.CutPrefix(line, `"`+name+`"`)
+		if !ok {
+			rest, ok = strings.CutPrefix(line, name)
+		}
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		rest, ok = strings.CutPrefix(rest, "=")
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+
+		if quoted, ok := strings.CutPrefix(rest, `"`); ok {
+			if end := strings.Index(quoted, `"`); end >= 0 {
+				return quoted[:end], nil
+			}
+			return quoted, nil
+		}
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			return fields[0], nil
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("无法从响应中解析 %s 的值: %q", name, strings.TrimSpace(response))
+}
+
+// ReadCvar 通过 RCON 读取 cvar 的当前值
+func ReadCvar(server, name string) (string, error) {
+	response, err := ExecRconCommand(context.Background(), server, name)
+	if err != nil {
+		return "", err
+	}
+	return ParseCvarValue(name, response)
+}
+
+// CvarValuesEqual 比较 cvar 值，数值按数值比较，其余忽略大小写比较
+func CvarValuesEqual(expected, actual string) bool {
+	expected, actual = strings.TrimSpace(expected), strings.TrimSpace(actual)
+	e, errE := strconv.ParseFloat(expected, 64)
+	a, errA := strconv.ParseFloat(actual, 64)
+	if errE == nil && errA == nil {
+		return math.Abs(e-a) < 1e-4
+	}
+	return strings.EqualFold(expected, actual)
+}
+
+// VerifyCvars 逐个读取 cvar 并与期望值比较，结果按名称排序
+func VerifyCvars(server string, expected map[string]string) []CvarCheck {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]CvarCheck, 0, len(names))
+	for _, name := range names {
+		check := CvarCheck{Cvar: name, Expected: expected[name]}
+		actual, err := ReadCvar(server, name)
+		if err != nil {
+			check.Error, check.err = err.Error(), err
+		} else {
+			check.Actual = actual
+			check.OK = CvarValuesEqual(check.Expected, actual)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// failedChecks 返回未通过的检查结果
+func failedChecks(checks []CvarCheck) []CvarCheck {
+	failed := make([]CvarCheck, 0)
+	for _, check := range checks {
+		if !check.OK {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+)
+
+// panelDataPath 生成面板数据目录下的文件路径
+func panelDataPath(elem ...string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("获取当前工作目录失败：%w", err)
+	}
+	parts := append([]string{cwd, config.GlobalConfig.Server.PanelDataDir}, elem...)
+	return filepath.Join(parts...), nil
+}
+
+// loadPanelJSON 读取面板数据目录下的 JSON 文件，文件不存在时返回 false
+func loadPanelJSON(v any, elem ...string) (bool, error) {
+	filePath, err := panelDataPath(elem...)
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("读取文件 %s 失败：%w", filePath, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("解析 JSON 文件 %s 失败：%w", filePath, err)
+	}
+	return true, nil
+}
+
+// savePanelJSON 将数据保存为面板数据目录下的 JSON 文件
+// 先写入临时文件再重命名，避免写入中断导致文件损坏
+func savePanelJSON(v any, elem ...string) error {
+	data, err := marshalPanelJSON(v)
+	if err != nil {
+		return err
+	}
+	return writePanelFile(data, 0644, elem...)
+}
+
+// marshalPanelJSON 按面板数据文件的格式序列化
+func marshalPanelJSON(v any) ([]byte, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化为 JSON 失败：%w", err)
+	}
+	return data, nil
+}
+
+// writePanelFile 原子地写入面板数据目录下的文件
+func writePanelFile(data []byte, perm os.FileMode, elem ...string) error {
+	filePath, err := panelDataPath(elem...)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建目录 %s 失败：%w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败：%w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入 %s 失败：%w", filePath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入 %s 失败：%w", filePath, err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("设置 %s 权限失败：%w", filePath, err)
+	}
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("写入 %s 失败：%w", filePath, err)
+	}
+	return nil
+}
@@ -2,50 +2,345 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/VanVodkaer/CS2Panel/config"
 	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
 )
 
+// recreateVerifyDelay 重建容器后确认新容器仍在运行前的等待时间
+const recreateVerifyDelay = 3 * time.Second
+
+// 面板容器标签
+const (
+	LabelPanelID       = "cs2panel.panel_id"       // 面板 ID
+	LabelServerName    = "cs2panel.server_name"    // 服务器名称（不含前缀）
+	LabelSchemaVersion = "cs2panel.schema_version" // 标签结构版本
+	labelSchemaVersion = "1"
+)
+
+// ErrContainerNotFound 服务器名称没有对应的容器
+var ErrContainerNotFound = errors.New("容器不存在")
+
 // FullName 生成完整的名称 prefix-name
 func FullName(name string) string {
 	return config.GlobalConfig.Docker.Prefix + "-" + name
 }
 
-func GetEnvValue(name string, key string) (string, error) {
-	// 获取所有容器
-	containers, err := docker.Cli.ContainerList(context.Background(), container.ListOptions{
+// PanelLabels 生成面板创建容器时写入的标签
+func PanelLabels(name string) map[string]string {
+	return map[string]string{
+		LabelPanelID:       config.GlobalConfig.Docker.PanelID,
+		LabelServerName:    name,
+		LabelSchemaVersion: labelSchemaVersion,
+	}
+}
+
+// panelFilters 生成按面板标签过滤容器的过滤器，name 不为空时只匹配该服务器
+func panelFilters(name string) filters.Args {
+	args := filters.NewArgs(filters.Arg("label", LabelPanelID+"="+config.GlobalConfig.Docker.PanelID))
+	if name != "" {
+		args.Add("label", LabelServerName+"="+name)
+	}
+	return args
+}
+
+// PanelContainer 面板管理的容器及其服务器名称
+type PanelContainer struct {
+	types.Container
+	ServerName string       `json:"server_name"`     // 服务器名称（不含前缀）
+	Imported   bool         `json:"imported"`        // 是否为通过别名导入的容器
+	Drift      *DriftStatus `json:"drift,omitempty"` // 后台偏移检查的最新结果
+}
+
+// ListPanelContainers 获取本面板管理的所有容器，包括带面板标签的容器与导入的容器
+func ListPanelContainers(ctx context.Context) ([]PanelContainer, error) {
+	labeled, err := docker.Cli.ContainerList(ctx, container.ListOptions{
 		All:     true,
-		Filters: filters.NewArgs(filters.Arg("name", config.GlobalConfig.Docker.Prefix)),
+		Filters: panelFilters(""),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PanelContainer, 0, len(labeled))
+	for _, c := range labeled {
+		result = append(result, PanelContainer{Container: c, ServerName: c.Labels[LabelServerName]})
+	}
+
+	imported, names, err := listAliasedContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range imported {
+		result = append(result, PanelContainer{Container: c, ServerName: names[c.ID], Imported: true})
+	}
+	return result, nil
+}
+
+// ResolveContainer 根据服务器名称查找本面板管理的容器，找不到带标签的容器时查找导入的容器
+func ResolveContainer(ctx context.Context, name string) (types.Container, error) {
+	containers, err := docker.Cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: panelFilters(name),
+	})
+	if err != nil {
+		return types.Container{}, err
+	}
+
+	switch len(containers) {
+	case 0:
+		c, ok, err := resolveAlias(ctx, name)
+		if err != nil {
+			return types.Container{}, err
+		}
+		if !ok {
+			return types.Container{}, fmt.Errorf("未找到服务器 %q 对应的容器: %w", name, ErrContainerNotFound)
+		}
+		return c, nil
+	case 1:
+		return containers[0], nil
+	default:
+		return types.Container{}, conflictf("服务器 %q 对应多个容器", name)
+	}
+}
+
+// GetEnvValue 获取服务器容器的环境变量
+func GetEnvValue(name string, key string) (string, error) {
+	c, err := ResolveContainer(context.Background(), name)
 	if err != nil {
 		return "", err
 	}
 
+	// 获取容器详细信息
+	containerInfo, err := docker.Cli.ContainerInspect(context.Background(), c.ID)
+	if err != nil {
+		return "", err
+	}
+
+	// 解析环境变量 通过=分割
+	for _, env := range containerInfo.Config.Env {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 && parts[0] == key {
+			return parts[1], nil
+		}
+	}
+	return "", fmt.Errorf("未找到环境变量 %q", key)
+}
+
+// migrateContainerLabels 为旧版本按名称前缀创建的容器补充面板标签
+// Docker 不支持修改已有容器的标签，因此通过重建容器完成，运行中的容器会被重启
+// 全部迁移成功后写入标记文件，之后不再执行
+func migrateContainerLabels() error {
+	const marker = "container_labels_v1"
+	markerPath, err := panelDataPath("migrations", marker)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(markerPath); err == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	prefix := config.GlobalConfig.Docker.Prefix + "-"
+	containers, err := docker.Cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("name", prefix)),
+	})
+	if err != nil {
+		return fmt.Errorf("获取容器列表失败: %w", err)
+	}
+
+	var failed int
 	for _, c := range containers {
-		// 检查容器ID是否匹配
-		if strings.TrimPrefix(c.Names[0], "/") == name {
-			// 获取容器详细信息
-			containerInfo, err := docker.Cli.ContainerInspect(context.Background(), c.ID)
-			if err != nil {
-				return "", err
+		// 名称过滤为子串匹配，这里严格要求以前缀开头且使用面板镜像
+		name := strings.TrimPrefix(c.Names[0], "/")
+		if !strings.HasPrefix(name, prefix) || !strings.HasPrefix(c.Image, config.GlobalConfig.Docker.ImageName) {
+			continue
+		}
+		if _, ok := c.Labels[LabelPanelID]; ok {
+			continue
+		}
+
+		serverName := strings.TrimPrefix(name, prefix)
+		_, err := RecreateContainer(ctx, c.ID, func(cfg *container.Config, _ *container.HostConfig) error {
+			if cfg.Labels == nil {
+				cfg.Labels = make(map[string]string)
 			}
+			for k, v := range PanelLabels(serverName) {
+				cfg.Labels[k] = v
+			}
+			return nil
+		})
+		if err != nil {
+			util.Error(fmt.Sprintf("为容器 %s 补充标签失败", name), err)
+			failed++
+			continue
+		}
+		util.Info("已为容器补充面板标签: " + name)
+	}
 
-			// 打印容器的环境变量
-			for _, env := range containerInfo.Config.Env {
-				if strings.HasPrefix(env, key) {
-					// 解析环境变量 通过=分割
-					parts := strings.SplitN(env, "=", 2)
-					if len(parts) == 2 {
-						return parts[1], nil
-					}
-				}
+	if failed > 0 {
+		return fmt.Errorf("%d 个容器迁移失败", failed)
+	}
+	return writePanelFile([]byte(time.Now().Format(time.RFC3339)), 0644, "migrations", marker)
+}
+
+// RecreateContainer 按修改后的配置重建容器
+// 新容器沿用旧容器的名称、挂载与端口；旧容器原本运行时启动新容器并确认其仍在运行，失败时删除新容器并回滚到旧容器；
+// 导入的容器的别名随之指向新容器
+func RecreateContainer(ctx context.Context, id string, mutate func(cfg *container.Config, hostCfg *container.HostConfig) error) (string, error) {
+	info, err := docker.Cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("获取容器信息失败: %w", err)
+	}
+
+	name := strings.TrimPrefix(info.Name, "/")
+	wasRunning := info.State != nil && info.State.Running
+
+	// 复制旧容器配置，主机名由 Docker 重新生成
+	cfg := *info.Config
+	cfg.Hostname = ""
+	hostCfg := *info.HostConfig
+	if err := mutate(&cfg, &hostCfg); err != nil {
+		return "", err
+	}
+
+	// 上次重建中断时可能留下同名的备份容器，会导致改名失败
+	backupName := name + "-backup"
+	if err := removeStaleBackup(ctx, backupName); err != nil {
+		return "", err
+	}
+
+	// 停止旧容器并改名，为新容器腾出名称
+	if wasRunning {
+		if err := docker.Cli.ContainerStop(ctx, info.ID, container.StopOptions{}); err != nil {
+			return "", fmt.Errorf("停止旧容器失败: %w", err)
+		}
+	}
+	if err := docker.Cli.ContainerRename(ctx, info.ID, backupName); err != nil {
+		restoreContainer(ctx, info.ID, "", wasRunning)
+		return "", fmt.Errorf("重命名旧容器失败: %w", err)
+	}
+
+	createResp, err := docker.Cli.ContainerCreate(ctx, &cfg, &hostCfg, nil, nil, name)
+	if err != nil {
+		restoreContainer(ctx, info.ID, name, wasRunning)
+		return "", fmt.Errorf("创建新容器失败: %w", err)
+	}
+
+	// 旧容器原本未运行时新容器同样保持停止，不启动验证
+	if wasRunning {
+		if err := startAndVerify(ctx, createResp.ID); err != nil {
+			if rmErr := docker.Cli.ContainerRemove(ctx, createResp.ID, container.RemoveOptions{Force: true}); rmErr != nil {
+				util.Error("删除启动失败的新容器失败", rmErr)
 			}
+			restoreContainer(ctx, info.ID, name, wasRunning)
+			return "", fmt.Errorf("新容器启动失败, 已回滚: %w", err)
 		}
 	}
-	return "", fmt.Errorf("未找到环境变量 %q", key)
+
+	// 导入的服务器通过别名按容器 ID 查找，新容器没有面板标签，需要把别名指向新容器
+	if err := aliasStore.Replace(info.ID, createResp.ID); err != nil {
+		util.Error(fmt.Sprintf("更新容器 %s 的别名失败", name), err)
+	}
+
+	if err := docker.Cli.ContainerRemove(ctx, info.ID, container.RemoveOptions{}); err != nil {
+		util.Error(fmt.Sprintf("删除旧容器 %s 失败", backupName), err)
+	}
+
+	return createResp.ID, nil
+}
+
+// startAndVerify 启动容器并在短暂等待后确认其仍在运行
+func startAndVerify(ctx context.Context, id string) error {
+	if err := docker.Cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return err
+	}
+
+	time.Sleep(recreateVerifyDelay)
+
+	info, err := docker.Cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	if info.State == nil {
+		return fmt.Errorf("无法获取容器状态")
+	}
+	if !info.State.Running {
+		return fmt.Errorf("容器已退出, 退出码 %d", info.State.ExitCode)
+	}
+	return nil
+}
+
+// removeStaleBackup 删除上次重建中断时留下的已停止的备份容器，备份容器仍在运行时需要人工确认
+func removeStaleBackup(ctx context.Context, backupName string) error {
+	backup, err := docker.Cli.ContainerInspect(ctx, backupName)
+	if errdefs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("检查备份容器 %s 失败: %w", backupName, err)
+	}
+	if backup.State != nil && backup.State.Running {
+		return conflictf("备份容器 %s 仍在运行，请确认后手动删除", backupName)
+	}
+
+	util.Warn(fmt.Sprintf("删除上次重建留下的备份容器 %s", backupName))
+	if err := docker.Cli.ContainerRemove(ctx, backup.ID, container.RemoveOptions{}); err != nil {
+		return fmt.Errorf("删除备份容器 %s 失败: %w", backupName, err)
+	}
+	return nil
+}
+
+// restoreContainer 将旧容器恢复原名，并在其原本运行时重新启动
+func restoreContainer(ctx context.Context, id, name string, start bool) {
+	if name != "" {
+		if err := docker.Cli.ContainerRename(ctx, id, name); err != nil {
+			util.Error("回滚时恢复旧容器名称失败", err)
+		}
+	}
+	if start {
+		if err := docker.Cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+			util.Error("回滚时启动旧容器失败", err)
+		}
+	}
+}
+
+// MergeEnv 将变更合并到 KEY=VALUE 形式的环境变量列表中，保留原有顺序
+func MergeEnv(env []string, changes map[string]string) []string {
+	merged := make([]string, 0, len(env)+len(changes))
+	seen := make(map[string]bool, len(changes))
+	for _, kv := range env {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if value, ok := changes[key]; ok {
+			merged = append(merged, key+"="+value)
+			seen[key] = true
+		} else {
+			merged = append(merged, kv)
+		}
+	}
+
+	// 追加原先不存在的变量，按键排序保证结果稳定
+	var added []string
+	for key := range changes {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		merged = append(merged, key+"="+changes[key])
+	}
+	return merged
 }
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// setEnvMutation 重建时修改环境变量
+func setEnvMutation(cfg *container.Config, _ *container.HostConfig) error {
+	cfg.Env = MergeEnv(cfg.Env, map[string]string{"CS2_MAXPLAYERS": "12"})
+	return nil
+}
+
+// 旧容器未运行时新容器保持停止，上次中断留下的已停止备份容器先被删除
+func TestRecreateStoppedContainer(t *testing.T) {
+	stale := panelContainer("stale", "one", "exited")
+	stale.Names = []string{"/cs2-one-backup"}
+	testDocker.setContainers(panelContainer("c1", "one", "exited"), stale)
+	testDocker.setEnv("c1", "CS2_MAXPLAYERS=10")
+
+	id, err := RecreateContainer(context.Background(), "c1", setEnvMutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	containers, started := testDocker.snapshot()
+	if len(started) != 0 {
+		t.Errorf("旧容器未运行时不应启动任何容器，实际启动了 %v", started)
+	}
+	if len(containers) != 1 || containers[0].ID != id || containers[0].Names[0] != "/cs2-one" || containers[0].State != "created" {
+		t.Fatalf("应只剩下未启动的新容器 cs2-one，实际 %+v", containers)
+	}
+	testDocker.mu.Lock()
+	env := testDocker.env[id]
+	testDocker.mu.Unlock()
+	if !reflect.DeepEqual(env, []string{"CS2_MAXPLAYERS=12"}) {
+		t.Errorf("新容器的环境变量不正确: %v", env)
+	}
+}
+
+// 备份容器仍在运行时拒绝重建，旧容器保持原样
+func TestRecreateRunningBackup(t *testing.T) {
+	backup := panelContainer("backup", "one", "running")
+	backup.Names = []string{"/cs2-one-backup"}
+	original := panelContainer("c1", "one", "running")
+	testDocker.setContainers(original, backup)
+
+	_, err := RecreateContainer(context.Background(), "c1", setEnvMutation)
+	if classifyError(err) != CodeConflict {
+		t.Fatalf("应返回冲突错误，实际 %v", err)
+	}
+
+	containers, _ := testDocker.snapshot()
+	if want := []types.Container{original, backup}; !reflect.DeepEqual(containers, want) {
+		t.Errorf("拒绝重建时不应改动容器，实际 %+v", containers)
+	}
+}
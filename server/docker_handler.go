@@ -10,13 +10,19 @@ import (
 	"github.com/VanVodkaer/CS2Panel/config"
 	"github.com/VanVodkaer/CS2Panel/docker"
 	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/go-connections/nat"
 	"github.com/gin-gonic/gin"
 )
 
+// DockerPingResponse Docker 服务 ping 的响应
+type DockerPingResponse struct {
+	Message string     `json:"message"`
+	Ping    types.Ping `json:"ping"`
+}
+
 // dockerPingHandler 处理 Docker 服务的 ping 请求
 func dockerPingHandler(c *gin.Context) {
 	ping, err := docker.Cli.Ping(context.Background())
@@ -24,22 +30,24 @@ func dockerPingHandler(c *gin.Context) {
 		handleErrorResponse(c, "Docker 服务连接失败", err)
 		return
 	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "Docker 服务正在运行",
-			"ping":    ping,
+		c.JSON(http.StatusOK, DockerPingResponse{
+			Message: "Docker 服务正在运行",
+			Ping:    ping,
 		})
 	}
 
 	util.Info(fmt.Sprintf("Docker 服务正在运行, Ping 信息: %+v", ping))
 }
 
-// dockerImagePullHandler 异步处理拉取 Docker 镜像的请求
+// pullStatus 镜像拉取状态
 var pullStatus sync.Map // key: imageName, value: status(string)
+
+// dockerImagePullHandler 异步处理拉取 Docker 镜像的请求
 func dockerImagePullHandler(c *gin.Context) {
 	imageName := config.GlobalConfig.Docker.ImageName
 	_, loaded := pullStatus.LoadOrStore(imageName, "pulling")
 	if loaded {
-		c.JSON(http.StatusOK, gin.H{"message": "镜像正在拉取中"})
+		c.JSON(http.StatusOK, MessageResponse{Message: "镜像正在拉取中"})
 		return
 	}
 
@@ -71,94 +79,249 @@ func dockerImagePullHandler(c *gin.Context) {
 		util.Info("镜像拉取成功")
 	}()
 
-	c.JSON(http.StatusAccepted, gin.H{
-		"message": "已开始拉取镜像",
+	c.JSON(http.StatusAccepted, MessageResponse{
+		Message: "已开始拉取镜像",
 	})
 }
 
+// ImagePullStatusResponse 镜像拉取状态的响应
+type ImagePullStatusResponse struct {
+	Status string `json:"status"` // not_started、pulling、success 或 failed
+}
+
 // dockerImagePullStatusHandler 处理获取 Docker 镜像拉取状态的请求
 func dockerImagePullStatusHandler(c *gin.Context) {
 	imageName := config.GlobalConfig.Docker.ImageName
 	if status, ok := pullStatus.Load(imageName); ok {
-		c.JSON(http.StatusOK, gin.H{
-			"status": status,
+		c.JSON(http.StatusOK, ImagePullStatusResponse{
+			Status: status.(string),
 		})
 	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "not_started",
+		c.JSON(http.StatusOK, ImagePullStatusResponse{
+			Status: "not_started",
 		})
 	}
 }
 
+// ContainerListResponse 容器列表的响应
+type ContainerListResponse struct {
+	Containers []PanelContainer `json:"containers"`
+}
+
 // dockerContainerListHandler 处理获取 Docker 容器列表的请求
 func dockerContainerListHandler(c *gin.Context) {
-	// 过滤器按容器名称前缀过滤
-	containers, err := docker.Cli.ContainerList(context.Background(), container.ListOptions{
-		All:     true,
-		Filters: filters.NewArgs(filters.Arg("name", config.GlobalConfig.Docker.Prefix)),
-	})
+	containers, err := visibleContainers(c)
 	if err != nil {
 		handleErrorResponse(c, "获取 Docker 容器列表失败", err)
 		return
 	}
 
-	if len(containers) == 0 { // 没有容器，返回一个空的容器列表
-		c.JSON(http.StatusOK, gin.H{
-			"containers": []string{}, // 空列表
-		})
-	} else { // 返回成功响应并包含指定前缀容器列表
-		c.JSON(http.StatusOK, gin.H{
-			"containers": containers,
-		})
-	}
+	c.JSON(http.StatusOK, ContainerListResponse{
+		Containers: containers,
+	})
 
 	util.Info("获取 Docker 容器列表成功")
 }
 
-// dockerContainerCreateHandler 处理创建 Docker 容器的请求
-func dockerContainerCreateHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type ContainerCreateRequest struct {
-		// 容器名称（必填）
-		Name string `json:"name" binding:"required"`
-
-		// 校验参数（可选，默认值为 "1"）
-		STEAMAPPVALIDATE string `json:"steamappvalidate"` // "0" 不校验，"1" 校验
-
-		// 以下参数均为可选，若未提供则使用默认值
-		CS2_PORT             string `json:"cs2_port"`             // 游戏服务器端口，默认值 "27015"
-		CS2_RCON_PORT        string `json:"cs2_rcon_port"`        // RCON 端口，默认值 "27015"
-		TV_PORT              string `json:"tv_port"`              // SourceTV 端口，默认值 "27020"
-		CS2_LAN              string `json:"cs2_lan"`              // "0" 关闭，"1" 开启局域网模式，默认值 "0"
-		CS2_MAXPLAYERS       string `json:"cs2_maxplayers"`       // 最大玩家数
-		CS2_STARTMAP         string `json:"cs2_startmap"`         // 启动地图，例如 "de_inferno"
-		CS2_MAPGROUP         string `json:"cs2_mapgroup"`         // 地图组名称，例如 "mg_active"
-		CS2_SERVERNAME       string `json:"cs2_servername"`       // 服务器名称
-		CS2_RCONPW           string `json:"cs2_rconpw"`           // RCON 密码
-		CS2_PW               string `json:"cs2_pw"`               // 服务器连接密码
-		CS2_CHEATS           string `json:"cs2_cheats"`           // "0" 禁止作弊，"1" 允许作弊，默认值 "0"
-		CS2_TV_ENABLE        string `json:"cs2_tv_enable"`        // "0" 禁用，"1" 启用 SourceTV，默认值 "0"
-		CS2_TV_PW            string `json:"cs2_tv_pw"`            // SourceTV 观看密码
-		CS2_TV_DELAY         string `json:"cs2_tv_delay"`         // SourceTV 延迟，单位为秒
-		CS2_TV_AUTORECORD    string `json:"cs2_tv_autorecord"`    // "0" 禁用，"1" 启用 SourceTV 自动录制，默认值 "0"
-		CS2_BOT_QUOTA        string `json:"cs2_bot_quota"`        // 机器人数量
-		CS2_BOT_DIFFICULTY   string `json:"cs2_bot_difficulty"`   // "0" 最容易，"3" 最难，默认值 "1"
-		CS2_COMPETITIVE_MODE string `json:"cs2_competitive_mode"` // "0" 启用，"1" 禁用比赛模式，默认值 "0"
-		CS2_LOGGING_ENABLED  string `json:"cs2_logging_enabled"`  // "0" 禁用，"1" 启用日志记录，默认值 "1"
-		CS2_GAMEMODE         string `json:"cs2_gamemode"`         // "0" 休闲模式，"1" 竞技模式，默认值 "0"
-		CS2_GAMETYPE         string `json:"cs2_gametype"`         // "0" 普通游戏，"1" 死亡竞赛，默认值 "0"
+// visibleContainers 返回当前用户有权查看的服务器容器，并附加后台偏移检查的结果；没有容器时返回空列表
+func visibleContainers(c *gin.Context) ([]PanelContainer, error) {
+	// 按面板标签过滤，只返回本面板管理的容器
+	containers, err := ListPanelContainers(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]PanelContainer, 0, len(containers))
+	for _, ctr := range containers {
+		if serverAllowed(c, PermContainerView, ctr.ServerName) {
+			visible = append(visible, ctr)
+		}
+	}
+
+	for i := range visible {
+		if status, ok := driftStatuses.Load(visible[i].ServerName); ok {
+			drift := status.(DriftStatus)
+			visible[i].Drift = &drift
+		}
+	}
+	return visible, nil
+}
+
+// ContainerInspectRequest 获取容器详情的请求参数
+type ContainerInspectRequest struct {
+	Name   string `form:"name" binding:"required"`
+	Reveal bool   `form:"reveal"` // 是否返回密码类环境变量的明文，需要 secret.view 权限
+}
+
+// ContainerInspectResponse 容器详情的响应
+type ContainerInspectResponse struct {
+	Name      string                `json:"name"`
+	ID        string                `json:"id"`
+	Image     string                `json:"image"`
+	Created   string                `json:"created"`
+	State     *types.ContainerState `json:"state"`
+	Env       map[string]string     `json:"env"`   // 密码类变量默认隐去
+	Ports     map[string]string     `json:"ports"` // 端口类型 -> 端口
+	Resources ContainerResources    `json:"resources"`
+}
+
+// dockerContainerInspectHandler 处理获取服务器容器详情的请求
+func dockerContainerInspectHandler(c *gin.Context) {
+	var req ContainerInspectRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
 	}
 
+	// 密码类环境变量默认隐去，reveal=true 且有 secret.view 权限时返回明文
+	reveal, ok := revealSecrets(c, req.Name)
+	if !ok {
+		return
+	}
+	resp, err := inspectServer(context.Background(), req.Name, reveal)
+	if err != nil {
+		handleErrorResponse(c, "获取容器详情失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// inspectServer 获取服务器容器详情，reveal 为 false 时隐去密码类环境变量
+func inspectServer(ctx context.Context, name string, reveal bool) (ContainerInspectResponse, error) {
+	ctr, err := ResolveContainer(ctx, name)
+	if err != nil {
+		return ContainerInspectResponse{}, err
+	}
+	info, err := docker.Cli.ContainerInspect(ctx, ctr.ID)
+	if err != nil {
+		return ContainerInspectResponse{}, err
+	}
+
+	env := envMap(info.Config.Env)
+	shownEnv := env
+	if !reveal {
+		shownEnv = redactEnv(env)
+	}
+
+	return ContainerInspectResponse{
+		Name:      name,
+		ID:        info.ID,
+		Image:     info.Config.Image,
+		Created:   info.Created,
+		State:     info.State,
+		Env:       shownEnv,
+		Ports:     detectServerPorts(info, env),
+		Resources: ResourcesFromConfig(info.Config, info.HostConfig),
+	}, nil
+}
+
+// ContainerCreateRequest 创建容器的请求参数
+type ContainerCreateRequest struct {
+	// 容器名称（必填）
+	Name string `json:"name" binding:"required"`
+
+	// 校验参数（可选，默认值为 "1"）
+	STEAMAPPVALIDATE string `json:"steamappvalidate"` // "0" 不校验，"1" 校验
+
+	// 以下参数均为可选，若未提供则使用默认值
+	CS2_PORT             string `json:"cs2_port"`             // 游戏服务器端口，默认值 "27015"
+	CS2_RCON_PORT        string `json:"cs2_rcon_port"`        // RCON 端口，默认值 "27015"
+	TV_PORT              string `json:"tv_port"`              // SourceTV 端口，默认值 "27020"
+	CS2_LAN              string `json:"cs2_lan"`              // "0" 关闭，"1" 开启局域网模式，默认值 "0"
+	CS2_MAXPLAYERS       string `json:"cs2_maxplayers"`       // 最大玩家数
+	CS2_STARTMAP         string `json:"cs2_startmap"`         // 启动地图，例如 "de_inferno"
+	CS2_MAPGROUP         string `json:"cs2_mapgroup"`         // 地图组名称，例如 "mg_active"
+	CS2_SERVERNAME       string `json:"cs2_servername"`       // 服务器名称
+	CS2_RCONPW           string `json:"cs2_rconpw"`           // RCON 密码
+	CS2_PW               string `json:"cs2_pw"`               // 服务器连接密码
+	CS2_CHEATS           string `json:"cs2_cheats"`           // "0" 禁止作弊，"1" 允许作弊，默认值 "0"
+	CS2_TV_ENABLE        string `json:"cs2_tv_enable"`        // "0" 禁用，"1" 启用 SourceTV，默认值 "0"
+	CS2_TV_PW            string `json:"cs2_tv_pw"`            // SourceTV 观看密码
+	CS2_TV_DELAY         string `json:"cs2_tv_delay"`         // SourceTV 延迟，单位为秒
+	CS2_TV_AUTORECORD    string `json:"cs2_tv_autorecord"`    // "0" 禁用，"1" 启用 SourceTV 自动录制，默认值 "0"
+	CS2_BOT_QUOTA        string `json:"cs2_bot_quota"`        // 机器人数量
+	CS2_BOT_DIFFICULTY   string `json:"cs2_bot_difficulty"`   // "0" 最容易，"3" 最难，默认值 "1"
+	CS2_COMPETITIVE_MODE string `json:"cs2_competitive_mode"` // "0" 启用，"1" 禁用比赛模式，默认值 "0"
+	CS2_LOGGING_ENABLED  string `json:"cs2_logging_enabled"`  // "0" 禁用，"1" 启用日志记录，默认值 "1"
+	CS2_GAMEMODE         string `json:"cs2_gamemode"`         // "0" 休闲模式，"1" 竞技模式，默认值 "0"
+	CS2_GAMETYPE         string `json:"cs2_gametype"`         // "0" 普通游戏，"1" 死亡竞赛，默认值 "0"
+
+	// 创意工坊（可选），可填写 ID 或登记的名称，设置后启动时加载对应的创意工坊地图或合集
+	CS2_HOST_WORKSHOP_MAP        string `json:"cs2_host_workshop_map"`
+	CS2_HOST_WORKSHOP_COLLECTION string `json:"cs2_host_workshop_collection"`
+
+	// 资源限制与重启策略（可选）
+	Resources *ContainerResources `json:"resources"`
+}
+
+// ContainerResponse 创建、更新或导入容器的响应
+type ContainerResponse struct {
+	Message     string `json:"message"`
+	ContainerID string `json:"container_id"`
+	Warning     string `json:"warning,omitempty"` // 容器已创建但附带操作失败时的提示
+}
+
+// dockerContainerCreateHandler 处理创建 Docker 容器的请求
+func dockerContainerCreateHandler(c *gin.Context) {
 	// 从请求中解析参数
 	var req ContainerCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
+	resp, err := createServer(context.Background(), req)
+	if err != nil {
+		handleErrorResponse(c, "创建容器失败", err)
+		return
+	}
+
+	// 返回容器创建成功的消息和容器 ID
+	c.JSON(http.StatusOK, resp)
+}
+
+// createServer 按请求参数创建服务器容器，创建后保持停止
+func createServer(ctx context.Context, req ContainerCreateRequest) (ContainerResponse, error) {
+	if req.Resources != nil {
+		if err := req.Resources.Validate(ctx); err != nil {
+			return ContainerResponse{}, err
+		}
+	}
+
+	// 创意工坊名称解析为 ID，地图与合集不能混用
+	for _, field := range []struct {
+		value *string
+		typ   string
+	}{
+		{&req.CS2_HOST_WORKSHOP_MAP, WorkshopTypeMap},
+		{&req.CS2_HOST_WORKSHOP_COLLECTION, WorkshopTypeCollection},
+	} {
+		if *field.value == "" {
+			continue
+		}
+		item, ok, err := ResolveWorkshopItem(ctx, *field.value)
+		if err == nil && !ok {
+			err = fmt.Errorf("%w: %s", ErrWorkshopNotFound, *field.value)
+		}
+		if err == nil {
+			err = CheckWorkshopType(ctx, item, field.typ)
+		}
+		if err != nil {
+			return ContainerResponse{}, err
+		}
+		*field.value = item.ID
+	}
+
+	// 同一服务器名称只能对应一个容器
+	if _, err := ResolveContainer(ctx, req.Name); err == nil {
+		return ContainerResponse{}, conflictf("服务器 %q 已存在", req.Name)
+	}
+
 	// 定义容器的创建配置
 	containerConfig := &container.Config{
-		Image: config.GlobalConfig.Docker.ImageName,
+		Image:  config.GlobalConfig.Docker.ImageName,
+		Labels: PanelLabels(req.Name),
 		ExposedPorts: nat.PortSet{
 			nat.Port(fmt.Sprintf("%s/tcp", util.DefaultIfEmpty(req.CS2_RCON_PORT, "27015"))): {},
 			nat.Port(fmt.Sprintf("%s/udp", util.DefaultIfEmpty(req.CS2_PORT, "27015"))):      {},
@@ -190,6 +353,8 @@ func dockerContainerCreateHandler(c *gin.Context) {
 			fmt.Sprintf("CS2_LOGGING_ENABLED=%s", util.DefaultIfEmpty(req.CS2_LOGGING_ENABLED, "1")),
 			fmt.Sprintf("CS2_GAMEMODE=%s", util.DefaultIfEmpty(req.CS2_GAMEMODE, "0")),
 			fmt.Sprintf("CS2_GAMETYPE=%s", util.DefaultIfEmpty(req.CS2_GAMETYPE, "0")),
+			fmt.Sprintf("CS2_HOST_WORKSHOP_MAP=%s", req.CS2_HOST_WORKSHOP_MAP),
+			fmt.Sprintf("CS2_HOST_WORKSHOP_COLLECTION=%s", req.CS2_HOST_WORKSHOP_COLLECTION),
 		},
 	}
 
@@ -209,233 +374,357 @@ func dockerContainerCreateHandler(c *gin.Context) {
 			fmt.Sprintf("%s:/home/steam/cs2-dedicated", config.GlobalConfig.Docker.VolumeName),
 		},
 	}
+	if req.Resources != nil {
+		req.Resources.Apply(containerConfig, hostConfig)
+	}
 
 	// 创建容器
-	createResp, err := docker.Cli.ContainerCreate(context.Background(), containerConfig, hostConfig, nil, nil, FullName(req.Name))
+	createResp, err := docker.Cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, FullName(req.Name))
 	if err != nil {
-		handleErrorResponse(c, "创建容器失败", err)
-		return
-	} else {
-		// 返回容器创建成功的消息和容器 ID
-		c.JSON(200, gin.H{
-			"message":      "容器创建成功",
-			"container_id": createResp.ID,
-		})
+		return ContainerResponse{}, err
+	}
+
+	resp := ContainerResponse{
+		Message:     "容器创建成功",
+		ContainerID: createResp.ID,
+	}
+	// 使用自定义地图组时确保 gamemodes_server.txt 已写入游戏卷（首个服务器创建前无法写入）
+	if _, err := mapGroupStore.Get(req.CS2_MAPGROUP); err == nil {
+		if err := SyncGameModesServer(ctx); err != nil {
+			util.Warn("写入 gamemodes_server.txt 失败: " + err.Error())
+			resp.Warning = "写入 gamemodes_server.txt 失败: " + err.Error()
+		}
 	}
 
 	util.Info("容器创建成功 容器 ID: " + createResp.ID)
+	return resp, nil
 }
 
-// dockerContainerStartHandler 处理启动一个或多个 Docker 容器的请求，并可选地执行命令
-func dockerContainerStartHandler(c *gin.Context) {
-	// 请求参数结构体：name 或 names 至少提供其一；cmds 可选
-	type ContainerStartRequest struct {
-		Name  string   `json:"name"`
-		Names []string `json:"names"`
-		Cmds  []string `json:"cmds"`
-	}
+// updatableEnvKeys 允许通过更新接口修改的环境变量
+// 端口相关变量与端口映射绑定，重建时保持不变，因此不在此列
+var updatableEnvKeys = map[string]bool{
+	"STEAMAPPVALIDATE":     true,
+	"CS2_LAN":              true,
+	"CS2_MAXPLAYERS":       true,
+	"CS2_STARTMAP":         true,
+	"CS2_MAPGROUP":         true,
+	"CS2_SERVERNAME":       true,
+	"CS2_RCONPW":           true,
+	"CS2_PW":               true,
+	"CS2_CHEATS":           true,
+	"CS2_TV_ENABLE":        true,
+	"CS2_TV_PW":            true,
+	"CS2_TV_DELAY":         true,
+	"CS2_TV_AUTORECORD":    true,
+	"CS2_BOT_QUOTA":        true,
+	"CS2_BOT_DIFFICULTY":   true,
+	"CS2_COMPETITIVE_MODE": true,
+	"CS2_LOGGING_ENABLED":  true,
+	"CS2_GAMEMODE":         true,
+	"CS2_GAMETYPE":         true,
+
+	"CS2_HOST_WORKSHOP_MAP":        true,
+	"CS2_HOST_WORKSHOP_COLLECTION": true,
+}
 
-	var req ContainerStartRequest
+// ContainerUpdateRequest 修改服务器设置的请求参数：env 与 resources 至少提供其一
+type ContainerUpdateRequest struct {
+	Name      string              `json:"name" binding:"required"`
+	Env       map[string]string   `json:"env"`       // 需要修改的环境变量，例如 {"CS2_MAXPLAYERS": "12"}
+	Resources *ContainerResources `json:"resources"` // 需要修改的资源限制与重启策略
+}
+
+// dockerContainerUpdateHandler 处理修改服务器设置的请求，通过重建容器使新的环境变量与资源限制生效
+func dockerContainerUpdateHandler(c *gin.Context) {
+	var req ContainerUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
-	// 收集待处理的容器名称
-	var targets []string
-	if len(req.Names) > 0 {
-		targets = req.Names
-	} else if req.Name != "" {
-		targets = []string{req.Name}
-	} else {
-		handleErrorResponse(c, "必须提供 name 或 names 参数", nil)
+	id, err := updateServer(context.Background(), req.Name, req.Env, req.Resources)
+	if err != nil {
+		handleErrorResponse(c, fmt.Sprintf("更新容器 %s 失败", req.Name), err)
 		return
 	}
 
-	// 启动成功的容器列表，以及每个容器的命令执行结果
-	var started []string
-	results := make(map[string][]string)
+	c.JSON(http.StatusOK, ContainerResponse{
+		Message:     "容器更新成功",
+		ContainerID: id,
+	})
+}
 
-	for _, name := range targets {
-		fullName := FullName(name)
-		// 启动容器
-		if err := docker.Cli.ContainerStart(context.Background(), fullName, container.StartOptions{}); err != nil {
-			handleErrorResponse(c, fmt.Sprintf("启动容器 %s 失败", name), err)
-			return
+// updateServer 合并环境变量与资源限制的修改并重建服务器容器，返回新容器 ID
+func updateServer(ctx context.Context, name string, env map[string]string, resources *ContainerResources) (string, error) {
+	if len(env) == 0 && resources == nil {
+		return "", invalidf("必须提供 env 或 resources 参数")
+	}
+	for key := range env {
+		if !updatableEnvKeys[key] {
+			return "", invalidf("不支持修改环境变量 %s", key)
 		}
-		util.Info(fmt.Sprintf("容器启动成功 容器 ID: %s", name))
-		started = append(started, name)
-
-		// 如果传入了 cmds，则对该容器执行命令
-		if len(req.Cmds) > 0 {
-			responses, err := ExecRconCommands(fullName, req.Cmds)
-			if err != nil {
-				handleErrorResponse(c, fmt.Sprintf("在容器 %s 中执行命令失败", name), err)
-				return
-			}
-			util.Info(fmt.Sprintf("执行命令成功 容器: %s 命令: %v 响应: %v", name, req.Cmds, responses))
-			results[name] = responses
+	}
+	if resources != nil {
+		if err := resources.Validate(ctx); err != nil {
+			return "", err
 		}
 	}
 
-	// 根据是否执行命令，返回不同结构的 JSON
-	if len(req.Cmds) > 0 {
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "容器启动并执行命令成功",
-			"started":   started,
-			"responses": results,
-		})
-	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "容器启动成功",
-			"started": started,
-		})
+	ctr, err := ResolveContainer(ctx, name)
+	if err != nil {
+		return "", err
 	}
+
+	// 读取当前环境变量并合并修改后重建容器
+	id, err := RecreateContainer(ctx, ctr.ID, func(cfg *container.Config, hostCfg *container.HostConfig) error {
+		cfg.Env = MergeEnv(cfg.Env, env)
+		if resources != nil {
+			resources.Apply(cfg, hostCfg)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// 密码等信息可能已变更，丢弃旧的 RCON 连接
+	rconPool.Remove(name)
+
+	util.Info(fmt.Sprintf("容器更新成功 容器: %s 新容器 ID: %s", name, id))
+	return id, nil
 }
 
-// dockerContainerStopHandler 处理停止一个或多个 Docker 容器的请求
-func dockerContainerStopHandler(c *gin.Context) {
-	// 请求参数结构体：name 或 names 至少提供其一
-	type ContainerStopRequest struct {
-		Name  string   `json:"name"`
-		Names []string `json:"names"`
+// ImportCandidatesResponse 可导入容器列表的响应
+type ImportCandidatesResponse struct {
+	Candidates []ImportCandidate `json:"candidates"`
+}
+
+// dockerContainerImportCandidatesHandler 处理获取可导入的 CS2 容器列表的请求
+func dockerContainerImportCandidatesHandler(c *gin.Context) {
+	candidates, err := FindImportCandidates(context.Background())
+	if err != nil {
+		handleErrorResponse(c, "获取可导入容器列表失败", err)
+		return
 	}
 
-	var req ContainerStopRequest
+	c.JSON(http.StatusOK, ImportCandidatesResponse{
+		Candidates: candidates,
+	})
+}
+
+// ContainerImportRequest 导入容器的请求参数
+type ContainerImportRequest struct {
+	ID   string `json:"id" binding:"required"`   // 容器 ID 或容器名称
+	Name string `json:"name" binding:"required"` // 导入后在面板中使用的服务器名称
+}
+
+// dockerContainerImportHandler 处理将已有容器导入面板的请求，只登记别名，不重建容器
+func dockerContainerImportHandler(c *gin.Context) {
+	var req ContainerImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
-	// 收集待停止的容器名称
-	var targets []string
-	if len(req.Names) > 0 {
-		targets = req.Names
-	} else if req.Name != "" {
-		targets = []string{req.Name}
-	} else {
-		handleErrorResponse(c, "必须提供 name 或 names 参数", nil)
+	id, err := ImportContainer(context.Background(), req.ID, req.Name)
+	if err != nil {
+		handleErrorResponse(c, "导入容器失败", err)
 		return
 	}
 
-	// 存放已成功停止的容器列表
-	var stopped []string
+	c.JSON(http.StatusOK, ContainerResponse{
+		Message:     "容器导入成功",
+		ContainerID: id,
+	})
+
+	util.Info(fmt.Sprintf("容器导入成功 服务器: %s 容器 ID: %s", req.Name, id))
+}
 
-	for _, name := range targets {
-		fullName := FullName(name)
-		// 停止容器
-		if err := docker.Cli.ContainerStop(context.Background(), fullName, container.StopOptions{}); err != nil {
-			handleErrorResponse(c, fmt.Sprintf("停止容器 %s 失败", name), err)
-			return
-		}
-		util.Info(fmt.Sprintf("容器停止成功 容器 ID: %s", name))
-		stopped = append(stopped, name)
+// ContainerImportReleaseRequest 取消导入的请求参数
+type ContainerImportReleaseRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// dockerContainerImportReleaseHandler 处理取消导入的请求，只删除别名，不影响容器本身
+func dockerContainerImportReleaseHandler(c *gin.Context) {
+	var req ContainerImportReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := aliasStore.Delete(req.Name); err != nil {
+		handleErrorResponse(c, "取消导入失败", err)
+		return
 	}
+	rconPool.Remove(req.Name)
 
-	// 返回停止成功的消息和列表
-	c.JSON(http.StatusOK, gin.H{
-		"message": "容器停止成功",
-		"stopped": stopped,
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "已取消导入",
 	})
+
+	util.Info("已取消导入 服务器: " + req.Name)
 }
 
-// dockerContainerRestartHandler 处理重启一个或多个 Docker 容器的请求
-func dockerContainerRestartHandler(c *gin.Context) {
-	// 请求参数结构体：name 或 names 至少提供其一
-	type ContainerRestartRequest struct {
-		Name  string   `json:"name"`
-		Names []string `json:"names"`
-	}
+// ContainerStartRequest 启动容器的请求参数：name 或 names 至少提供其一；cmds 可选
+type ContainerStartRequest struct {
+	BatchRequest
+	Cmds []string `json:"cmds"`
+}
+
+// ContainerStartResponse 启动容器的响应
+type ContainerStartResponse struct {
+	Message   string              `json:"message"`
+	Started   []string            `json:"started"`
+	Results   []BatchResult       `json:"results"`
+	Responses map[string][]string `json:"responses,omitempty"` // 每个容器的命令执行结果，仅提供 cmds 时返回
+}
+
+// ContainerStopResponse 停止容器的响应
+type ContainerStopResponse struct {
+	Message string        `json:"message"`
+	Stopped []string      `json:"stopped"`
+	Results []BatchResult `json:"results"`
+}
+
+// ContainerRestartResponse 重启容器的响应
+type ContainerRestartResponse struct {
+	Message   string        `json:"message"`
+	Restarted []string      `json:"restarted"`
+	Results   []BatchResult `json:"results"`
+}
 
-	// 解析请求体
-	var req ContainerRestartRequest
+// ContainerRemoveResponse 删除容器的响应
+type ContainerRemoveResponse struct {
+	Message string        `json:"message"`
+	Removed []string      `json:"removed"`
+	Results []BatchResult `json:"results"`
+}
+
+// dockerContainerStartHandler 处理启动一个或多个 Docker 容器的请求，并可选地执行命令
+// 每个容器单独处理，返回逐个容器的结果，存在失败时返回 207
+func dockerContainerStartHandler(c *gin.Context) {
+	var req ContainerStartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	targets, err := req.Targets()
+	if err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
-	// 收集待重启的容器名称
-	var targets []string
-	if len(req.Names) > 0 {
-		targets = req.Names
-	} else if req.Name != "" {
-		targets = []string{req.Name}
-	} else {
-		handleErrorResponse(c, "必须提供 name 或 names 参数", nil)
+	// 启动后执行的命令是任意 RCON 命令，还需要 rcon.exec 权限
+	if len(req.Cmds) > 0 && !requireServers(c, PermRconExec, targets) {
 		return
 	}
 
-	// 存放已成功重启的容器列表
-	var restarted []string
+	results := runBatch(targets, req.Parallel, func(name string) BatchResult {
+		return startServer(c.Request.Context(), name, req.Cmds)
+	})
 
-	for _, name := range targets {
-		fullName := FullName(name)
-		// 重启容器（如需传超时时间可自行拓展 RestartOptions）
-		if err := docker.Cli.ContainerRestart(context.Background(), fullName, container.StopOptions{}); err != nil {
-			handleErrorResponse(c, fmt.Sprintf("重启容器 %s 失败", name), err)
-			return
+	// 每个容器的命令执行结果
+	responses := make(map[string][]string)
+	for _, r := range results {
+		if r.Responses != nil {
+			responses[r.Name] = r.Responses
 		}
-		util.Info(fmt.Sprintf("容器重启成功 容器 ID: %s", name))
-		restarted = append(restarted, name)
 	}
 
-	// 返回重启成功的消息和列表
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "容器重启成功",
-		"restarted": restarted,
-	})
+	status := batchStatusCode(results)
+	message := "容器启动成功"
+	if status != http.StatusOK {
+		message = "部分容器启动失败"
+	}
+	body := ContainerStartResponse{
+		Message: message,
+		Started: succeededNames(results),
+		Results: results,
+	}
+	if len(req.Cmds) > 0 {
+		body.Responses = responses
+	}
+	c.JSON(status, body)
 }
 
-// dockerContainerRemoveHandler 处理删除 Docker 容器的请求
-func dockerContainerRemoveHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type ContainerRemoveRequest struct {
-		Name  string   `json:"name"`
-		Names []string `json:"names"`
+// dockerContainerStopHandler 处理停止一个或多个 Docker 容器的请求
+func dockerContainerStopHandler(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	targets, err := req.Targets()
+	if err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
 	}
 
-	// 从请求中解析参数
-	var req ContainerRemoveRequest
+	results := runBatch(targets, req.Parallel, stopServer)
+
+	status := batchStatusCode(results)
+	message := "容器停止成功"
+	if status != http.StatusOK {
+		message = "部分容器停止失败"
+	}
+	c.JSON(status, ContainerStopResponse{
+		Message: message,
+		Stopped: succeededNames(results),
+		Results: results,
+	})
+}
+
+// dockerContainerRestartHandler 处理重启一个或多个 Docker 容器的请求
+func dockerContainerRestartHandler(c *gin.Context) {
+	var req BatchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-
-	// 收集待删除的容器名称
-	var targets []string
-	if len(req.Names) > 0 {
-		targets = req.Names
-	} else if req.Name != "" {
-		targets = []string{req.Name}
-	} else {
-		handleErrorResponse(c, "必须提供 name 或 names 参数", nil)
+	targets, err := req.Targets()
+	if err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
-	// 存放已成功删除的容器列表
-	var removed []string
+	results := runBatch(targets, req.Parallel, restartServer)
 
-	for _, name := range targets {
-		fullName := FullName(name)
-		// 先停止容器
-		if err := docker.Cli.ContainerStop(context.Background(), fullName, container.StopOptions{}); err != nil {
-			handleErrorResponse(c, fmt.Sprintf("停止容器 %s 失败", name), err)
-			return
-		}
-		// 删除容器
-		if err := docker.Cli.ContainerRemove(context.Background(), fullName, container.RemoveOptions{}); err != nil {
-			handleErrorResponse(c, fmt.Sprintf("删除容器 %s 失败", name), err)
-			return
-		}
-		util.Info(fmt.Sprintf("容器删除成功 容器 ID: %s", name))
-		removed = append(removed, name)
+	status := batchStatusCode(results)
+	message := "容器重启成功"
+	if status != http.StatusOK {
+		message = "部分容器重启失败"
 	}
-
-	// 返回删除成功的消息和列表
-	c.JSON(http.StatusOK, gin.H{
-		"message": "容器删除成功",
-		"removed": removed,
+	c.JSON(status, ContainerRestartResponse{
+		Message:   message,
+		Restarted: succeededNames(results),
+		Results:   results,
 	})
+}
+
+// dockerContainerRemoveHandler 处理删除一个或多个 Docker 容器的请求
+func dockerContainerRemoveHandler(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	targets, err := req.Targets()
+	if err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
 
-	util.Info("容器删除成功 容器 ID: " + req.Name)
+	results := runBatch(targets, req.Parallel, removeServer)
+
+	status := batchStatusCode(results)
+	message := "容器删除成功"
+	if status != http.StatusOK {
+		message = "部分容器删除失败"
+	}
+	c.JSON(status, ContainerRemoveResponse{
+		Message: message,
+		Removed: succeededNames(results),
+		Results: results,
+	})
 }
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+)
+
+// ErrBaselineNotSet 服务器未设置期望的 cvar 基线
+var ErrBaselineNotSet = errors.New("未设置 cvar 基线")
+
+// baselineFile cvar 基线在面板数据目录下的文件名
+const baselineFile = "baselines.json"
+
+// BaselineStore 保存每个服务器期望的 cvar 基线：服务器名称 -> cvar -> 值
+type BaselineStore struct {
+	mu sync.Mutex
+}
+
+// 全局基线存储
+var baselineStore = &BaselineStore{}
+
+// load 读取所有基线，调用方需持有锁
+func (s *BaselineStore) load() (map[string]map[string]string, error) {
+	baselines := make(map[string]map[string]string)
+	if _, err := loadPanelJSON(&baselines, baselineFile); err != nil {
+		return nil, err
+	}
+	return baselines, nil
+}
+
+// Get 获取服务器的基线
+func (s *BaselineStore) Get(server string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return baselines[server], nil
+}
+
+// Set 保存服务器的基线，cvars 为空时删除
+func (s *BaselineStore) Set(server string, cvars map[string]string) error {
+	if err := validateCvars(cvars); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	baselines, err := s.load()
+	if err != nil {
+		return err
+	}
+	if len(cvars) == 0 {
+		delete(baselines, server)
+	} else {
+		baselines[server] = cvars
+	}
+	return savePanelJSON(baselines, baselineFile)
+}
+
+// DriftReport 服务器当前 cvar 与基线的比较结果
+type DriftReport struct {
+	Server    string      `json:"server"`
+	CheckedAt time.Time   `json:"checked_at"`
+	Drifted   bool        `json:"drifted"`
+	Diff      []CvarCheck `json:"diff"`   // 与基线不一致的 cvar
+	Errors    []CvarCheck `json:"errors"` // 读取失败、无法判断是否一致的 cvar
+	Checks    []CvarCheck `json:"checks"` // 全部 cvar 的比较结果
+}
+
+// DriftStatus 容器列表中展示的偏移摘要
+type DriftStatus struct {
+	Drifted   bool      `json:"drifted"`
+	Count     int       `json:"count"` // 不一致的 cvar 数量，不含读取失败的 cvar
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"` // 检查失败或部分 cvar 读取失败的原因
+}
+
+// splitDriftChecks 把未通过的检查分为与基线不一致与读取失败两类
+func splitDriftChecks(checks []CvarCheck) (diff, errored []CvarCheck) {
+	diff, errored = make([]CvarCheck, 0), make([]CvarCheck, 0)
+	for _, check := range failedChecks(checks) {
+		if check.Error != "" {
+			errored = append(errored, check)
+		} else {
+			diff = append(diff, check)
+		}
+	}
+	return diff, errored
+}
+
+// driftStatuses 后台检查得到的最新偏移摘要：服务器名称 -> DriftStatus
+var driftStatuses sync.Map
+
+// CheckDrift 通过 RCON 读取基线中每个 cvar 的当前值并与期望值比较
+func CheckDrift(server string) (*DriftReport, error) {
+	baseline, err := baselineStore.Get(server)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseline) == 0 {
+		return nil, fmt.Errorf("服务器 %s %w", server, ErrBaselineNotSet)
+	}
+
+	checks := VerifyCvars(server, baseline)
+	diff, errored := splitDriftChecks(checks)
+	// 全部读取失败时（如 RCON 无法连接）无法判断是否偏移，按检查失败处理
+	if len(errored) == len(checks) {
+		err := fmt.Errorf("读取服务器 %s 的 cvar 失败: %w", server, errored[0].err)
+		driftStatuses.Store(server, DriftStatus{CheckedAt: time.Now(), Error: err.Error()})
+		return nil, err
+	}
+	report := &DriftReport{
+		Server:    server,
+		CheckedAt: time.Now(),
+		Drifted:   len(diff) > 0,
+		Diff:      diff,
+		Errors:    errored,
+		Checks:    checks,
+	}
+
+	status := DriftStatus{Drifted: report.Drifted, Count: len(diff), CheckedAt: report.CheckedAt}
+	if len(errored) > 0 {
+		status.Error = fmt.Sprintf("%d 个 cvar 读取失败: %s", len(errored), errored[0].Error)
+	}
+	driftStatuses.Store(server, status)
+	return report, nil
+}
+
+// ReapplyBaseline 重新设置基线中的全部 cvar 并再次检查
+func ReapplyBaseline(ctx context.Context, server string) (*DriftReport, error) {
+	baseline, err := baselineStore.Get(server)
+	if err != nil {
+		return nil, err
+	}
+	if len(baseline) == 0 {
+		return nil, fmt.Errorf("服务器 %s %w", server, ErrBaselineNotSet)
+	}
+
+	if _, err := ExecRconCommands(ctx, server, CvarCommands(baseline)); err != nil {
+		return nil, err
+	}
+	return CheckDrift(server)
+}
+
+// startDriftChecker 定期检查所有运行中且设置了基线的服务器
+func startDriftChecker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		containers, err := ListPanelContainers(context.Background())
+		if err != nil {
+			util.Error("偏移检查获取容器列表失败", err)
+			continue
+		}
+
+		for _, c := range containers {
+			if c.State != "running" {
+				driftStatuses.Delete(c.ServerName)
+				continue
+			}
+			_, err := CheckDrift(c.ServerName)
+			if errors.Is(err, ErrBaselineNotSet) {
+				driftStatuses.Delete(c.ServerName)
+				continue
+			}
+			if err != nil {
+				driftStatuses.Store(c.ServerName, DriftStatus{CheckedAt: time.Now(), Error: err.Error()})
+				util.Error(fmt.Sprintf("服务器 %s 偏移检查失败", c.ServerName), err)
+			}
+		}
+	}
+}
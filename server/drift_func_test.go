@@ -0,0 +1,37 @@
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplitDriftChecks(t *testing.T) {
+	diff, errored := splitDriftChecks([]CvarCheck{
+		{Cvar: "mp_maxrounds", Expected: "24", Actual: "24", OK: true},
+		{Cvar: "mp_freezetime", Expected: "15", Actual: "5"},
+		{Cvar: "mp_c4timer", Expected: "40", Error: "RCON 连接失败"},
+	})
+	if len(diff) != 1 || diff[0].Cvar != "mp_freezetime" {
+		t.Errorf("不一致的 cvar 应只有 mp_freezetime，实际 %+v", diff)
+	}
+	if len(errored) != 1 || errored[0].Cvar != "mp_c4timer" {
+		t.Errorf("读取失败的 cvar 应只有 mp_c4timer，实际 %+v", errored)
+	}
+}
+
+// 全部 cvar 读取失败时按检查失败处理，不显示为偏移
+func TestCheckDriftUnreachable(t *testing.T) {
+	testDocker.setContainers()
+	if err := baselineStore.Set("drift-down", map[string]string{"mp_maxrounds": "24", "mp_freezetime": "15"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := CheckDrift("drift-down")
+	if err == nil || !errors.Is(err, ErrContainerNotFound) {
+		t.Fatalf("应返回读取失败的原因，实际 report=%+v err=%v", report, err)
+	}
+	v, _ := driftStatuses.Load("drift-down")
+	if status := v.(DriftStatus); status.Drifted || status.Count != 0 || status.Error == "" {
+		t.Errorf("偏移摘要应为检查失败，实际 %+v", status)
+	}
+}
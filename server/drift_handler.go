@@ -0,0 +1,147 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RconGameDriftRequest 检查服务器 cvar 与基线差异的请求参数
+type RconGameDriftRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// DriftReportResponse 检查配置偏移的响应
+type DriftReportResponse struct {
+	Report *DriftReport `json:"report"`
+}
+
+// rconGameDriftHandler 处理检查服务器 cvar 与基线差异的请求
+func rconGameDriftHandler(c *gin.Context) {
+	var req RconGameDriftRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	report, err := CheckDrift(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "检查配置偏移失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DriftReportResponse{
+		Report: report,
+	})
+}
+
+// RconGameDriftBaselineGetRequest 获取服务器 cvar 基线的请求参数
+type RconGameDriftBaselineGetRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// DriftBaselineResponse 基线的响应
+type DriftBaselineResponse struct {
+	Message string            `json:"message,omitempty"`
+	Cvars   map[string]string `json:"cvars"`
+}
+
+// rconGameDriftBaselineGetHandler 处理获取服务器 cvar 基线的请求
+func rconGameDriftBaselineGetHandler(c *gin.Context) {
+	var req RconGameDriftBaselineGetRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	baseline, err := baselineStore.Get(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取基线失败", err)
+		return
+	}
+	if baseline == nil {
+		baseline = map[string]string{}
+	}
+
+	c.JSON(http.StatusOK, DriftBaselineResponse{
+		Cvars: baseline,
+	})
+}
+
+// RconGameDriftBaselineSetRequest 设置服务器 cvar 基线的请求参数
+type RconGameDriftBaselineSetRequest struct {
+	Name   string            `json:"name" binding:"required"`
+	Preset string            `json:"preset"`
+	Cvars  map[string]string `json:"cvars"` // 为空且不提供 preset 时删除基线
+}
+
+// rconGameDriftBaselineSetHandler 处理设置服务器 cvar 基线的请求
+// 提供 preset 时以预设的期望值作为基线，cvars 中的值覆盖预设中的同名项
+func rconGameDriftBaselineSetHandler(c *gin.Context) {
+	var req RconGameDriftBaselineSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	cvars := make(map[string]string)
+	if req.Preset != "" {
+		preset, err := presetStore.Get(req.Preset)
+		if err != nil {
+			handleErrorResponse(c, "获取预设失败", err)
+			return
+		}
+		cvars = preset.ExpectedCvars()
+	}
+	for name, value := range req.Cvars {
+		cvars[name] = value
+	}
+
+	if err := baselineStore.Set(req.Name, cvars); err != nil {
+		handleErrorResponse(c, "保存基线失败", err)
+		return
+	}
+	driftStatuses.Delete(req.Name)
+
+	c.JSON(http.StatusOK, DriftBaselineResponse{
+		Message: "保存基线成功",
+		Cvars:   cvars,
+	})
+
+	util.Info(fmt.Sprintf("保存基线成功 服务器: %s cvar 数量: %d", req.Name, len(cvars)))
+}
+
+// RconGameDriftReapplyRequest 重新应用基线的请求参数
+type RconGameDriftReapplyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// DriftReapplyResponse 重新应用基线的响应
+type DriftReapplyResponse struct {
+	Message string       `json:"message"`
+	Report  *DriftReport `json:"report"` // 应用后的检查结果
+}
+
+// rconGameDriftReapplyHandler 处理重新应用基线的请求
+func rconGameDriftReapplyHandler(c *gin.Context) {
+	var req RconGameDriftReapplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	report, err := ReapplyBaseline(c.Request.Context(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "重新应用基线失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, DriftReapplyResponse{
+		Message: "重新应用基线成功",
+		Report:  report,
+	})
+
+	util.Info(fmt.Sprintf("重新应用基线 服务器: %s 仍不一致: %d", req.Name, len(report.Diff)))
+}
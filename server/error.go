@@ -1,18 +1,275 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/gin-gonic/gin"
 )
 
-// handleErrorResponse 处理错误响应，记录错误日志并返回 500 错误给客户端
+// ErrorCode 稳定的错误码，客户端应据此判断错误类型，而不是依赖错误消息
+type ErrorCode string
+
+// 错误码
+const (
+	CodeInvalidRequest  ErrorCode = "invalid_request"     // 请求参数无效
+	CodeUnauthorized    ErrorCode = "unauthorized"        // 未登录、登录过期或凭据错误
+	CodeForbidden       ErrorCode = "forbidden"           // 没有权限
+	CodeRconDenied      ErrorCode = "rcon_command_denied" // RCON 命令被策略拒绝
+	CodeNotFound        ErrorCode = "not_found"           // 资源不存在
+	CodeConflict        ErrorCode = "conflict"            // 与当前状态冲突，如名称已存在、服务器未运行
+	CodeRateLimited     ErrorCode = "rate_limited"        // 请求过于频繁
+	CodeUpstreamError   ErrorCode = "upstream_error"      // Docker、RCON 或外部服务请求失败
+	CodeUpstreamTimeout ErrorCode = "upstream_timeout"    // Docker、RCON 或外部服务超时
+	CodeInternal        ErrorCode = "internal_error"      // 面板内部错误
+)
+
+const (
+	// requestIDHeader 请求 ID 的请求头与响应头
+	requestIDHeader = "X-Request-ID"
+	// contextRequestIDKey gin.Context 中保存请求 ID 的键
+	contextRequestIDKey = "request_id"
+)
+
+// errorCodeStatus 错误码对应的 HTTP 状态
+var errorCodeStatus = map[ErrorCode]int{
+	CodeInvalidRequest:  http.StatusBadRequest,
+	CodeUnauthorized:    http.StatusUnauthorized,
+	CodeForbidden:       http.StatusForbidden,
+	CodeRconDenied:      http.StatusForbidden,
+	CodeNotFound:        http.StatusNotFound,
+	CodeConflict:        http.StatusConflict,
+	CodeRateLimited:     http.StatusTooManyRequests,
+	CodeUpstreamError:   http.StatusBadGateway,
+	CodeUpstreamTimeout: http.StatusGatewayTimeout,
+	CodeInternal:        http.StatusInternalServerError,
+}
+
+// errorCodeMessages 错误码的本地化说明：语言 -> 说明
+var errorCodeMessages = map[ErrorCode]map[string]string{
+	CodeInvalidRequest:  {"zh": "请求参数无效", "en": "Invalid request parameters"},
+	CodeUnauthorized:    {"zh": "未登录或登录已过期", "en": "Not logged in or session expired"},
+	CodeForbidden:       {"zh": "没有权限执行该操作", "en": "Permission denied"},
+	CodeRconDenied:      {"zh": "RCON 命令被策略拒绝", "en": "RCON command denied by policy"},
+	CodeNotFound:        {"zh": "资源不存在", "en": "Resource not found"},
+	CodeConflict:        {"zh": "操作与当前状态冲突", "en": "Request conflicts with the current state"},
+	CodeRateLimited:     {"zh": "请求过于频繁，请稍后重试", "en": "Too many requests, please retry later"},
+	CodeUpstreamError:   {"zh": "Docker、RCON 或外部服务请求失败", "en": "Docker, RCON or an external service failed"},
+	CodeUpstreamTimeout: {"zh": "Docker、RCON 或外部服务响应超时", "en": "Docker, RCON or an external service timed out"},
+	CodeInternal:        {"zh": "服务器内部错误", "en": "Internal server error"},
+}
+
+// errorCodeSentinels 已知错误对应的错误码，按顺序匹配
+var errorCodeSentinels = []struct {
+	target error
+	code   ErrorCode
+}{
+	{ErrRconCommandDenied, CodeRconDenied},
+	{ErrForbidden, CodeForbidden},
+	{ErrPathNotAllowed, CodeForbidden},
+	{ErrUnauthorized, CodeUnauthorized},
+	{ErrInvalidCredentials, CodeUnauthorized},
+	{ErrContainerNotFound, CodeNotFound},
+	{ErrUserNotFound, CodeNotFound},
+	{ErrRoleNotFound, CodeNotFound},
+	{ErrTokenNotFound, CodeNotFound},
+	{ErrPresetNotFound, CodeNotFound},
+	{ErrProfileNotFound, CodeNotFound},
+	{ErrMapGroupNotFound, CodeNotFound},
+	{ErrWorkshopNotFound, CodeNotFound},
+	{ErrMapUnknown, CodeNotFound},
+	{ErrMapModeIncompatible, CodeConflict},
+	{ErrMapNotInstalled, CodeConflict},
+	{ErrBaselineNotSet, CodeConflict},
+	{ErrNoVolumeContainer, CodeConflict},
+}
+
+// APIError 带错误码的错误，Error 返回原始错误的消息，errors.Is/As 可以继续匹配原始错误
+type APIError struct {
+	Code ErrorCode
+	Err  error
+}
+
+// Error 返回原始错误的消息
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// badRequest 把请求参数绑定或校验失败的错误标记为 400
+func badRequest(err error) error {
+	return &APIError{Code: CodeInvalidRequest, Err: err}
+}
+
+// invalidf 创建参数无效的错误（400），用法同 fmt.Errorf
+func invalidf(format string, args ...any) error {
+	return &APIError{Code: CodeInvalidRequest, Err: fmt.Errorf(format, args...)}
+}
+
+// conflictf 创建与当前状态冲突的错误（409），如名称已存在、服务器未运行
+func conflictf(format string, args ...any) error {
+	return &APIError{Code: CodeConflict, Err: fmt.Errorf(format, args...)}
+}
+
+// upstreamf 创建 Docker、RCON 或外部服务请求失败的错误（502），原因是超时时为 504
+func upstreamf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if isTimeout(err) {
+		return &APIError{Code: CodeUpstreamTimeout, Err: err}
+	}
+	return &APIError{Code: CodeUpstreamError, Err: err}
+}
+
+// timeoutf 创建等待 Docker、RCON 或外部服务超时的错误（504）
+func timeoutf(format string, args ...any) error {
+	return &APIError{Code: CodeUpstreamTimeout, Err: fmt.Errorf(format, args...)}
+}
+
+// isTimeout 错误是否由超时引起
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// classifyError 判断错误对应的错误码：先看 APIError 与已知错误，再看 Docker 错误与网络超时
+func classifyError(err error) ErrorCode {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	for _, s := range errorCodeSentinels {
+		if errors.Is(err, s.target) {
+			return s.code
+		}
+	}
+
+	if isTimeout(err) {
+		return CodeUpstreamTimeout
+	}
+	switch {
+	case client.IsErrConnectionFailed(err):
+		return CodeUpstreamError
+	case errdefs.IsNotFound(err):
+		return CodeNotFound
+	case errdefs.IsConflict(err):
+		return CodeConflict
+	case errdefs.IsInvalidParameter(err):
+		return CodeInvalidRequest
+	case errdefs.IsDeadline(err):
+		return CodeUpstreamTimeout
+	case errdefs.IsUnavailable(err), errdefs.IsSystem(err), errdefs.IsUnknown(err):
+		return CodeUpstreamError
+	}
+	return CodeInternal
+}
+
+// errorLanguage 按 Accept-Language 选择错误说明的语言，默认中文
+func errorLanguage(c *gin.Context) string {
+	for _, tag := range strings.Split(c.GetHeader("Accept-Language"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case strings.HasPrefix(tag, "zh"):
+			return "zh"
+		case strings.HasPrefix(tag, "en"):
+			return "en"
+		}
+	}
+	return "zh"
+}
+
+// requestIDRegex 接受客户端传入的请求 ID 的格式
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID 为每个请求分配 ID 并写入响应头，客户端传入合法的 X-Request-ID 时沿用
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id, _ = randomToken(12)
+		}
+		c.Set(contextRequestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestID 获取当前请求的 ID
+func requestID(c *gin.Context) string {
+	return c.GetString(contextRequestIDKey)
+}
+
+// ErrorResponse 错误响应
+// Code 为错误码；Error 为处理函数给出的摘要；Message 为错误码的本地化说明；
+// Details 为具体原因，5xx 错误不返回内部细节，可凭 RequestID 在日志中查找
+type ErrorResponse struct {
+	Code       ErrorCode `json:"code"`
+	Error      string    `json:"error"`
+	Message    string    `json:"message"`
+	RequestID  string    `json:"request_id"`
+	Details    string    `json:"details,omitempty"`
+	Permission string    `json:"permission,omitempty"`  // 缺少的权限，仅 forbidden
+	RetryAfter int       `json:"retry_after,omitempty"` // 可重试的秒数，仅 rate_limited
+}
+
+// errorResponse 错误响应的内容
+func errorResponse(c *gin.Context, code ErrorCode, message string, err error) ErrorResponse {
+	resp := ErrorResponse{
+		Code:      code,
+		Error:     message,
+		Message:   errorCodeMessages[code][errorLanguage(c)],
+		RequestID: requestID(c),
+	}
+	if errorCodeStatus[code] < http.StatusInternalServerError {
+		resp.Details = err.Error()
+	}
+	return resp
+}
+
+// handleErrorResponse 按错误类型返回对应的 HTTP 状态与错误码，并记录日志
+// err 为空时按内部错误处理
 func handleErrorResponse(c *gin.Context, message string, err error) {
-	c.JSON(http.StatusInternalServerError, gin.H{
-		"error":   message,
-		"details": err.Error(), // 将错误详情返回给客户端，便于调试
-	})
+	respondError(c, message, err, nil)
+}
+
+// abortWithError 同 handleErrorResponse，并中止后续处理函数，用于中间件
+// extra 不为空时可以补充错误响应的附加字段
+func abortWithError(c *gin.Context, message string, err error, extra func(*ErrorResponse)) {
+	respondError(c, message, err, extra)
+	c.Abort()
+}
+
+// respondError 写入错误响应，extra 用于补充附加字段
+func respondError(c *gin.Context, message string, err error, extra func(*ErrorResponse)) {
+	if err == nil {
+		err = errors.New(message)
+	}
+	_ = c.Error(err) // 供审计日志记录失败原因
+
+	code := classifyError(err)
+	status := errorCodeStatus[code]
+	resp := errorResponse(c, code, message, err)
+	if extra != nil {
+		extra(&resp)
+	}
+	c.JSON(status, resp)
 
-	util.Error(message, err) // 日志记录
+	u, _ := currentUser(c)
+	logMessage := message + " 错误码: " + string(code) + " 路径: " + c.FullPath() + " 用户: " + u.Username + " 请求: " + requestID(c)
+	if status >= http.StatusInternalServerError {
+		util.Error(logMessage, err) // 日志记录
+	} else {
+		util.Warn(logMessage + " 原因: " + err.Error())
+	}
 }
@@ -0,0 +1,370 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	// gameInstallDir 镜像中 CS2 服务器的安装目录（即游戏卷的挂载点）
+	gameInstallDir = "/home/steam/cs2-dedicated"
+	// gameRootDir 文件管理允许访问的根目录
+	gameRootDir = gameInstallDir + "/game/csgo"
+	// maxTextFileSize 在线查看与编辑的文本文件大小上限
+	maxTextFileSize = 1 << 20
+)
+
+// ErrPathNotAllowed 路径超出 game/csgo 目录
+var ErrPathNotAllowed = errors.New("路径超出允许访问的范围")
+
+// FileEntry 目录中的文件信息
+type FileEntry struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"` // 相对于 game/csgo 的路径
+	Type    string    `json:"type"` // file, dir, link, other
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// resolveGamePath 将相对于 game/csgo 的路径转换为容器内的绝对路径
+// 不允许出现 .. 路径段，清理后的路径始终位于 game/csgo 之内
+func resolveGamePath(rel string) (string, error) {
+	if strings.ContainsRune(rel, 0) {
+		return "", ErrPathNotAllowed
+	}
+	for _, seg := range strings.Split(strings.ReplaceAll(rel, "\\", "/"), "/") {
+		if seg == ".." {
+			return "", ErrPathNotAllowed
+		}
+	}
+	abs := path.Join(gameRootDir, path.Clean("/"+rel))
+	if abs != gameRootDir && !strings.HasPrefix(abs, gameRootDir+"/") {
+		return "", ErrPathNotAllowed
+	}
+	return abs, nil
+}
+
+// relGamePath 将容器内的绝对路径转换为相对于 game/csgo 的路径
+func relGamePath(abs string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(abs, gameRootDir), "/")
+}
+
+// checkGamePath 确认路径存在，且从 game/csgo 起的每一级路径都不是指向 game/csgo 之外的符号链接
+// Docker 返回的链接目标已在容器内完全解析，链接指向另一个链接时同样能检查到
+func checkGamePath(ctx context.Context, id, abs string) (container.PathStat, error) {
+	var stat container.PathStat
+	for _, p := range gamePathPrefixes(abs) {
+		var err error
+		if stat, err = docker.Cli.ContainerStatPath(ctx, id, p); err != nil {
+			return stat, err
+		}
+		if stat.LinkTarget != "" {
+			target := stat.LinkTarget
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(p), target)
+			}
+			if target != gameRootDir && !strings.HasPrefix(target, gameRootDir+"/") {
+				return stat, ErrPathNotAllowed
+			}
+		}
+	}
+	return stat, nil
+}
+
+// checkGameWritePath 确认写入目标的上级目录与目标本身（已存在时）都没有指向 game/csgo 之外
+func checkGameWritePath(ctx context.Context, id, abs string) error {
+	if _, err := checkGamePath(ctx, id, path.Dir(abs)); err != nil {
+		return err
+	}
+	if _, err := checkGamePath(ctx, id, abs); err != nil && !errdefs.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// gamePathPrefixes game/csgo 之下从第一级到 abs 的每一级路径，abs 为 game/csgo 时只有它本身
+func gamePathPrefixes(abs string) []string {
+	rel := relGamePath(abs)
+	if rel == "" {
+		return []string{gameRootDir}
+	}
+	segs := strings.Split(rel, "/")
+	prefixes := make([]string, len(segs))
+	for i := range segs {
+		prefixes[i] = path.Join(gameRootDir, strings.Join(segs[:i+1], "/"))
+	}
+	return prefixes
+}
+
+// runServerCommand 在服务器容器中执行命令并返回标准输出
+// 容器运行时使用 exec，未运行时启动一个挂载相同卷的临时容器执行
+func runServerCommand(ctx context.Context, ctr types.Container, cmd []string) (string, error) {
+	if ctr.State == "running" {
+		return execInContainer(ctx, ctr.ID, cmd)
+	}
+	return runHelperContainer(ctx, ctr, cmd)
+}
+
+// execInContainer 在运行中的容器内执行命令
+func execInContainer(ctx context.Context, id string, cmd []string) (string, error) {
+	exec, err := docker.Cli.ContainerExecCreate(ctx, id, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("创建 exec 失败: %w", err)
+	}
+
+	resp, err := docker.Cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("执行命令失败: %w", err)
+	}
+	defer resp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return "", fmt.Errorf("读取命令输出失败: %w", err)
+	}
+
+	inspect, err := docker.Cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return "", fmt.Errorf("获取命令执行结果失败: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return "", fmt.Errorf("命令退出码 %d: %s", inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// runHelperContainer 使用服务器镜像启动只读挂载服务器卷的临时容器执行命令
+func runHelperContainer(ctx context.Context, ctr types.Container, cmd []string) (string, error) {
+	resp, err := docker.Cli.ContainerCreate(ctx, &container.Config{
+		Image:      ctr.Image,
+		Entrypoint: cmd[:1],
+		Cmd:        cmd[1:],
+		User:       "root",
+	}, &container.HostConfig{
+		VolumesFrom: []string{ctr.ID + ":ro"},
+		NetworkMode: "none",
+	}, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("创建临时容器失败: %w", err)
+	}
+	defer func() {
+		if err := docker.Cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			util.Error("删除临时容器失败", err)
+		}
+	}()
+
+	waitCh, errCh := docker.Cli.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := docker.Cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("启动临时容器失败: %w", err)
+	}
+
+	var exitCode int64
+	select {
+	case result := <-waitCh:
+		exitCode = result.StatusCode
+	case err := <-errCh:
+		return "", fmt.Errorf("等待临时容器退出失败: %w", err)
+	}
+
+	logs, err := docker.Cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", fmt.Errorf("读取临时容器输出失败: %w", err)
+	}
+	defer logs.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return "", fmt.Errorf("读取临时容器输出失败: %w", err)
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("命令退出码 %d: %s", exitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// listContainerDir 列出容器内目录的直接子项
+func listContainerDir(ctx context.Context, ctr types.Container, dir string) ([]FileEntry, error) {
+	output, err := runServerCommand(ctx, ctr, []string{
+		"find", dir, "-mindepth", "1", "-maxdepth", "1", "-printf", `%y\t%s\t%T@\t%f\n`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("列出目录 %s 失败: %w", dir, err)
+	}
+
+	entries := make([]FileEntry, 0)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[1], 10, 64)
+		mtime, _ := strconv.ParseFloat(fields[2], 64)
+		entry := FileEntry{
+			Name:    fields[3],
+			Path:    relGamePath(path.Join(dir, fields[3])),
+			Size:    size,
+			ModTime: time.Unix(int64(mtime), 0),
+		}
+		switch fields[0] {
+		case "f":
+			entry.Type = "file"
+		case "d":
+			entry.Type = "dir"
+		case "l":
+			entry.Type = "link"
+		default:
+			entry.Type = "other"
+		}
+		entries = append(entries, entry)
+	}
+
+	// 目录在前，同类按名称排序
+	sort.Slice(entries, func(i, j int) bool {
+		if (entries[i].Type == "dir") != (entries[j].Type == "dir") {
+			return entries[i].Type == "dir"
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries, nil
+}
+
+// readContainerFile 读取容器内的单个文件，超过 limit 字节时返回错误
+func readContainerFile(ctx context.Context, id, file string, limit int64) ([]byte, error) {
+	reader, stat, err := docker.Cli.CopyFromContainer(ctx, id, file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if stat.Mode.IsDir() {
+		return nil, invalidf("%s 是目录", relGamePath(file))
+	}
+	if stat.Size > limit {
+		return nil, invalidf("文件大小 %d 字节超过上限 %d 字节", stat.Size, limit)
+	}
+
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return io.ReadAll(io.LimitReader(tr, limit))
+}
+
+// writeContainerFile 写入容器内的单个文件，父目录必须已存在
+func writeContainerFile(ctx context.Context, id, file string, data []byte) error {
+	return copyToContainerFile(ctx, id, file, bytes.NewReader(data), int64(len(data)))
+}
+
+// copyToContainerFile 将 r 中 size 字节的内容以流的方式写入容器内的文件
+func copyToContainerFile(ctx context.Context, id, file string, r io.Reader, size int64) error {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    path.Base(file),
+			Mode:    0644,
+			Size:    size,
+			ModTime: time.Now(),
+		})
+		if err == nil {
+			_, err = io.CopyN(tw, r, size)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	err := docker.Cli.CopyToContainer(ctx, id, path.Dir(file), pr, container.CopyToContainerOptions{CopyUIDGID: true})
+	pr.CloseWithError(err)
+	return err
+}
+
+// uploadContainerArchive 将 tar 包解压到容器内的目录
+// 包中的条目逐个校验，绝对路径、.. 路径段、链接与设备文件都会被拒绝
+func uploadContainerArchive(ctx context.Context, id, dir string, archive io.Reader) error {
+	pr, pw := io.Pipe()
+	// 条目写入已存在的目录时，该目录不能是指向 game/csgo 之外的符号链接
+	checked := make(map[string]bool)
+	checkEntry := func(name string) error {
+		parent := path.Dir(path.Join(dir, name))
+		if checked[parent] {
+			return nil
+		}
+		if _, err := checkGamePath(ctx, id, parent); err != nil && !errdefs.IsNotFound(err) {
+			return fmt.Errorf("压缩包中的 %q: %w", name, err)
+		}
+		checked[parent] = true
+		return nil
+	}
+	go func() {
+		pw.CloseWithError(sanitizeArchive(archive, pw, checkEntry))
+	}()
+
+	err := docker.Cli.CopyToContainer(ctx, id, dir, pr, container.CopyToContainerOptions{CopyUIDGID: true})
+	pr.CloseWithError(err)
+	return err
+}
+
+// sanitizeArchive 校验 tar 包条目并写入 w，check 检查条目在目标目录中的位置
+func sanitizeArchive(archive io.Reader, w io.Writer, check func(name string) error) error {
+	tr := tar.NewReader(archive)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取压缩包失败: %w", err)
+		}
+
+		name := strings.ReplaceAll(hdr.Name, "\\", "/")
+		if path.IsAbs(name) {
+			return fmt.Errorf("压缩包中的 %q: %w", hdr.Name, ErrPathNotAllowed)
+		}
+		for _, seg := range strings.Split(name, "/") {
+			if seg == ".." {
+				return fmt.Errorf("压缩包中的 %q: %w", hdr.Name, ErrPathNotAllowed)
+			}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeDir:
+		default:
+			return invalidf("压缩包中的 %q 不是普通文件或目录", hdr.Name)
+		}
+
+		if err := check(name); err != nil {
+			return err
+		}
+
+		hdr.Name = name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
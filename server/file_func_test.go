@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// setGameFiles 设置测试用的游戏目录：cfg 是指向 /etc 的符号链接，maps 是普通目录
+func setGameFiles() {
+	testDocker.setContainers(panelContainer("f1", "files", "running"))
+	testDocker.setPaths(map[string]container.PathStat{
+		gameRootDir:                      {Name: "csgo", Mode: os.ModeDir},
+		gameRootDir + "/cfg":             {Name: "cfg", Mode: os.ModeSymlink, LinkTarget: "/etc"},
+		gameRootDir + "/cfg/passwd":      {Name: "passwd"},
+		gameRootDir + "/maps":            {Name: "maps", Mode: os.ModeDir},
+		gameRootDir + "/maps/de_x.vpk":   {Name: "de_x.vpk"},
+		gameRootDir + "/maps/shadow.cfg": {Name: "shadow.cfg", Mode: os.ModeSymlink, LinkTarget: "/etc/shadow"},
+		gameRootDir + "/alias":           {Name: "alias", Mode: os.ModeSymlink, LinkTarget: gameRootDir + "/maps"},
+		gameRootDir + "/alias/de_x.vpk":  {Name: "de_x.vpk"},
+	})
+}
+
+// 路径中任意一级是指向 game/csgo 之外的符号链接时都不允许访问
+func TestCheckGamePath(t *testing.T) {
+	setGameFiles()
+	ctx := context.Background()
+
+	for rel, allowed := range map[string]bool{
+		"":                true,
+		"maps":            true,
+		"maps/de_x.vpk":   true,
+		"alias/de_x.vpk":  true,
+		"cfg":             false,
+		"cfg/passwd":      false,
+		"maps/shadow.cfg": false,
+	} {
+		abs, err := resolveGamePath(rel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = checkGamePath(ctx, "f1", abs)
+		if err != nil && !errors.Is(err, ErrPathNotAllowed) {
+			t.Fatalf("%q: %v", rel, err)
+		}
+		if got := err == nil; got != allowed {
+			t.Errorf("%q: allowed = %v，应为 %v", rel, got, allowed)
+		}
+	}
+
+	// 写入时目标可以不存在，但上级目录与已存在的目标都要检查
+	for rel, allowed := range map[string]bool{
+		"maps/new.cfg":    true,
+		"maps/de_x.vpk":   true,
+		"cfg/new.cfg":     false,
+		"cfg/passwd":      false,
+		"maps/shadow.cfg": false,
+	} {
+		abs, _ := resolveGamePath(rel)
+		err := checkGameWritePath(ctx, "f1", abs)
+		if err != nil && !errors.Is(err, ErrPathNotAllowed) {
+			t.Fatalf("写入 %q: %v", rel, err)
+		}
+		if got := err == nil; got != allowed {
+			t.Errorf("写入 %q: allowed = %v，应为 %v", rel, got, allowed)
+		}
+	}
+}
+
+// 通过接口写入符号链接目录下的文件被拒绝
+func TestFileWriteSymlinkParent(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	setGameFiles()
+
+	w := serveJSON(router, http.MethodPost, "/api/file/write", FileWriteRequest{Name: "files", Path: "cfg/passwd", Content: "x"}, session)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("写入符号链接目录下的文件应返回 403，实际 %d: %s", w.Code, w.Body.String())
+	}
+}
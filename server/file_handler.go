@@ -0,0 +1,290 @@
+package server
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// FileListRequest 列出服务器目录内容的请求参数
+type FileListRequest struct {
+	Name string `form:"name" binding:"required"`
+	Path string `form:"path"` // 相对于 game/csgo 的目录，默认为 game/csgo 本身
+}
+
+// FileListResponse 目录内容的响应
+type FileListResponse struct {
+	Path    string      `json:"path"` // 相对于 game/csgo 的目录
+	Entries []FileEntry `json:"entries"`
+}
+
+// fileListHandler 处理列出服务器目录内容的请求
+func fileListHandler(c *gin.Context) {
+	var req FileListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	dir, err := resolveGamePath(req.Path)
+	if err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	ctr, err := ResolveContainer(context.Background(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取容器失败", err)
+		return
+	}
+	if _, err := checkGamePath(context.Background(), ctr.ID, dir); err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	entries, err := listContainerDir(context.Background(), ctr, dir)
+	if err != nil {
+		handleErrorResponse(c, "列出目录失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, FileListResponse{
+		Path:    relGamePath(dir),
+		Entries: entries,
+	})
+}
+
+// FileReadRequest 读取文本文件的请求参数
+type FileReadRequest struct {
+	Name string `form:"name" binding:"required"`
+	Path string `form:"path" binding:"required"` // 相对于 game/csgo 的文件路径，例如 cfg/server.cfg
+}
+
+// FileReadResponse 文本文件内容的响应
+type FileReadResponse struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// fileReadHandler 处理读取文本文件的请求
+func fileReadHandler(c *gin.Context) {
+	var req FileReadRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	file, err := resolveGamePath(req.Path)
+	if err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	ctr, err := ResolveContainer(context.Background(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取容器失败", err)
+		return
+	}
+	if _, err := checkGamePath(context.Background(), ctr.ID, file); err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	data, err := readContainerFile(context.Background(), ctr.ID, file, maxTextFileSize)
+	if err != nil {
+		handleErrorResponse(c, "读取文件失败", err)
+		return
+	}
+	if !utf8.Valid(data) {
+		handleErrorResponse(c, "读取文件失败", invalidf("%s 不是文本文件", req.Path))
+		return
+	}
+
+	c.JSON(http.StatusOK, FileReadResponse{
+		Path:    relGamePath(file),
+		Content: string(data),
+	})
+}
+
+// FileWriteRequest 写入文本文件的请求参数
+type FileWriteRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Path    string `json:"path" binding:"required"`
+	Content string `json:"content"`
+}
+
+// FileSaveResponse 写入或上传文件的响应
+type FileSaveResponse struct {
+	Message string `json:"message"`
+	Path    string `json:"path"` // 写入的文件或上传的目标目录
+}
+
+// fileWriteHandler 处理写入文本文件的请求，文件不存在时创建
+func fileWriteHandler(c *gin.Context) {
+	var req FileWriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	if len(req.Content) > maxTextFileSize {
+		handleErrorResponse(c, "无效的请求参数", invalidf("文件内容超过上限 %d 字节", maxTextFileSize))
+		return
+	}
+	file, err := resolveGamePath(req.Path)
+	if err != nil || file == gameRootDir {
+		handleErrorResponse(c, "无效的路径", ErrPathNotAllowed)
+		return
+	}
+
+	ctr, err := ResolveContainer(context.Background(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取容器失败", err)
+		return
+	}
+	if err := checkGameWritePath(context.Background(), ctr.ID, file); err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	if err := writeContainerFile(context.Background(), ctr.ID, file, []byte(req.Content)); err != nil {
+		handleErrorResponse(c, "写入文件失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, FileSaveResponse{
+		Message: "文件保存成功",
+		Path:    relGamePath(file),
+	})
+
+	util.Info(fmt.Sprintf("文件保存成功 服务器: %s 文件: %s", req.Name, relGamePath(file)))
+}
+
+// FileDownloadRequest 下载文件或目录的请求参数
+type FileDownloadRequest struct {
+	Name string `form:"name" binding:"required"`
+	Path string `form:"path"`
+}
+
+// fileDownloadHandler 处理下载文件或目录的请求，目录以 tar 包形式下载
+func fileDownloadHandler(c *gin.Context) {
+	var req FileDownloadRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	target, err := resolveGamePath(req.Path)
+	if err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	ctr, err := ResolveContainer(context.Background(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取容器失败", err)
+		return
+	}
+	if _, err := checkGamePath(context.Background(), ctr.ID, target); err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	reader, stat, err := docker.Cli.CopyFromContainer(context.Background(), ctr.ID, target)
+	if err != nil {
+		handleErrorResponse(c, "下载文件失败", err)
+		return
+	}
+	defer reader.Close()
+
+	// 目录直接返回 Docker 生成的 tar 包
+	if stat.Mode.IsDir() {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stat.Name+".tar"))
+		c.DataFromReader(http.StatusOK, -1, "application/x-tar", reader, nil)
+		return
+	}
+
+	// 单个文件从 tar 包中取出后返回
+	tr := tar.NewReader(reader)
+	if _, err := tr.Next(); err != nil {
+		handleErrorResponse(c, "下载文件失败", err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", stat.Name))
+	c.DataFromReader(http.StatusOK, stat.Size, "application/octet-stream", tr, nil)
+}
+
+// FileUploadRequest 上传文件的请求参数
+type FileUploadRequest struct {
+	Name    string `form:"name" binding:"required"`
+	Path    string `form:"path"`
+	Extract bool   `form:"extract"`
+
+	File *multipart.FileHeader `form:"file" binding:"required"` // 上传的文件
+}
+
+// fileUploadHandler 处理上传文件的请求
+// 表单字段 file 为上传的文件，path 为目标目录；extract=true 时将 tar 包解压到目标目录
+func fileUploadHandler(c *gin.Context) {
+	var req FileUploadRequest
+	if err := c.ShouldBind(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	header := req.File
+	dir, err := resolveGamePath(req.Path)
+	if err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	ctr, err := ResolveContainer(context.Background(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取容器失败", err)
+		return
+	}
+	if _, err := checkGamePath(context.Background(), ctr.ID, dir); err != nil {
+		handleErrorResponse(c, "无效的路径", err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		handleErrorResponse(c, "读取上传文件失败", err)
+		return
+	}
+	defer file.Close()
+
+	if req.Extract {
+		err = uploadContainerArchive(context.Background(), ctr.ID, dir, file)
+	} else {
+		// 只取文件名部分，避免文件名中携带路径
+		fileName := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+		if fileName == "." || fileName == "/" || fileName == ".." {
+			handleErrorResponse(c, "无效的文件名", ErrPathNotAllowed)
+			return
+		}
+		target := path.Join(dir, fileName)
+		if err := checkGameWritePath(context.Background(), ctr.ID, target); err != nil {
+			handleErrorResponse(c, "无效的路径", err)
+			return
+		}
+		err = copyToContainerFile(context.Background(), ctr.ID, target, file, header.Size)
+	}
+	if err != nil {
+		handleErrorResponse(c, "上传文件失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, FileSaveResponse{
+		Message: "文件上传成功",
+		Path:    relGamePath(dir),
+	})
+
+	util.Info(fmt.Sprintf("文件上传成功 服务器: %s 目录: %s 文件: %s", req.Name, relGamePath(dir), header.Filename))
+}
@@ -0,0 +1,387 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// serverNameRegex 服务器名称允许的字符
+var serverNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// cs2EnvSignature 识别 CS2 服务器容器的环境变量，命中其中两个即视为候选
+var cs2EnvSignature = []string{"CS2_PORT", "CS2_RCON_PORT", "CS2_RCONPW", "CS2_SERVERNAME", "CS2_STARTMAP", "SRCDS_TOKEN"}
+
+// AliasStore 保存导入容器的别名映射：服务器名称 -> 容器 ID
+type AliasStore struct {
+	mu      sync.RWMutex
+	aliases map[string]string
+	loaded  bool
+}
+
+// 全局别名映射
+var aliasStore = &AliasStore{}
+
+// aliasFile 别名映射在面板数据目录下的文件名
+const aliasFile = "aliases.json"
+
+// load 首次使用时从面板数据目录读取别名映射，调用方需持有写锁
+func (s *AliasStore) load() error {
+	if s.loaded {
+		return nil
+	}
+	aliases := make(map[string]string)
+	if _, err := loadPanelJSON(&aliases, aliasFile); err != nil {
+		return err
+	}
+	s.aliases = aliases
+	s.loaded = true
+	return nil
+}
+
+// All 返回所有别名映射的副本
+func (s *AliasStore) All() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(s.aliases))
+	for name, id := range s.aliases {
+		result[name] = id
+	}
+	return result, nil
+}
+
+// Get 获取服务器名称对应的容器 ID
+func (s *AliasStore) Get(name string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", false, err
+	}
+	id, ok := s.aliases[name]
+	return id, ok, nil
+}
+
+// Set 注册别名并保存
+func (s *AliasStore) Set(name, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.aliases[name] = id
+	return savePanelJSON(s.aliases, aliasFile)
+}
+
+// Delete 删除别名并保存
+func (s *AliasStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.aliases[name]; !ok {
+		return nil
+	}
+	delete(s.aliases, name)
+	return savePanelJSON(s.aliases, aliasFile)
+}
+
+// Replace 容器重建后把指向旧容器 ID 的别名改为新容器 ID，没有对应别名时不做修改
+func (s *AliasStore) Replace(oldID, newID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	changed := false
+	for name, id := range s.aliases {
+		if id == oldID {
+			s.aliases[name] = newID
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return savePanelJSON(s.aliases, aliasFile)
+}
+
+// resolveAlias 通过别名映射查找导入的容器
+func resolveAlias(ctx context.Context, name string) (types.Container, bool, error) {
+	id, ok, err := aliasStore.Get(name)
+	if err != nil || !ok {
+		return types.Container{}, false, err
+	}
+
+	containers, err := docker.Cli.ContainerList(ctx, container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("id", id)),
+	})
+	if err != nil {
+		return types.Container{}, false, err
+	}
+	if len(containers) == 0 {
+		return types.Container{}, false, fmt.Errorf("服务器 %q 导入的容器 %s 已不存在: %w", name, shortID(id), ErrContainerNotFound)
+	}
+	return containers[0], true, nil
+}
+
+// listAliasedContainers 获取所有导入的容器，返回容器 ID -> 服务器名称
+func listAliasedContainers(ctx context.Context) ([]types.Container, map[string]string, error) {
+	aliases, err := aliasStore.All()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(aliases) == 0 {
+		return nil, nil, nil
+	}
+
+	names := make(map[string]string, len(aliases))
+	args := filters.NewArgs()
+	for name, id := range aliases {
+		names[id] = name
+		args.Add("id", id)
+	}
+
+	containers, err := docker.Cli.ContainerList(ctx, container.ListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, nil, err
+	}
+	return containers, names, nil
+}
+
+// ImportCandidate 可导入的 CS2 容器及检测到的配置
+type ImportCandidate struct {
+	ID            string            `json:"id"`
+	ContainerName string            `json:"container_name"`
+	Image         string            `json:"image"`
+	State         string            `json:"state"`
+	SuggestedName string            `json:"suggested_name"` // 建议的面板服务器名称
+	MatchedBy     string            `json:"matched_by"`     // 识别方式 image 或 env
+	Ports         map[string]string `json:"ports"`          // 端口类型 -> 主机端口
+	RconPassword  string            `json:"rcon_password"`
+	Map           string            `json:"map"`
+	ServerName    string            `json:"server_name"` // 游戏内服务器名称
+}
+
+// FindImportCandidates 查找尚未被面板管理的 CS2 容器
+// 镜像与配置镜像一致，或环境变量命中 CS2 特征的容器均视为候选
+func FindImportCandidates(ctx context.Context) ([]ImportCandidate, error) {
+	containers, err := docker.Cli.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("获取容器列表失败: %w", err)
+	}
+	aliases, err := aliasStore.All()
+	if err != nil {
+		return nil, err
+	}
+	aliased := make(map[string]bool, len(aliases))
+	for _, id := range aliases {
+		aliased[id] = true
+	}
+
+	candidates := make([]ImportCandidate, 0)
+	for _, c := range containers {
+		if c.Labels[LabelPanelID] == config.GlobalConfig.Docker.PanelID || aliased[c.ID] {
+			continue
+		}
+
+		info, err := docker.Cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取容器 %s 信息失败: %w", shortID(c.ID), err)
+		}
+		env := envMap(info.Config.Env)
+
+		matchedBy := importMatch(c.Image, env)
+		if matchedBy == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(info.Name, "/")
+		candidates = append(candidates, ImportCandidate{
+			ID:            c.ID,
+			ContainerName: name,
+			Image:         c.Image,
+			State:         c.State,
+			SuggestedName: suggestServerName(name),
+			MatchedBy:     matchedBy,
+			Ports:         detectServerPorts(info, env),
+			RconPassword:  redactSecret(env["CS2_RCONPW"]),
+			Map:           env["CS2_STARTMAP"],
+			ServerName:    env["CS2_SERVERNAME"],
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ContainerName < candidates[j].ContainerName
+	})
+	return candidates, nil
+}
+
+// importMatch 判断容器是否为 CS2 服务器：镜像与配置镜像一致返回 image，环境变量命中 CS2 特征返回 env，都不满足时返回空字符串
+func importMatch(image string, env map[string]string) string {
+	imageRepo := strings.SplitN(config.GlobalConfig.Docker.ImageName, ":", 2)[0]
+	switch {
+	case strings.SplitN(image, ":", 2)[0] == imageRepo:
+		return "image"
+	case countEnvKeys(env, cs2EnvSignature) >= 2:
+		return "env"
+	}
+	return ""
+}
+
+// ImportContainer 以别名方式将容器注册为面板服务器，不重建容器
+// 只接受 FindImportCandidates 同样会列出的 CS2 容器
+func ImportContainer(ctx context.Context, id, name string) (string, error) {
+	if !serverNameRegex.MatchString(name) {
+		return "", invalidf("无效的服务器名称 %q", name)
+	}
+	if _, err := ResolveContainer(ctx, name); err == nil {
+		return "", conflictf("服务器 %q 已存在", name)
+	}
+
+	info, err := docker.Cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("获取容器信息失败: %w", err)
+	}
+	if info.Config.Labels[LabelPanelID] == config.GlobalConfig.Docker.PanelID {
+		return "", conflictf("容器 %s 已由面板管理", strings.TrimPrefix(info.Name, "/"))
+	}
+	if importMatch(info.Config.Image, envMap(info.Config.Env)) == "" {
+		return "", invalidf("容器 %s 不是 CS2 服务器容器", strings.TrimPrefix(info.Name, "/"))
+	}
+	aliases, err := aliasStore.All()
+	if err != nil {
+		return "", err
+	}
+	for existing, aliasedID := range aliases {
+		if aliasedID == info.ID {
+			return "", conflictf("容器已导入为服务器 %q", existing)
+		}
+	}
+
+	if err := aliasStore.Set(name, info.ID); err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// ServerPort 获取服务器在主机上的端口
+// 优先使用容器端口映射中的主机端口，没有映射（如 host 网络）时使用环境变量中的端口
+func ServerPort(name, key, proto string) (string, error) {
+	ctr, err := ResolveContainer(context.Background(), name)
+	if err != nil {
+		return "", err
+	}
+	info, err := docker.Cli.ContainerInspect(context.Background(), ctr.ID)
+	if err != nil {
+		return "", err
+	}
+
+	env := envMap(info.Config.Env)
+	port, ok := env[key]
+	if !ok {
+		return "", fmt.Errorf("未找到环境变量 %q", key)
+	}
+	if hostPort := boundHostPort(info, port, proto); hostPort != "" {
+		return hostPort, nil
+	}
+	return port, nil
+}
+
+// detectServerPorts 检测游戏、RCON 与 SourceTV 端口在主机上的映射
+func detectServerPorts(info types.ContainerJSON, env map[string]string) map[string]string {
+	ports := make(map[string]string)
+	for _, p := range []struct{ kind, key, def, proto string }{
+		{"game", "CS2_PORT", "27015", "udp"},
+		{"rcon", "CS2_RCON_PORT", "27015", "tcp"},
+		{"tv", "TV_PORT", "27020", "udp"},
+	} {
+		port := env[p.key]
+		if port == "" {
+			port = p.def
+		}
+		if hostPort := boundHostPort(info, port, p.proto); hostPort != "" {
+			port = hostPort
+		}
+		ports[p.kind] = port
+	}
+	return ports
+}
+
+// boundHostPort 获取容器端口映射到的主机端口，没有映射时返回空字符串
+func boundHostPort(info types.ContainerJSON, port, proto string) string {
+	if info.HostConfig == nil {
+		return ""
+	}
+	for containerPort, bindings := range info.HostConfig.PortBindings {
+		if containerPort.Port() != port || containerPort.Proto() != proto {
+			continue
+		}
+		for _, b := range bindings {
+			if b.HostPort != "" {
+				return b.HostPort
+			}
+		}
+	}
+	return ""
+}
+
+// envMap 将 KEY=VALUE 形式的环境变量列表转换为映射
+func envMap(env []string) map[string]string {
+	result := make(map[string]string, len(env))
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			result[parts[0]] = parts[1]
+		}
+	}
+	return result
+}
+
+// countEnvKeys 统计环境变量中出现的键的数量
+func countEnvKeys(env map[string]string, keys []string) int {
+	n := 0
+	for _, key := range keys {
+		if _, ok := env[key]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+// suggestServerName 根据容器名称生成合法的服务器名称
+func suggestServerName(containerName string) string {
+	name := strings.TrimPrefix(containerName, config.GlobalConfig.Docker.Prefix+"-")
+	name = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`).ReplaceAllString(name, "-")
+	name = strings.TrimLeft(name, "_.-")
+	if name == "" {
+		return "imported"
+	}
+	return name
+}
+
+// shortID 截取容器 ID 前 12 位
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -1,17 +1,53 @@
 package server
 
 import (
+	"embed"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/util"
 )
 
+// mapSnapshot 随程序打包的地图元数据快照，本地没有可用数据时使用
+//
+//go:embed mapdata/*.json
+var mapSnapshot embed.FS
+
+const (
+	// mapWikiURL 地图元数据来源页面
+	mapWikiURL = "https://developer.valvesoftware.com/wiki/Counter-Strike_2/Maps"
+	// mapFetchTimeout 请求 Wiki 的超时时间
+	mapFetchTimeout = 30 * time.Second
+	// mapFetchMetaFile 记录每个分类上次成功刷新时的 ETag 与 Last-Modified
+	mapFetchMetaFile = "fetch_meta.json"
+)
+
+// mapClass 地图分类及其在 Wiki 页面上的标题锚点
+type mapClass struct {
+	Class  string
+	Anchor string
+	Min    int // 校验时要求的最少地图数量
+}
+
+// mapClasses 所有地图分类
+var mapClasses = []mapClass{
+	{Class: "current", Anchor: "Current_Maps", Min: 5},
+	{Class: "former", Anchor: "Former_Maps", Min: 1},
+}
+
+// mapInternalNameRegex 地图文件名格式
+var mapInternalNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// mapRefreshMu 防止并发刷新地图元数据
+var mapRefreshMu sync.Mutex
+
 // MapInfo 只保留 name、internal_name 和 playable_modes
 type MapInfo struct {
 	Name          string   `json:"name"`           // 地图显示名
@@ -19,27 +55,30 @@ type MapInfo struct {
 	PlayableModes []string `json:"playable_modes"` // 在 CS2 各模式下可玩的模式列表
 }
 
-// fetchMaps 抓取地图列表
-func fetchMaps(mapclass string) ([]MapInfo, error) {
-	var result []MapInfo
+// mapFetchMeta 条件请求所需的校验信息
+type mapFetchMeta struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
 
-	// 1. HTTP GET 页面
-	resp, err := http.Get("https://developer.valvesoftware.com/wiki/Counter-Strike_2/Maps")
-	if err != nil {
-		return nil, fmt.Errorf("请求页面失败：%w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP 返回 %d", resp.StatusCode)
-	}
+// MapRefreshResult 刷新地图元数据的结果
+type MapRefreshResult struct {
+	NotModified bool     `json:"not_modified"` // 页面未变化，沿用本地数据
+	Updated     []string `json:"updated"`      // 成功更新的分类
+	Warnings    []string `json:"warnings"`     // 解析或校验失败的分类，保留上次的数据
+}
 
-	// 2. 用 goquery 解析 HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("解析 HTML 失败：%w", err)
-	}
+// mapFileName 分类对应的本地文件名
+func mapFileName(mapclass string) string {
+	return fmt.Sprintf("%s_maps.json", mapclass)
+}
 
-	// 3. 定位 mapclass 对应的表格
+// parseMapTable 从 Wiki 页面中解析 mapclass 对应表格的地图列表
+func parseMapTable(doc *goquery.Document, mapclass string) ([]MapInfo, error) {
+	var result []MapInfo
+
+	// 1. 定位 mapclass 对应的表格
 	heading := doc.Find(fmt.Sprintf("span#%s", mapclass)).Closest("h2")
 	if heading.Length() == 0 {
 		return nil, fmt.Errorf("未找到 %s 标题", mapclass)
@@ -49,7 +88,7 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 		return nil, fmt.Errorf("未找到 %s 表格", mapclass)
 	}
 
-	// 4. 拆出两行表头：第一行基础列、第二行各模式列
+	// 2. 拆出两行表头：第一行基础列、第二行各模式列
 	headerRows := table.Find("tr").FilterFunction(func(i int, tr *goquery.Selection) bool {
 		return tr.Find("th").Length() > 0
 	})
@@ -58,7 +97,7 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 	}
 	modeHeader := headerRows.Eq(1)
 
-	// 5. 读取所有模式名
+	// 3. 读取所有模式名
 	modeNames := make([]string, 0)
 	modeHeader.Find("th").Each(func(_ int, th *goquery.Selection) {
 		if alt, ok := th.Find("img").Attr("alt"); ok && alt != "" {
@@ -72,7 +111,7 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 		return nil, fmt.Errorf("未检测到任何模式列")
 	}
 
-	// 6. 遍历每一行数据
+	// 4. 遍历每一行数据
 	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
 		cells := tr.Find("td")
 		if cells.Length() == 0 {
@@ -89,7 +128,7 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 		}
 		data := texts[1:] // data[0]=MapName, data[1]=Internal, data[2..]=各模式数据
 
-		// 7. 提取可玩的模式
+		// 5. 提取可玩的模式
 		playable := make([]string, 0, modeCount)
 		for i, mode := range modeNames {
 			if i+2 < len(data) && strings.EqualFold(data[2+i], "Yes") {
@@ -97,7 +136,7 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 			}
 		}
 
-		// 8. 构造并追加 MapInfo
+		// 6. 构造并追加 MapInfo
 		mi := MapInfo{
 			Name:          data[0],
 			InternalName:  data[1],
@@ -109,81 +148,146 @@ func fetchMaps(mapclass string) ([]MapInfo, error) {
 	return result, nil
 }
 
-// saveMaps 抓取地图列表并保存到本地 JSON 文件
-func saveMaps(mapclass, outputFile string) error {
-	maps, err := fetchMaps(mapclass)
-	if err != nil {
-		return err
+// validateMaps 在替换本地文件前校验解析结果，防止页面结构变化时写入错误数据
+func validateMaps(maps []MapInfo, min int) error {
+	if len(maps) < min {
+		return fmt.Errorf("地图数量过少：解析到 %d 个，至少需要 %d 个", len(maps), min)
+	}
+	seen := make(map[string]bool)
+	for _, m := range maps {
+		if strings.TrimSpace(m.Name) == "" {
+			return fmt.Errorf("地图 %q 缺少显示名", m.InternalName)
+		}
+		if !mapInternalNameRegex.MatchString(m.InternalName) {
+			return fmt.Errorf("地图 %q 的文件名 %q 格式无效", m.Name, m.InternalName)
+		}
+		if seen[m.InternalName] {
+			return fmt.Errorf("地图文件名 %q 重复", m.InternalName)
+		}
+		seen[m.InternalName] = true
+		if m.PlayableModes == nil {
+			return fmt.Errorf("地图 %q 缺少可玩模式列表", m.InternalName)
+		}
 	}
+	return nil
+}
 
-	data, err := json.MarshalIndent(maps, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化为 JSON 失败：%w", err)
+// RefreshMapMetadata 从 Wiki 刷新指定分类的地图元数据，classes 为空时刷新全部分类
+// 页面未变化时不重新解析；某个分类解析或校验失败时保留该分类上次的数据并记录警告
+func RefreshMapMetadata(classes ...string) (MapRefreshResult, error) {
+	result := MapRefreshResult{Updated: []string{}, Warnings: []string{}}
+
+	mapRefreshMu.Lock()
+	defer mapRefreshMu.Unlock()
+
+	metas := make(map[string]mapFetchMeta)
+	if _, err := loadPanelJSON(&metas, "maps", mapFetchMetaFile); err != nil {
+		util.Warn("读取地图刷新记录失败，将完整刷新: " + err.Error())
+		metas = make(map[string]mapFetchMeta)
 	}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("获取当前工作目录失败：%w", err)
+	var targets []mapClass
+	for _, mc := range mapClasses {
+		if len(classes) == 0 || slices.Contains(classes, mc.Class) {
+			targets = append(targets, mc)
+		}
+	}
+	if len(targets) == 0 {
+		return result, fmt.Errorf("未知的地图分类 %v", classes)
 	}
-	outputPath := filepath.Join(cwd, config.GlobalConfig.Server.PanelDataDir, "maps", outputFile)
 
-	// 关键：先创建目录
-	dir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("创建目录 %s 失败：%w", dir, err)
+	req, err := http.NewRequest(http.MethodGet, mapWikiURL, nil)
+	if err != nil {
+		return result, fmt.Errorf("创建请求失败：%w", err)
+	}
+	// 所有目标分类都基于同一版本页面时才发送条件请求
+	if validator, ok := metas[targets[0].Class]; ok {
+		conditional := true
+		for _, mc := range targets[1:] {
+			m, ok := metas[mc.Class]
+			if !ok || m.ETag != validator.ETag || m.LastModified != validator.LastModified {
+				conditional = false
+				break
+			}
+		}
+		if conditional {
+			if validator.ETag != "" {
+				req.Header.Set("If-None-Match", validator.ETag)
+			}
+			if validator.LastModified != "" {
+				req.Header.Set("If-Modified-Since", validator.LastModified)
+			}
+		}
 	}
 
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
-		return fmt.Errorf("写入 %s 失败：%w", outputFile, err)
+	client := &http.Client{Timeout: mapFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return result, upstreamf("请求页面失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		result.NotModified = true
+		return result, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, upstreamf("HTTP 返回 %d", resp.StatusCode)
 	}
 
-	return nil
-}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("解析 HTML 失败：%w", err)
+	}
 
-// fetchCurrentMaps 抓取并解析“Current Maps”表格，保存为 current_maps.json
-func fetchCurrentMaps() error {
-	return saveMaps("Current_Maps", "current_maps.json")
-}
+	meta := mapFetchMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	for _, mc := range targets {
+		maps, err := parseMapTable(doc, mc.Anchor)
+		if err == nil {
+			err = validateMaps(maps, mc.Min)
+		}
+		if err == nil {
+			err = savePanelJSON(maps, "maps", mapFileName(mc.Class))
+		}
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s 地图列表未更新，保留上次的数据：%v", mc.Class, err))
+			continue
+		}
+		metas[mc.Class] = meta
+		result.Updated = append(result.Updated, mc.Class)
+	}
 
-// fetchFormerMaps 抓取并解析“Former Maps”表格，保存为 former_maps.json
-func fetchFormerMaps() error {
-	return saveMaps("Former_Maps", "former_maps.json")
+	if err := savePanelJSON(metas, "maps", mapFetchMetaFile); err != nil {
+		result.Warnings = append(result.Warnings, "保存地图刷新记录失败："+err.Error())
+	}
+	return result, nil
 }
 
-// getMapList 获取地图列表，如果文件不存在则返回空切片
+// getMapList 获取地图列表，本地没有可用数据时使用打包的快照
 func getMapList(mapclass string) ([]MapInfo, error) {
-	cwd, err := os.Getwd()
+	var maps []MapInfo
+	found, err := loadPanelJSON(&maps, "maps", mapFileName(mapclass))
 	if err != nil {
-		return nil, fmt.Errorf("获取当前工作目录失败：%w", err)
+		util.Warn("读取本地地图列表失败，使用内置快照: " + err.Error())
 	}
+	if found && err == nil {
+		return maps, nil
+	}
+	return snapshotMapList(mapclass)
+}
 
-	// 用 filepath.Join 生成平台无关的文件路径
-	fileName := fmt.Sprintf("%s_maps.json", mapclass)
-	filePath := filepath.Join(cwd, config.GlobalConfig.Server.PanelDataDir, "maps", fileName)
-
-	data, err := os.ReadFile(filePath)
+// snapshotMapList 读取打包的地图元数据快照
+func snapshotMapList(mapclass string) ([]MapInfo, error) {
+	data, err := mapSnapshot.ReadFile("mapdata/" + mapFileName(mapclass))
 	if err != nil {
-		if os.IsNotExist(err) {
-			// 如果文件不存在，按需返回空列表
-			return []MapInfo{}, nil
-		}
-		return nil, fmt.Errorf("读取文件 %s 失败：%w", filePath, err)
+		return []MapInfo{}, nil
 	}
-
 	var maps []MapInfo
 	if err := json.Unmarshal(data, &maps); err != nil {
-		return nil, fmt.Errorf("解析 JSON 文件 %s 失败：%w", filePath, err)
+		return nil, fmt.Errorf("解析内置地图快照失败：%w", err)
 	}
-
 	return maps, nil
 }
-
-// getCurrentMaps 获取当前地图列表
-func getCurrentMaps() ([]MapInfo, error) {
-	return getMapList("current")
-}
-
-// getFormerMaps 获取历史地图列表
-func getFormerMaps() ([]MapInfo, error) {
-	return getMapList("former")
-}
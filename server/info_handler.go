@@ -1,205 +1,224 @@
 package server
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/util"
 	"github.com/gin-gonic/gin"
 )
 
+// MapUpdateRequest 更新地图列表的请求参数
+type MapUpdateRequest struct {
+	Class string `form:"class" binding:"omitempty,oneof=current former"` // 不带参数时更新所有地图
+}
+
+// MapUpdateResponse 更新地图列表的响应
+type MapUpdateResponse struct {
+	Message string `json:"message"`
+	MapRefreshResult
+}
+
 // infoMapUpdateHandler 处理获取地图列表的更新请求
+// 解析或校验失败时保留上次的数据，并在 warnings 中返回原因
 func infoMapUpdateHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type MapListRequest struct {
-		Class string `form:"class"` // "current" 或 "former"，不带参数时获取所有地图
+	var req MapUpdateRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
 	}
 
-	var req MapListRequest
-	if err := c.BindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+	var classes []string
+	if req.Class != "" {
+		classes = append(classes, req.Class)
+	}
+	result, err := RefreshMapMetadata(classes...)
+	if err != nil {
+		handleErrorResponse(c, "获取地图列表失败", err)
 		return
 	}
 
-	if req.Class == "current" {
-		err := fetchCurrentMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取当前地图列表失败", err)
-			return
-		}
-	} else if req.Class == "former" {
-		err := fetchFormerMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取历史地图列表失败", err)
-			return
-		}
-	} else {
-		err := fetchCurrentMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取当前地图列表失败", err)
-			return
-		}
-		err = fetchFormerMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取历史地图列表失败", err)
-			return
-		}
+	message := "地图列表更新成功"
+	if result.NotModified {
+		message = "地图列表未变化"
+	} else if len(result.Warnings) > 0 {
+		message = "地图列表部分更新失败，已保留上次的数据"
 	}
-
-	c.JSON(200, gin.H{
-		"message": "地图列表更新成功",
+	c.JSON(http.StatusOK, MapUpdateResponse{
+		Message:          message,
+		MapRefreshResult: result,
 	})
 }
 
+// MapListRequest 获取地图列表的请求参数
+type MapListRequest struct {
+	Class   string `form:"class" binding:"omitempty,oneof=current former installed official workshop"`
+	Refresh bool   `form:"refresh"` // 忽略缓存重新扫描游戏卷
+}
+
+// MapListResponse 地图目录的响应
+type MapListResponse struct {
+	Maps    []MapCatalogEntry `json:"maps"`
+	Warning string            `json:"warning,omitempty"` // 扫描游戏卷失败时的提示，此时只返回元数据中的地图
+}
+
 // infoMapListHandler 处理获取地图列表的请求
+// 地图目录合并地图元数据与游戏卷上已安装的地图，class 可为 current、former、installed、official、workshop
 func infoMapListHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type MapListRequest struct {
-		Class string `form:"class"`
+	var req MapListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
 	}
 
-	var req MapListRequest
-	if c.Request.ContentLength != 0 {
-		if err := c.BindQuery(&req); err != nil {
-			handleErrorResponse(c, "无效的请求参数", err)
-			return
-		}
+	catalog, err := BuildMapCatalog(c.Request.Context(), req.Refresh)
+	if catalog == nil {
+		handleErrorResponse(c, "获取地图列表失败", err)
+		return
 	}
 
-	if req.Class == "current" {
-		maps, err := getCurrentMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取当前地图列表失败", err)
-			return
-		}
-		c.JSON(200, gin.H{
-			"maps": maps,
-		})
-	} else if req.Class == "former" {
-		maps, err := getFormerMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取历史地图列表失败", err)
-			return
-		}
-		c.JSON(200, gin.H{
-			"maps": maps,
-		})
-	} else {
-		var allMaps []MapInfo
-		currentMaps, err := getCurrentMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取当前地图列表失败", err)
-			return
-		}
-		allMaps = append(allMaps, currentMaps...)
-		formerMaps, err := getFormerMaps()
-		if err != nil {
-			handleErrorResponse(c, "获取历史地图列表失败", err)
-			return
+	resp := MapListResponse{
+		Maps: FilterMapCatalog(catalog, req.Class),
+	}
+	if err != nil {
+		// 扫描失败时仍返回元数据中的地图
+		if !errors.Is(err, ErrNoVolumeContainer) {
+			util.Warn("扫描已安装地图失败: " + err.Error())
 		}
-		allMaps = append(allMaps, formerMaps...)
-		c.JSON(200, gin.H{
-			"maps": allMaps,
-		})
+		resp.Warning = "扫描已安装地图失败: " + err.Error()
 	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// NetworkAddrResponse 网络地址的响应
+type NetworkAddrResponse struct {
+	Addr string `json:"addr"`
 }
 
 // infoNetworkAddrHandler 处理获取网络地址的请求
 func infoNetworkAddrHandler(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"addr": config.GlobalConfig.Game.Address,
+	c.JSON(http.StatusOK, NetworkAddrResponse{
+		Addr: config.GlobalConfig.Game.Address,
 	})
 }
 
+// NetworkPortRequest 获取网络端口的请求参数
+type NetworkPortRequest struct {
+	Name string `form:"name" binding:"required"` // 容器名称
+}
+
+// NetworkPortResponse 网络端口的响应
+type NetworkPortResponse struct {
+	Port string `json:"port"`
+}
+
 // infoNetworkGamePortHandler 处理获取网络端口的请求
 func infoNetworkGamePortHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type NetworkPortRequest struct {
-		Name string `form:"name" binding:"required"` // 容器名称
-	}
-
 	var req NetworkPortRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 	// 获取网络端口信息
-	port, err := GetEnvValue(FullName(req.Name), "CS2_PORT")
+	port, err := ServerPort(req.Name, "CS2_PORT", "udp")
 	if err != nil {
 		handleErrorResponse(c, "获取网络端口失败", err)
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"port": port,
+	c.JSON(http.StatusOK, NetworkPortResponse{
+		Port: port,
 	})
 }
 
 // infoNetworkTVPortHandler 处理获取网络端口的请求
 func infoNetworkTVPortHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type NetworkPortRequest struct {
-		Name string `form:"name" binding:"required"` // 容器名称
-	}
-
 	var req NetworkPortRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 	// 获取网络端口信息
-	port, err := GetEnvValue(FullName(req.Name), "TV_PORT")
+	port, err := ServerPort(req.Name, "TV_PORT", "udp")
 	if err != nil {
 		handleErrorResponse(c, "获取网络端口失败", err)
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"port": port,
+	c.JSON(http.StatusOK, NetworkPortResponse{
+		Port: port,
 	})
 }
 
-// infoNetworkGamePasswdHandler 处理获取游戏密码的请求
-func infoNetworkGamePasswdHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type NetworkPasswdRequest struct {
-		Name string `form:"name" binding:"required"` // 容器名称
-	}
+// NetworkPasswdRequest 获取游戏密码的请求参数
+type NetworkPasswdRequest struct {
+	Name   string `form:"name" binding:"required"` // 容器名称
+	Reveal bool   `form:"reveal"`                  // 是否返回明文，需要 secret.view 权限
+}
+
+// NetworkPasswdResponse 密码的响应，未显示明文时 passwd 为隐去后的值
+type NetworkPasswdResponse struct {
+	Set    bool   `json:"set"` // 是否设置了密码
+	Passwd string `json:"passwd"`
+}
 
+// infoNetworkGamePasswdHandler 处理获取游戏密码的请求，明文需要 secret.view 权限
+func infoNetworkGamePasswdHandler(c *gin.Context) {
 	var req NetworkPasswdRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 	// 获取游戏密码
-	passwd, err := GetEnvValue(FullName(req.Name), "CS2_PW")
+	reveal, ok := revealSecrets(c, req.Name)
+	if !ok {
+		return
+	}
+	passwd, err := GetEnvValue(req.Name, "CS2_PW")
 	if err != nil {
 		handleErrorResponse(c, "获取游戏密码失败", err)
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"passwd": passwd,
+	// 默认只返回是否设置了密码，reveal=true 时返回明文
+	set := passwd != ""
+	if !reveal {
+		passwd = redactSecret(passwd)
+	}
+
+	c.JSON(http.StatusOK, NetworkPasswdResponse{
+		Set:    set,
+		Passwd: passwd,
 	})
 }
 
-// infoNetworkTVPasswdHandler 处理获取TV密码的请求
+// infoNetworkTVPasswdHandler 处理获取TV密码的请求，明文需要 secret.view 权限
 func infoNetworkTVPasswdHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type NetworkPasswdRequest struct {
-		Name string `form:"name" binding:"required"` // 容器名称
-	}
-
 	var req NetworkPasswdRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 	// 获取TV密码
-	passwd, err := GetEnvValue(FullName(req.Name), "CS2_TV_PW")
+	reveal, ok := revealSecrets(c, req.Name)
+	if !ok {
+		return
+	}
+	passwd, err := GetEnvValue(req.Name, "CS2_TV_PW")
 	if err != nil {
 		handleErrorResponse(c, "获取TV密码失败", err)
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"passwd": passwd,
+	// 默认只返回是否设置了密码，reveal=true 时返回明文
+	set := passwd != ""
+	if !reveal {
+		passwd = redactSecret(passwd)
+	}
+
+	c.JSON(http.StatusOK, NetworkPasswdResponse{
+		Set:    set,
+		Passwd: passwd,
 	})
 }
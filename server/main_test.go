@@ -0,0 +1,408 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/gin-gonic/gin"
+)
+
+// 测试不读取配置文件也不连接 Docker：面板数据写入临时目录，Docker 客户端指向 fakeDocker
+
+const (
+	testPanelID       = "test"
+	testAdminPassword = "test-password"
+)
+
+// testDocker 测试使用的 Docker 替身
+var testDocker = &fakeDocker{}
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dir, err := os.MkdirTemp("", "cs2panel-test-")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	// panelDataPath 以当前工作目录为基准
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.PanelDataDir = "data"
+	cfg.Server.AdminPassword = testAdminPassword
+	cfg.Docker.Prefix = "cs2-"
+	cfg.Docker.PanelID = testPanelID
+	config.GlobalConfig = cfg
+
+	srv := httptest.NewServer(testDocker)
+	defer srv.Close()
+	docker.Cli, err = client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithHTTPClient(srv.Client()))
+	if err != nil {
+		panic(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	if err := bootstrapAdmin(); err != nil {
+		panic(err)
+	}
+	return m.Run()
+}
+
+// fakeDocker 只实现测试用到的 Docker Engine API：列出、查看、创建、启停、改名与删除容器，查看容器内的文件信息
+type fakeDocker struct {
+	mu         sync.Mutex
+	containers []types.Container
+	env        map[string][]string // 容器 ID -> 环境变量
+	started    []string            // 依次被启动的容器 ID
+	created    int
+	paths      map[string]container.PathStat // 容器内路径 -> 文件信息，所有容器共用
+}
+
+// dockerPathRegex 去掉 API 版本前缀后的路径
+var dockerPathRegex = regexp.MustCompile(`^(/v[0-9.]+)?(/.*)$`)
+
+// containerPathRegex 单个容器的接口路径：/containers/{id 或名称}[/操作]
+var containerPathRegex = regexp.MustCompile(`^/containers/([^/]+)(/[a-z]+)?$`)
+
+// setContainers 替换替身中的容器
+func (f *fakeDocker) setContainers(containers ...types.Container) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.containers = containers
+	f.env = make(map[string][]string)
+	f.started = nil
+	f.paths = nil
+}
+
+// setPaths 设置容器内的文件信息
+func (f *fakeDocker) setPaths(paths map[string]container.PathStat) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = paths
+}
+
+// setEnv 设置容器的环境变量
+func (f *fakeDocker) setEnv(id string, env ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.env[id] = env
+}
+
+// snapshot 返回当前的容器与启动记录
+func (f *fakeDocker) snapshot() ([]types.Container, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]types.Container(nil), f.containers...), append([]string(nil), f.started...)
+}
+
+// find 按 ID 或名称查找容器，调用方需持有锁
+func (f *fakeDocker) find(id string) (int, bool) {
+	for i, c := range f.containers {
+		if c.ID == id || c.Names[0] == "/"+id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (f *fakeDocker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := dockerPathRegex.FindStringSubmatch(r.URL.Path)[2]
+	switch {
+	case path == "/_ping":
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && path == "/containers/json":
+		args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+		if err != nil {
+			writeDockerError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		result := []types.Container{}
+		for _, c := range f.containers {
+			if args.MatchKVList("label", c.Labels) && (!args.Contains("id") || args.ExactMatch("id", c.ID)) {
+				result = append(result, c)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		f.create(w, r)
+	default:
+		m := containerPathRegex.FindStringSubmatch(path)
+		if m == nil {
+			writeDockerError(w, http.StatusNotFound, "page not found")
+			return
+		}
+		i, ok := f.find(m[1])
+		if !ok {
+			writeDockerError(w, http.StatusNotFound, "No such container: "+m[1])
+			return
+		}
+		f.serveContainer(w, r, i, m[2])
+	}
+}
+
+// serveContainer 处理单个容器的接口，调用方需持有锁
+func (f *fakeDocker) serveContainer(w http.ResponseWriter, r *http.Request, i int, action string) {
+	c := &f.containers[i]
+	switch {
+	case r.Method == http.MethodGet && action == "/json":
+		_ = json.NewEncoder(w).Encode(types.ContainerJSON{
+			ContainerJSONBase: &types.ContainerJSONBase{
+				ID:         c.ID,
+				Name:       c.Names[0],
+				State:      &types.ContainerState{Status: c.State, Running: c.State == "running"},
+				HostConfig: &container.HostConfig{},
+			},
+			Config: &container.Config{Image: c.Image, Env: f.env[c.ID], Labels: c.Labels},
+		})
+	case r.Method == http.MethodHead && action == "/archive":
+		stat, ok := f.paths[r.URL.Query().Get("path")]
+		if !ok {
+			writeDockerError(w, http.StatusNotFound, "Could not find the file "+r.URL.Query().Get("path")+" in container "+c.ID)
+			return
+		}
+		data, _ := json.Marshal(stat)
+		w.Header().Set("X-Docker-Container-Path-Stat", base64.StdEncoding.EncodeToString(data))
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && action == "/start":
+		c.State = "running"
+		f.started = append(f.started, c.ID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "/stop":
+		c.State = "exited"
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "/rename":
+		name := r.URL.Query().Get("name")
+		if _, taken := f.find(name); taken {
+			writeDockerError(w, http.StatusConflict, "Conflict. The container name \"/"+name+"\" is already in use")
+			return
+		}
+		c.Names = []string{"/" + name}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && action == "":
+		if c.State == "running" && r.URL.Query().Get("force") != "1" {
+			writeDockerError(w, http.StatusConflict, "cannot remove a running container")
+			return
+		}
+		f.containers = append(f.containers[:i], f.containers[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeDockerError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// create 按请求体创建处于 created 状态的容器，调用方需持有锁
+func (f *fakeDocker) create(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if _, taken := f.find(name); taken {
+		writeDockerError(w, http.StatusConflict, "Conflict. The container name \"/"+name+"\" is already in use")
+		return
+	}
+	var req container.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDockerError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	f.created++
+	id := fmt.Sprintf("new%d", f.created)
+	f.containers = append(f.containers, types.Container{ID: id, Names: []string{"/" + name}, Image: req.Image, State: "created", Labels: req.Labels})
+	f.env[id] = req.Env
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(container.CreateResponse{ID: id, Warnings: []string{}})
+}
+
+// fakeRcon 测试使用的 RCON 服务替身，记录收到的命令，cvar 的设置与查询按控制台的格式响应
+// 客户端在连接关闭时才结束读取响应，因此每个连接认证后只回复一条命令就关闭
+type fakeRcon struct {
+	listener net.Listener
+	mu       sync.Mutex
+	commands []string
+	cvars    map[string]string
+}
+
+// startFakeRcon 启动 RCON 替身，测试结束时关闭
+func startFakeRcon(t *testing.T) *fakeRcon {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRcon{listener: l, cvars: make(map[string]string)}
+	go f.serve()
+	t.Cleanup(func() { l.Close() })
+	return f
+}
+
+// env 让容器的 RCON 端口指向替身的环境变量
+func (f *fakeRcon) env() []string {
+	_, port, _ := net.SplitHostPort(f.listener.Addr().String())
+	return []string{"CS2_RCON_PORT=" + port, "CS2_RCONPW=test"}
+}
+
+// received 返回收到的命令
+func (f *fakeRcon) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *fakeRcon) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRcon) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var header [12]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		id := binary.LittleEndian.Uint32(header[4:8])
+		kind := binary.LittleEndian.Uint32(header[8:12])
+		body := make([]byte, size-8)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		command := strings.TrimRight(string(body), "\x00")
+
+		switch kind {
+		case 3: // 认证
+			writeRconPacket(conn, id, 2, "")
+		case 2: // 执行命令
+			writeRconPacket(conn, id, 0, f.exec(command))
+			return
+		}
+	}
+}
+
+// exec 记录命令；不带参数时按 `name = value` 返回 cvar 的值，带参数时保存
+func (f *fakeRcon) exec(command string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, command)
+	name, args := splitRconCommand(command)
+	if args == "" {
+		return fmt.Sprintf("%s = %s", name, f.cvars[name])
+	}
+	f.cvars[name] = strings.Trim(args, `"`)
+	return ""
+}
+
+// writeRconPacket 按 Source RCON 协议写入一个数据包
+func writeRconPacket(w io.Writer, id, kind uint32, body string) {
+	packet := make([]byte, 12, 14+len(body))
+	binary.LittleEndian.PutUint32(packet[0:4], uint32(10+len(body)))
+	binary.LittleEndian.PutUint32(packet[4:8], id)
+	binary.LittleEndian.PutUint32(packet[8:12], kind)
+	packet = append(append(packet, body...), 0, 0)
+	_, _ = w.Write(packet)
+}
+
+// writeDockerError 按 Docker Engine API 的格式返回错误
+func writeDockerError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// panelContainer 带面板标签的容器
+func panelContainer(id, name, state string) types.Container {
+	return types.Container{
+		ID:     id,
+		Names:  []string{"/cs2-" + name},
+		State:  state,
+		Labels: map[string]string{LabelPanelID: testPanelID, LabelServerName: name},
+	}
+}
+
+// testRouter 与 ServerStart 相同的 API 路由
+func testRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(RequestID())
+	ServerSetRouter(router)
+	return router
+}
+
+// serveJSON 发送 JSON 请求，body 为 nil 时不带请求体
+func serveJSON(router *gin.Engine, method, path string, body any, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// adminSession 以初始管理员登录，返回会话 Cookie
+func adminSession(t *testing.T, router *gin.Engine) *http.Cookie {
+	t.Helper()
+	return loginSession(t, router, bootstrapAdminName, testAdminPassword)
+}
+
+// userSession 创建拥有指定角色分配的普通用户并登录，返回会话 Cookie
+func userSession(t *testing.T, router *gin.Engine, username string, bindings ...RoleBinding) *http.Cookie {
+	t.Helper()
+	if _, err := userStore.Create(username, testAdminPassword, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := roleStore.SetBindings(username, bindings); err != nil {
+		t.Fatal(err)
+	}
+	return loginSession(t, router, username, testAdminPassword)
+}
+
+// loginSession 登录并返回会话 Cookie
+func loginSession(t *testing.T, router *gin.Engine, username, password string) *http.Cookie {
+	t.Helper()
+	w := serveJSON(router, http.MethodPost, "/api/auth/login", AuthLoginRequest{Username: username, Password: password})
+	if w.Code != http.StatusOK {
+		t.Fatalf("登录失败: %d %s", w.Code, w.Body.String())
+	}
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == SessionCookieName {
+			return cookie
+		}
+	}
+	t.Fatal("登录响应中没有会话 Cookie")
+	return nil
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MapChangeMethodMap 使用 map 命令重新加载服务器
+	MapChangeMethodMap = "map"
+	// MapChangeMethodChangelevel 使用 changelevel 切换地图，保留已连接的玩家
+	MapChangeMethodChangelevel = "changelevel"
+)
+
+var (
+	// ErrMapUnknown 地图不在地图列表中
+	ErrMapUnknown = errors.New("未知的地图")
+	// ErrMapModeIncompatible 地图不支持服务器当前的游戏模式
+	ErrMapModeIncompatible = errors.New("地图不支持当前游戏模式")
+	// ErrMapNotInstalled 地图在地图列表中，但游戏卷上没有对应的 VPK
+	ErrMapNotInstalled = errors.New("地图未安装")
+)
+
+// playableModeGameTypes Wiki 可玩模式名（小写）对应的 game_type 与 game_mode
+var playableModeGameTypes = map[string][2]string{
+	"casual":      {"0", "0"},
+	"competitive": {"0", "1"},
+	"premier":     {"0", "1"},
+	"wingman":     {"0", "2"},
+	"arms race":   {"1", "0"},
+	"demolition":  {"1", "1"},
+	"deathmatch":  {"1", "2"},
+}
+
+// MapChangeOptions 切换地图的选项
+type MapChangeOptions struct {
+	Method       string // map 或 changelevel，为空时自动选择
+	Preset       string // 切换前应用的预设
+	SwitchPreset bool   // 地图不支持当前模式时自动切换到地图支持的内置预设
+}
+
+// MapChangeResult 切换地图的结果
+type MapChangeResult struct {
+	Map        string        `json:"map"`
+	Command    string        `json:"command"`
+	Response   string        `json:"response"`
+	Preset     *PresetResult `json:"preset,omitempty"` // 切换时应用的预设
+	CurrentMap string        `json:"current_map"`      // 加载完成后 status_json 报告的地图
+}
+
+// mapModes 地图可玩模式对应的 game_type/game_mode，无法识别的模式名被忽略
+func mapModes(entry MapCatalogEntry) [][2]string {
+	var modes [][2]string
+	for _, mode := range entry.PlayableModes {
+		if tm, ok := playableModeGameTypes[strings.ToLower(strings.TrimSpace(mode))]; ok {
+			modes = append(modes, tm)
+		}
+	}
+	return modes
+}
+
+// mapSupportsMode 判断地图是否支持指定的 game_type/game_mode
+// 没有可识别的模式信息（自定义地图等）时视为支持
+func mapSupportsMode(entry MapCatalogEntry, gameType, gameMode string) bool {
+	modes := mapModes(entry)
+	if len(modes) == 0 {
+		return true
+	}
+	for _, tm := range modes {
+		if CvarValuesEqual(tm[0], gameType) && CvarValuesEqual(tm[1], gameMode) {
+			return true
+		}
+	}
+	return false
+}
+
+// presetForMap 选择第一个与地图可玩模式匹配的内置预设
+func presetForMap(entry MapCatalogEntry) (GamePreset, bool) {
+	for _, preset := range builtinPresets {
+		if preset.Name != "practice" && mapSupportsMode(entry, preset.GameType, preset.GameMode) {
+			return preset, true
+		}
+	}
+	return GamePreset{}, false
+}
+
+// findCatalogMap 在地图目录中查找已安装的非创意工坊地图
+// 扫描游戏卷失败时无法判断是否安装，不做安装检查
+func findCatalogMap(ctx context.Context, mapName string) (MapCatalogEntry, error) {
+	catalog, scanErr := BuildMapCatalog(ctx, false)
+	if catalog == nil {
+		return MapCatalogEntry{}, scanErr
+	}
+	for _, entry := range catalog {
+		if entry.Workshop || !strings.EqualFold(entry.InternalName, mapName) {
+			continue
+		}
+		if !entry.Installed && scanErr == nil {
+			return MapCatalogEntry{}, fmt.Errorf("%w: %s", ErrMapNotInstalled, entry.InternalName)
+		}
+		return entry, nil
+	}
+	return MapCatalogEntry{}, fmt.Errorf("%w: %s", ErrMapUnknown, mapName)
+}
+
+// workshopMapName 已下载的创意工坊地图的加载名称，合集或尚未下载时返回空
+func workshopMapName(ctx context.Context, item WorkshopItem) string {
+	if item.Type != WorkshopTypeMap {
+		return ""
+	}
+	installed, _ := ScanInstalledMaps(ctx, false)
+	for _, m := range installed {
+		if m.WorkshopID == item.ID {
+			return m.InternalName
+		}
+	}
+	return ""
+}
+
+// ChangeMap 校验并切换地图
+// 地图必须在地图目录中且已安装，并支持服务器当前的 game_type/game_mode；指定或自动选择预设时先切换模式再加载地图
+// 创意工坊地图不做模式校验。切换后轮询 status_json 确认地图已加载
+func ChangeMap(ctx context.Context, server, mapName string, opts MapChangeOptions) (*MapChangeResult, error) {
+	result := &MapChangeResult{Map: mapName}
+
+	if item, ok, err := ResolveWorkshopItem(ctx, mapName); err != nil {
+		return nil, err
+	} else if ok {
+		previous := ""
+		if status, err := GetServerStatusJSON(server); err == nil {
+			previous = status.Server.Map
+		}
+		expected := workshopMapName(ctx, item)
+
+		result.Command = item.Command()
+		if result.Response, err = ExecRconCommand(ctx, server, result.Command); err != nil {
+			return nil, err
+		}
+		// 已下载的地图等待其加载名称，合集与首次下载的地图事先不知道名称，等待地图切换
+		if expected != "" {
+			result.CurrentMap, err = WaitForMap(server, expected, mapLoadTimeout)
+		} else {
+			result.CurrentMap, err = WaitForMapChange(server, previous, mapLoadTimeout)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	entry, err := findCatalogMap(ctx, mapName)
+	if err != nil {
+		return nil, err
+	}
+	mapName = entry.InternalName
+	result.Map = mapName
+
+	gameType, err := ReadCvar(server, "game_type")
+	if err != nil {
+		return nil, err
+	}
+	gameMode, err := ReadCvar(server, "game_mode")
+	if err != nil {
+		return nil, err
+	}
+
+	var preset *GamePreset
+	switch {
+	case opts.Preset != "":
+		p, err := presetStore.Get(opts.Preset)
+		if err != nil {
+			return nil, err
+		}
+		if !mapSupportsMode(entry, p.GameType, p.GameMode) {
+			return nil, fmt.Errorf("%w: %s 不支持预设 %s，可玩模式: %s", ErrMapModeIncompatible, mapName, p.Name, strings.Join(entry.PlayableModes, "、"))
+		}
+		preset = &p
+	case !mapSupportsMode(entry, gameType, gameMode):
+		if !opts.SwitchPreset {
+			return nil, fmt.Errorf("%w: %s 不支持 game_type %s game_mode %s，可玩模式: %s", ErrMapModeIncompatible, mapName, gameType, gameMode, strings.Join(entry.PlayableModes, "、"))
+		}
+		p, ok := presetForMap(entry)
+		if !ok {
+			return nil, fmt.Errorf("%w: 没有与 %s 匹配的内置预设", ErrMapModeIncompatible, mapName)
+		}
+		preset = &p
+	}
+
+	// 应用预设时由预设负责切换模式与加载地图
+	if preset != nil {
+		p := *preset
+		p.Map = mapName
+		if result.Preset, err = ApplyPreset(ctx, server, p); err != nil {
+			return nil, err
+		}
+		result.Command = MapChangeMethodMap + " " + mapName
+		result.CurrentMap = result.Preset.Map
+		return result, nil
+	}
+
+	// 服务器已加载地图时使用 changelevel 保留玩家连接，否则使用 map
+	method := opts.Method
+	if method == "" {
+		method = MapChangeMethodMap
+		if status, err := GetServerStatusJSON(server); err == nil && status.Server.Map != "" {
+			method = MapChangeMethodChangelevel
+		}
+	}
+	result.Command = method + " " + mapName
+	if result.Response, err = ExecRconCommand(ctx, server, result.Command); err != nil {
+		return nil, err
+	}
+	if result.CurrentMap, err = WaitForMap(server, mapName, mapLoadTimeout); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFindCatalogMap(t *testing.T) {
+	testDocker.setContainers()
+	ctx := context.Background()
+	defer func() { mapScanCache.at, mapScanCache.maps = time.Time{}, nil }()
+
+	// 游戏卷扫描失败时无法判断是否安装，不拒绝
+	if _, err := findCatalogMap(ctx, "de_mirage"); err != nil {
+		t.Fatalf("扫描失败时不应拒绝官方地图: %v", err)
+	}
+
+	mapScanCache.at, mapScanCache.maps = time.Now(), []InstalledMap{{InternalName: "de_dust2"}}
+	if entry, err := findCatalogMap(ctx, "DE_DUST2"); err != nil || entry.InternalName != "de_dust2" {
+		t.Errorf("已安装的地图应通过，实际 %+v %v", entry, err)
+	}
+	if _, err := findCatalogMap(ctx, "de_mirage"); !errors.Is(err, ErrMapNotInstalled) {
+		t.Errorf("未安装的官方地图应返回 ErrMapNotInstalled，实际 %v", err)
+	}
+	if _, err := findCatalogMap(ctx, "de_unknown"); !errors.Is(err, ErrMapUnknown) {
+		t.Errorf("不在地图列表中的地图应返回 ErrMapUnknown，实际 %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// mapGroupFile 自定义地图组在面板数据目录下的文件名
+	mapGroupFile = "mapgroups.json"
+	// gameModesServerFile 服务器读取的自定义模式与地图组文件，位于 game/csgo 下
+	gameModesServerFile = gameRootDir + "/gamemodes_server.txt"
+	// gameModesServerMarker 生成文件的首行标记，用于识别用户手写的文件
+	gameModesServerMarker = "// 由 CS2Panel 生成"
+)
+
+// ErrMapGroupNotFound 地图组不存在
+var ErrMapGroupNotFound = errors.New("地图组不存在")
+
+// mapGroupNameRegex 自定义地图组名称，与内置地图组一样以 mg_ 开头
+var mapGroupNameRegex = regexp.MustCompile(`^mg_[a-z0-9_]+$`)
+
+// BuiltinMapGroups 游戏自带的常用地图组
+var BuiltinMapGroups = []string{"mg_active", "mg_casualsigma", "mg_casualdelta", "mg_hostage", "mg_deathmatch", "mg_armsrace"}
+
+// gameModeKeys 游戏模式对应 gamemodes 文件中的 gameTypes 与 gameModes 键名
+var gameModeKeys = map[string][2]string{
+	"casual":      {"classic", "casual"},
+	"competitive": {"classic", "competitive"},
+	"wingman":     {"classic", "scrimcomp2v2"},
+	"armsrace":    {"gungame", "gungameprogressive"},
+	"demolition":  {"gungame", "gungametrbomb"},
+	"deathmatch":  {"gungame", "deathmatch"},
+}
+
+// MapGroup 自定义地图组
+type MapGroup struct {
+	Name  string   `json:"name"`  // 地图组名称，如 mg_custom
+	Label string   `json:"label"` // 显示名称
+	Modes []string `json:"modes"` // 可使用该地图组的游戏模式，见 gameModeKeys
+	Maps  []string `json:"maps"`  // 地图名，或登记的创意工坊名称/ID
+}
+
+// MapGroupStore 管理自定义地图组：名称 -> 地图组
+type MapGroupStore struct {
+	mu sync.Mutex
+}
+
+// 全局地图组存储
+var mapGroupStore = &MapGroupStore{}
+
+// load 读取所有地图组，调用方需持有锁
+func (s *MapGroupStore) load() (map[string]MapGroup, error) {
+	groups := make(map[string]MapGroup)
+	if _, err := loadPanelJSON(&groups, mapGroupFile); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// List 列出所有自定义地图组，按名称排序
+func (s *MapGroupStore) List() ([]MapGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]MapGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Get 按名称获取地图组
+func (s *MapGroupStore) Get(name string) (MapGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups, err := s.load()
+	if err != nil {
+		return MapGroup{}, err
+	}
+	group, ok := groups[name]
+	if !ok {
+		return MapGroup{}, fmt.Errorf("%w: %s", ErrMapGroupNotFound, name)
+	}
+	return group, nil
+}
+
+// Save 保存地图组，调用方负责校验地图
+func (s *MapGroupStore) Save(group MapGroup) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups, err := s.load()
+	if err != nil {
+		return err
+	}
+	groups[group.Name] = group
+	return savePanelJSON(groups, mapGroupFile)
+}
+
+// Delete 删除地图组
+func (s *MapGroupStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := groups[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrMapGroupNotFound, name)
+	}
+	delete(groups, name)
+	return savePanelJSON(groups, mapGroupFile)
+}
+
+// ValidateMapGroup 校验地图组名称、模式与地图，地图必须在地图目录中或为登记的创意工坊物品
+func ValidateMapGroup(ctx context.Context, group MapGroup) error {
+	if !mapGroupNameRegex.MatchString(group.Name) {
+		return invalidf("无效的地图组名称 %q，需以 mg_ 开头，只允许小写字母、数字与下划线", group.Name)
+	}
+	for _, builtin := range BuiltinMapGroups {
+		if builtin == group.Name {
+			return conflictf("不能覆盖内置地图组 %s", group.Name)
+		}
+	}
+	if len(group.Modes) == 0 {
+		return invalidf("地图组至少需要一个游戏模式")
+	}
+	for _, mode := range group.Modes {
+		if _, ok := gameModeKeys[mode]; !ok {
+			return invalidf("未知的游戏模式 %q", mode)
+		}
+	}
+	if len(group.Maps) == 0 {
+		return invalidf("地图组至少需要一张地图")
+	}
+
+	catalog, err := BuildMapCatalog(ctx, false)
+	if catalog == nil {
+		return err
+	}
+	for _, m := range group.Maps {
+		if _, ok := findGroupMap(catalog, m); !ok {
+			return fmt.Errorf("%w: %s", ErrMapUnknown, m)
+		}
+	}
+	return nil
+}
+
+// findGroupMap 在地图目录中查找地图组成员，匹配地图名或创意工坊 ID
+func findGroupMap(catalog []MapCatalogEntry, name string) (MapCatalogEntry, bool) {
+	for _, entry := range catalog {
+		if strings.EqualFold(entry.InternalName, name) || (entry.WorkshopID != "" && entry.WorkshopID == name) {
+			return entry, true
+		}
+	}
+	return MapCatalogEntry{}, false
+}
+
+// kvQuote 生成 KeyValues 字符串，KeyValues 不支持转义，去掉值中的引号与换行
+func kvQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "'", "\n", " ", "\r", " ").Replace(s) + `"`
+}
+
+// RenderGameModesServer 根据地图组生成 gamemodes_server.txt 内容
+// 创意工坊地图写为 workshop/<ID>/<地图名>，地图名未知（尚未下载）时写为 workshop/<ID>
+func RenderGameModesServer(groups []MapGroup, catalog []MapCatalogEntry, installed []InstalledMap) string {
+	workshopMaps := make(map[string]string)
+	for _, m := range installed {
+		if m.WorkshopID != "" {
+			workshopMaps[m.WorkshopID] = m.InternalName
+		}
+	}
+
+	// gameTypes -> gameModes -> 地图组
+	modes := make(map[string]map[string][]string)
+	for _, group := range groups {
+		for _, mode := range group.Modes {
+			keys := gameModeKeys[mode]
+			if modes[keys[0]] == nil {
+				modes[keys[0]] = make(map[string][]string)
+			}
+			modes[keys[0]][keys[1]] = append(modes[keys[0]][keys[1]], group.Name)
+		}
+	}
+
+	var b strings.Builder
+	line := func(depth int, parts ...string) {
+		b.WriteString(strings.Repeat("\t", depth))
+		b.WriteString(strings.Join(parts, "\t"))
+		b.WriteString("\n")
+	}
+
+	line(0, gameModesServerMarker+"，请勿手动修改")
+	line(0, kvQuote("GameModes_Server.txt"))
+	line(0, "{")
+	line(1, kvQuote("gameTypes"))
+	line(1, "{")
+	for _, gameType := range sortedKeys(modes) {
+		line(2, kvQuote(gameType))
+		line(2, "{")
+		line(3, kvQuote("gameModes"))
+		line(3, "{")
+		for _, gameMode := range sortedKeys(modes[gameType]) {
+			line(4, kvQuote(gameMode))
+			line(4, "{")
+			line(5, kvQuote("mapgroupsMP"))
+			line(5, "{")
+			for _, name := range modes[gameType][gameMode] {
+				line(6, kvQuote(name), kvQuote(""))
+			}
+			line(5, "}")
+			line(4, "}")
+		}
+		line(3, "}")
+		line(2, "}")
+	}
+	line(1, "}")
+
+	line(1, kvQuote("mapgroups"))
+	line(1, "{")
+	for _, group := range groups {
+		line(2, kvQuote(group.Name))
+		line(2, "{")
+		line(3, kvQuote("name"), kvQuote(group.Name))
+		line(3, kvQuote("displayname"), kvQuote(group.Label))
+		line(3, kvQuote("maps"))
+		line(3, "{")
+		for _, m := range group.Maps {
+			name := m
+			if entry, ok := findGroupMap(catalog, m); ok {
+				name = entry.InternalName
+				if entry.WorkshopID != "" {
+					name = "workshop/" + entry.WorkshopID
+					if vpk := workshopMaps[entry.WorkshopID]; vpk != "" {
+						name += "/" + vpk
+					}
+				}
+			}
+			line(4, kvQuote(name), kvQuote(""))
+		}
+		line(3, "}")
+		line(2, "}")
+	}
+	line(1, "}")
+	line(0, "}")
+	return b.String()
+}
+
+// sortedKeys 按字母顺序返回 map 的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SyncGameModesServer 重新生成 gamemodes_server.txt 并写入共享游戏卷，在下次加载地图时生效
+// 已存在且不是由面板生成的文件会先备份为 gamemodes_server.txt.bak
+func SyncGameModesServer(ctx context.Context) error {
+	groups, err := mapGroupStore.List()
+	if err != nil {
+		return err
+	}
+	catalog, err := BuildMapCatalog(ctx, false)
+	if catalog == nil {
+		return err
+	}
+	installed, _ := ScanInstalledMaps(ctx, false)
+
+	ctr, err := volumeContainer(ctx)
+	if err != nil {
+		return err
+	}
+
+	if old, err := readContainerFile(ctx, ctr.ID, gameModesServerFile, maxTextFileSize); err == nil && len(old) > 0 && !strings.HasPrefix(string(old), gameModesServerMarker) {
+		if err := writeContainerFile(ctx, ctr.ID, gameModesServerFile+".bak", old); err != nil {
+			return fmt.Errorf("备份 %s 失败: %w", relGamePath(gameModesServerFile), err)
+		}
+	}
+
+	content := RenderGameModesServer(groups, catalog, installed)
+	if err := writeContainerFile(ctx, ctr.ID, gameModesServerFile, []byte(content)); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", path.Base(gameModesServerFile), err)
+	}
+	return nil
+}
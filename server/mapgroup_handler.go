@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// MapGroupListResponse 地图组列表的响应
+type MapGroupListResponse struct {
+	Builtin []string   `json:"builtin"` // 内置地图组名称
+	Groups  []MapGroup `json:"groups"`
+}
+
+// MapGroupSaveResponse 保存或删除地图组的响应
+type MapGroupSaveResponse struct {
+	Message string `json:"message"`
+	Warning string `json:"warning,omitempty"` // 写入 gamemodes_server.txt 失败时的提示
+}
+
+// infoMapGroupListHandler 处理获取地图组列表的请求，返回内置地图组名称与自定义地图组
+func infoMapGroupListHandler(c *gin.Context) {
+	groups, err := mapGroupStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取地图组列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MapGroupListResponse{
+		Builtin: BuiltinMapGroups,
+		Groups:  groups,
+	})
+}
+
+// syncGameModesResponse 同步 gamemodes_server.txt 并生成响应，同步失败时数据已保存，返回警告
+func syncGameModesResponse(c *gin.Context, message string) {
+	resp := MapGroupSaveResponse{
+		Message: message + "，将在下次加载地图时生效",
+	}
+	if err := SyncGameModesServer(c.Request.Context()); err != nil {
+		util.Warn("写入 gamemodes_server.txt 失败: " + err.Error())
+		resp.Warning = "写入 gamemodes_server.txt 失败: " + err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// infoMapGroupSaveHandler 处理保存自定义地图组的请求
+func infoMapGroupSaveHandler(c *gin.Context) {
+	var req MapGroup
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := ValidateMapGroup(c.Request.Context(), req); err != nil {
+		handleErrorResponse(c, "无效的地图组", err)
+		return
+	}
+	if err := mapGroupStore.Save(req); err != nil {
+		handleErrorResponse(c, "保存地图组失败", err)
+		return
+	}
+
+	util.Info("保存地图组成功 地图组: " + req.Name)
+	syncGameModesResponse(c, "保存地图组成功")
+}
+
+// MapGroupDeleteRequest 删除自定义地图组的请求参数
+type MapGroupDeleteRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// infoMapGroupDeleteHandler 处理删除自定义地图组的请求
+func infoMapGroupDeleteHandler(c *gin.Context) {
+	var req MapGroupDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := mapGroupStore.Delete(req.Name); err != nil {
+		handleErrorResponse(c, "删除地图组失败", err)
+		return
+	}
+
+	util.Info("删除地图组成功 地图组: " + req.Name)
+	syncGameModesResponse(c, "删除地图组成功")
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/VanVodkaer/CS2Panel/openapi"
+)
+
+// OpenAPI 文档中的认证方式
+const (
+	securitySession = "session" // 会话 Cookie
+	securityToken   = "token"   // API 令牌（Authorization: Bearer）
+)
+
+// permAdmin 文档中表示需要管理员的权限
+const permAdmin = "admin"
+
+// sessionOnly 只接受登录会话、不接受 API 令牌的接口
+var sessionOnly = []string{securitySession}
+
+// apiOperations /api 下所有接口的说明，用于生成 OpenAPI 文档
+// 新增或修改路由、请求与响应结构体时需要同步修改，openapi 包的契约测试会检查二者是否一致
+var apiOperations = []openapi.Operation{
+	{ID: "openAPI", Method: http.MethodGet, Path: "/openapi.json", Tag: "meta", Summary: "获取 OpenAPI 文档", Public: true},
+
+	// auth
+	{ID: "authLogin", Method: http.MethodPost, Path: "/auth/login", Tag: "auth", Summary: "登录，成功后通过 Cookie 下发会话",
+		Public: true, Body: AuthLoginRequest{}, Response: AuthUserResponse{}},
+	{ID: "authLogout", Method: http.MethodPost, Path: "/auth/logout", Tag: "auth", Summary: "退出登录",
+		Public: true, Response: MessageResponse{}},
+	{ID: "authMe", Method: http.MethodGet, Path: "/auth/me", Tag: "auth", Summary: "获取当前登录用户",
+		Response: AuthMeResponse{}},
+	{ID: "authPassword", Method: http.MethodPost, Path: "/auth/password", Tag: "auth", Summary: "修改自己密码",
+		Security: sessionOnly, Body: AuthPasswordRequest{}, Response: MessageResponse{}},
+	{ID: "authTokenList", Method: http.MethodGet, Path: "/auth/token/list", Tag: "auth", Summary: "获取 API 令牌列表",
+		Security: sessionOnly, Params: AuthTokenListRequest{}, Response: AuthTokenListResponse{}},
+	{ID: "authTokenCreate", Method: http.MethodPost, Path: "/auth/token/create", Tag: "auth", Summary: "创建 API 令牌",
+		Security: sessionOnly, Body: AuthTokenCreateRequest{}, Response: AuthTokenCreateResponse{}},
+	{ID: "authTokenRevoke", Method: http.MethodPost, Path: "/auth/token/revoke", Tag: "auth", Summary: "吊销 API 令牌",
+		Security: sessionOnly, Body: AuthTokenRevokeRequest{}, Response: MessageResponse{}},
+	{ID: "authUserList", Method: http.MethodGet, Path: "/auth/user/list", Tag: "auth", Summary: "获取用户列表",
+		Permission: permAdmin, Security: sessionOnly, Response: AuthUserListResponse{}},
+	{ID: "authUserCreate", Method: http.MethodPost, Path: "/auth/user/create", Tag: "auth", Summary: "创建用户",
+		Permission: permAdmin, Security: sessionOnly, Body: AuthUserCreateRequest{}, Response: AuthUserResponse{}},
+	{ID: "authUserPassword", Method: http.MethodPost, Path: "/auth/user/password", Tag: "auth", Summary: "管理员重置用户密码",
+		Permission: permAdmin, Security: sessionOnly, Body: AuthUserPasswordRequest{}, Response: MessageResponse{}},
+	{ID: "authUserDelete", Method: http.MethodPost, Path: "/auth/user/delete", Tag: "auth", Summary: "删除用户",
+		Permission: permAdmin, Security: sessionOnly, Body: AuthUserDeleteRequest{}, Response: MessageResponse{}},
+	{ID: "authRoleList", Method: http.MethodGet, Path: "/auth/role/list", Tag: "auth", Summary: "获取角色列表",
+		Permission: permAdmin, Security: sessionOnly, Response: AuthRoleListResponse{}},
+	{ID: "authRoleSave", Method: http.MethodPost, Path: "/auth/role/save", Tag: "auth", Summary: "保存自定义角色",
+		Permission: permAdmin, Security: sessionOnly, Body: Role{}, Response: MessageResponse{}},
+	{ID: "authRoleDelete", Method: http.MethodPost, Path: "/auth/role/delete", Tag: "auth", Summary: "删除自定义角色",
+		Permission: permAdmin, Security: sessionOnly, Body: AuthRoleDeleteRequest{}, Response: MessageResponse{}},
+	{ID: "authRoleBindingGet", Method: http.MethodGet, Path: "/auth/role/binding", Tag: "auth", Summary: "获取用户角色分配",
+		Permission: permAdmin, Security: sessionOnly, Params: AuthRoleBindingGetRequest{}, Response: AuthRoleBindingResponse{}},
+	{ID: "authRoleBindingSet", Method: http.MethodPost, Path: "/auth/role/binding", Tag: "auth", Summary: "设置用户角色分配",
+		Permission: permAdmin, Security: sessionOnly, Body: AuthRoleBindingSetRequest{}, Response: MessageResponse{}},
+
+	// audit
+	{ID: "auditList", Method: http.MethodGet, Path: "/audit/list", Tag: "audit", Summary: "查询审计日志",
+		Permission: PermAuditView, Params: AuditFilter{}, Response: AuditListResponse{}},
+	{ID: "auditExport", Method: http.MethodGet, Path: "/audit/export", Tag: "audit", Summary: "以 JSONL 格式导出审计日志",
+		Permission: PermAuditView, Params: AuditFilter{}, Raw: "application/x-ndjson"},
+
+	// docker
+	{ID: "dockerPing", Method: http.MethodGet, Path: "/docker/ping", Tag: "docker", Summary: "检查 Docker 服务是否可用",
+		Permission: PermContainerView, Response: DockerPingResponse{}},
+	{ID: "dockerImagePull", Method: http.MethodPost, Path: "/docker/image/pull", Tag: "docker", Summary: "开始拉取游戏服务器镜像",
+		Permission: PermContainerAdmin, Statuses: []int{http.StatusAccepted}, Response: MessageResponse{}},
+	{ID: "dockerImagePullStatus", Method: http.MethodGet, Path: "/docker/image/pull/status", Tag: "docker", Summary: "获取 Docker 镜像拉取状态",
+		Permission: PermContainerAdmin, Response: ImagePullStatusResponse{}},
+	{ID: "dockerContainerList", Method: http.MethodGet, Path: "/docker/container/list", Tag: "docker", Summary: "获取 Docker 容器列表",
+		Permission: PermContainerView, Response: ContainerListResponse{}, Deprecated: true},
+	{ID: "dockerContainerInspect", Method: http.MethodGet, Path: "/docker/container/inspect", Tag: "docker", Summary: "获取服务器容器详情",
+		Permission: PermContainerView, Params: ContainerInspectRequest{}, Response: ContainerInspectResponse{}, Deprecated: true},
+	{ID: "dockerContainerCreate", Method: http.MethodPost, Path: "/docker/container/create", Tag: "docker", Summary: "创建 Docker 容器",
+		Permission: PermContainerAdmin, Body: ContainerCreateRequest{}, Response: ContainerResponse{}, Deprecated: true},
+	{ID: "dockerContainerUpdate", Method: http.MethodPost, Path: "/docker/container/update", Tag: "docker", Summary: "修改服务器设置",
+		Permission: PermContainerAdmin, Body: ContainerUpdateRequest{}, Response: ContainerResponse{}, Deprecated: true},
+	{ID: "dockerContainerStart", Method: http.MethodPost, Path: "/docker/container/start", Tag: "docker", Summary: "启动一个或多个 Docker 容器",
+		Permission: PermContainerAdmin, Statuses: []int{http.StatusMultiStatus}, Body: ContainerStartRequest{}, Response: ContainerStartResponse{}},
+	{ID: "dockerContainerStop", Method: http.MethodPost, Path: "/docker/container/stop", Tag: "docker", Summary: "停止一个或多个 Docker 容器",
+		Permission: PermContainerAdmin, Statuses: []int{http.StatusMultiStatus}, Body: BatchRequest{}, Response: ContainerStopResponse{}},
+	{ID: "dockerContainerRestart", Method: http.MethodPost, Path: "/docker/container/restart", Tag: "docker", Summary: "重启一个或多个 Docker 容器",
+		Permission: PermContainerAdmin, Statuses: []int{http.StatusMultiStatus}, Body: BatchRequest{}, Response: ContainerRestartResponse{}},
+	{ID: "dockerContainerRemove", Method: http.MethodPost, Path: "/docker/container/remove", Tag: "docker", Summary: "删除一个或多个 Docker 容器",
+		Permission: PermContainerAdmin, Statuses: []int{http.StatusMultiStatus}, Body: BatchRequest{}, Response: ContainerRemoveResponse{}},
+	{ID: "dockerContainerImportCandidates", Method: http.MethodGet, Path: "/docker/container/import/candidates", Tag: "docker", Summary: "获取可导入的 CS2 容器列表",
+		Permission: PermContainerAdmin, Response: ImportCandidatesResponse{}},
+	{ID: "dockerContainerImport", Method: http.MethodPost, Path: "/docker/container/import", Tag: "docker", Summary: "将已有容器导入面板",
+		Permission: PermContainerAdmin, Body: ContainerImportRequest{}, Response: ContainerResponse{}},
+	{ID: "dockerContainerImportRelease", Method: http.MethodPost, Path: "/docker/container/import/release", Tag: "docker", Summary: "取消导入",
+		Permission: PermContainerAdmin, Body: ContainerImportReleaseRequest{}, Response: MessageResponse{}},
+
+	// info
+	{ID: "infoMapUpdate", Method: http.MethodPost, Path: "/info/map/update", Tag: "info", Summary: "从 Wiki 更新地图元数据",
+		Permission: PermCatalogManage, Params: MapUpdateRequest{}, Response: MapUpdateResponse{}},
+	{ID: "infoMapList", Method: http.MethodGet, Path: "/info/map/list", Tag: "info", Summary: "获取地图列表",
+		Permission: PermContainerView, Params: MapListRequest{}, Response: MapListResponse{}},
+	{ID: "infoMapGroupList", Method: http.MethodGet, Path: "/info/map/group/list", Tag: "info", Summary: "获取地图组列表",
+		Permission: PermContainerView, Response: MapGroupListResponse{}},
+	{ID: "infoMapGroupSave", Method: http.MethodPost, Path: "/info/map/group/save", Tag: "info", Summary: "保存自定义地图组",
+		Permission: PermCatalogManage, Body: MapGroup{}, Response: MapGroupSaveResponse{}},
+	{ID: "infoMapGroupDelete", Method: http.MethodPost, Path: "/info/map/group/delete", Tag: "info", Summary: "删除自定义地图组",
+		Permission: PermCatalogManage, Body: MapGroupDeleteRequest{}, Response: MapGroupSaveResponse{}},
+	{ID: "infoNetworkAddr", Method: http.MethodGet, Path: "/info/network/addr", Tag: "info", Summary: "获取网络地址",
+		Permission: PermContainerView, Response: NetworkAddrResponse{}},
+	{ID: "infoNetworkGamePort", Method: http.MethodGet, Path: "/info/network/gameport", Tag: "info", Summary: "获取游戏端口",
+		Permission: PermContainerView, Params: NetworkPortRequest{}, Response: NetworkPortResponse{}},
+	{ID: "infoNetworkTVPort", Method: http.MethodGet, Path: "/info/network/tvport", Tag: "info", Summary: "获取 SourceTV 端口",
+		Permission: PermContainerView, Params: NetworkPortRequest{}, Response: NetworkPortResponse{}},
+	{ID: "infoNetworkGamePasswd", Method: http.MethodGet, Path: "/info/network/gamepasswd", Tag: "info", Summary: "获取游戏密码",
+		Permission: PermContainerView, Params: NetworkPasswdRequest{}, Response: NetworkPasswdResponse{}},
+	{ID: "infoNetworkTVPasswd", Method: http.MethodGet, Path: "/info/network/tvpasswd", Tag: "info", Summary: "获取 SourceTV 密码",
+		Permission: PermContainerView, Params: NetworkPasswdRequest{}, Response: NetworkPasswdResponse{}},
+
+	// workshop
+	{ID: "workshopList", Method: http.MethodGet, Path: "/workshop/list", Tag: "workshop", Summary: "获取登记的创意工坊物品列表",
+		Permission: PermContainerView, Response: WorkshopListResponse{}},
+	{ID: "workshopLookup", Method: http.MethodGet, Path: "/workshop/lookup", Tag: "workshop", Summary: "查询创意工坊物品信息",
+		Permission: PermContainerView, Params: WorkshopLookupRequest{}, Response: WorkshopItemResponse{}},
+	{ID: "workshopSave", Method: http.MethodPost, Path: "/workshop/save", Tag: "workshop", Summary: "登记创意工坊地图或合集",
+		Permission: PermCatalogManage, Body: WorkshopSaveRequest{}, Response: WorkshopItemResponse{}},
+	{ID: "workshopDelete", Method: http.MethodPost, Path: "/workshop/delete", Tag: "workshop", Summary: "删除登记的创意工坊物品",
+		Permission: PermCatalogManage, Body: WorkshopDeleteRequest{}, Response: MessageResponse{}},
+
+	// file
+	{ID: "fileList", Method: http.MethodGet, Path: "/file/list", Tag: "file", Summary: "列出服务器目录内容",
+		Permission: PermFileRead, Params: FileListRequest{}, Response: FileListResponse{}},
+	{ID: "fileRead", Method: http.MethodGet, Path: "/file/read", Tag: "file", Summary: "读取文本文件",
+		Permission: PermFileRead, Params: FileReadRequest{}, Response: FileReadResponse{}},
+	{ID: "fileDownload", Method: http.MethodGet, Path: "/file/download", Tag: "file", Summary: "下载文件，目录以 tar 包形式下载",
+		Permission: PermFileRead, Params: FileDownloadRequest{}, Raw: "application/octet-stream"},
+	{ID: "fileWrite", Method: http.MethodPost, Path: "/file/write", Tag: "file", Summary: "写入文本文件",
+		Permission: PermFileWrite, Body: FileWriteRequest{}, Response: FileSaveResponse{}},
+	{ID: "fileUpload", Method: http.MethodPost, Path: "/file/upload", Tag: "file", Summary: "上传文件",
+		Permission: PermFileWrite, Form: FileUploadRequest{}, Response: FileSaveResponse{}},
+
+	// cfg
+	{ID: "profileList", Method: http.MethodGet, Path: "/cfg/profile/list", Tag: "cfg", Summary: "获取 cfg 配置列表",
+		Permission: PermContainerView, Response: ProfileListResponse{}},
+	{ID: "profileGet", Method: http.MethodGet, Path: "/cfg/profile/get", Tag: "cfg", Summary: "获取 cfg 配置内容",
+		Permission: PermContainerView, Params: ProfileGetRequest{}, Response: ProfileGetResponse{}},
+	{ID: "profileDiff", Method: http.MethodGet, Path: "/cfg/profile/diff", Tag: "cfg", Summary: "比较 cfg 配置两个版本",
+		Permission: PermContainerView, Params: ProfileDiffRequest{}, Response: ProfileDiffResponse{}},
+	{ID: "profileSave", Method: http.MethodPost, Path: "/cfg/profile/save", Tag: "cfg", Summary: "保存 cfg 配置",
+		Permission: PermCatalogManage, Body: ProfileSaveRequest{}, Response: ProfileSaveResponse{}},
+	{ID: "profileDelete", Method: http.MethodPost, Path: "/cfg/profile/delete", Tag: "cfg", Summary: "删除 cfg 配置",
+		Permission: PermCatalogManage, Body: ProfileDeleteRequest{}, Response: MessageResponse{}},
+	{ID: "profilePush", Method: http.MethodPost, Path: "/cfg/profile/push", Tag: "cfg", Summary: "将 cfg 配置写入服务器 cfg 目录",
+		Permission: PermFileWrite, Body: ProfilePushRequest{}, Response: ProfilePushResponse{}},
+	{ID: "profileExec", Method: http.MethodPost, Path: "/cfg/profile/exec", Tag: "cfg", Summary: "推送并立即执行 cfg 配置",
+		Permission: PermFileWrite, Body: ProfileExecRequest{}, Response: ProfileExecResponse{}},
+	{ID: "profileAutoexecGet", Method: http.MethodGet, Path: "/cfg/profile/autoexec", Tag: "cfg", Summary: "获取服务器自动执行配置",
+		Permission: PermFileRead, Params: ProfileAutoexecGetRequest{}, Response: ProfileAutoexecResponse{}},
+	{ID: "profileAutoexecSet", Method: http.MethodPost, Path: "/cfg/profile/autoexec", Tag: "cfg", Summary: "设置服务器自动执行配置",
+		Permission: PermFileWrite, Body: ProfileAutoexecSetRequest{}, Response: MessageResponse{}},
+
+	// rcon
+	{ID: "rconExec", Method: http.MethodPost, Path: "/rcon/exec", Tag: "rcon", Summary: "执行 RCON 命令",
+		Permission: PermRconExec, Body: RconExecRequest{}, Response: RconResponses{}},
+	{ID: "rconPasswordRotate", Method: http.MethodPost, Path: "/rcon/password/rotate", Tag: "rcon", Summary: "轮换服务器 RCON 密码",
+		Permission: PermContainerAdmin, Body: RconPasswordRotateRequest{}, Response: RconPasswordRotateResponse{}},
+	{ID: "rconPolicyCheck", Method: http.MethodPost, Path: "/rcon/policy/check", Tag: "rcon", Summary: "按当前用户的策略检查 RCON 命令而不发送",
+		Permission: PermRconExec, Body: RconPolicyCheckRequest{}, Response: RconPolicyCheckResponse{}},
+	{ID: "rconPolicyList", Method: http.MethodGet, Path: "/rcon/policy/list", Tag: "rcon", Summary: "获取 RCON 命令策略列表",
+		Permission: permAdmin, Security: sessionOnly, Response: RconPolicyListResponse{}},
+	{ID: "rconPolicySave", Method: http.MethodPost, Path: "/rcon/policy/save", Tag: "rcon", Summary: "保存角色或全局 RCON 命令策略",
+		Permission: permAdmin, Security: sessionOnly, Body: RconPolicy{}, Response: MessageResponse{}},
+	{ID: "rconPolicyDelete", Method: http.MethodPost, Path: "/rcon/policy/delete", Tag: "rcon", Summary: "删除 RCON 命令策略",
+		Permission: permAdmin, Security: sessionOnly, Body: RconPolicyDeleteRequest{}, Response: MessageResponse{}},
+	{ID: "rconGameStatus", Method: http.MethodGet, Path: "/rcon/game/status", Tag: "rcon", Summary: "获取游戏状态",
+		Permission: PermContainerView, Params: RconGameStatusRequest{}, Response: RconGameStatusResponse{}},
+	{ID: "rconGameStatusJSON", Method: http.MethodGet, Path: "/rcon/game/statusjson", Tag: "rcon", Summary: "获取游戏状态 status_json",
+		Permission: PermContainerView, Params: RconGameStatusJSONRequest{}, Response: RconGameStatusJSONResponse{}},
+	{ID: "rconGameRestart", Method: http.MethodPost, Path: "/rcon/game/restart", Tag: "rcon", Summary: "重启游戏",
+		Permission: PermGameControl, Body: RconGameRestartRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigMode", Method: http.MethodPost, Path: "/rcon/game/mode", Tag: "rcon", Summary: "同时设置游戏模式与游戏类型",
+		Permission: PermGameControl, Body: RconGameConfigModeRequest{}, Response: RconResponses{}},
+	{ID: "rconGamePresetList", Method: http.MethodGet, Path: "/rcon/game/preset/list", Tag: "rcon", Summary: "获取游戏预设列表",
+		Permission: PermContainerView, Response: PresetListResponse{}},
+	{ID: "rconGamePresetApply", Method: http.MethodPost, Path: "/rcon/game/preset/apply", Tag: "rcon", Summary: "在服务器上应用游戏预设",
+		Permission: PermGameControl, Body: RconGamePresetApplyRequest{}, Response: PresetApplyResponse{}},
+	{ID: "rconGamePresetSave", Method: http.MethodPost, Path: "/rcon/game/preset/save", Tag: "rcon", Summary: "保存自定义游戏预设",
+		Permission: PermCatalogManage, Body: GamePreset{}, Response: MessageResponse{}},
+	{ID: "rconGamePresetDelete", Method: http.MethodPost, Path: "/rcon/game/preset/delete", Tag: "rcon", Summary: "删除自定义游戏预设",
+		Permission: PermCatalogManage, Body: RconGamePresetDeleteRequest{}, Response: MessageResponse{}},
+	{ID: "rconGameDrift", Method: http.MethodGet, Path: "/rcon/game/drift", Tag: "rcon", Summary: "检查服务器 cvar 与基线差异",
+		Permission: PermContainerView, Params: RconGameDriftRequest{}, Response: DriftReportResponse{}},
+	{ID: "rconGameDriftBaselineGet", Method: http.MethodGet, Path: "/rcon/game/drift/baseline", Tag: "rcon", Summary: "获取服务器 cvar 基线",
+		Permission: PermContainerView, Params: RconGameDriftBaselineGetRequest{}, Response: DriftBaselineResponse{}},
+	{ID: "rconGameDriftBaselineSet", Method: http.MethodPost, Path: "/rcon/game/drift/baseline", Tag: "rcon", Summary: "设置服务器 cvar 基线",
+		Permission: PermGameControl, Body: RconGameDriftBaselineSetRequest{}, Response: DriftBaselineResponse{}},
+	{ID: "rconGameDriftReapply", Method: http.MethodPost, Path: "/rcon/game/drift/reapply", Tag: "rcon", Summary: "重新应用基线",
+		Permission: PermGameControl, Body: RconGameDriftReapplyRequest{}, Response: DriftReapplyResponse{}},
+	{ID: "rconGameWarmStart", Method: http.MethodPost, Path: "/rcon/game/warm/start", Tag: "rcon", Summary: "立刻切换到热身模式",
+		Permission: PermGameControl, Body: RconGameWarmStartRequest{}, Response: RconResponse{}},
+	{ID: "rconGameWarmEnd", Method: http.MethodPost, Path: "/rcon/game/warm/end", Tag: "rcon", Summary: "立刻结束热身模式",
+		Permission: PermGameControl, Body: RconGameWarmEndRequest{}, Response: RconResponse{}},
+	{ID: "rconGameWarmTime", Method: http.MethodPost, Path: "/rcon/game/warm/time", Tag: "rcon", Summary: "设置热身时间",
+		Permission: PermGameControl, Body: RconGameWarmTimeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameWarmPause", Method: http.MethodPost, Path: "/rcon/game/warm/pause", Tag: "rcon", Summary: "控制热身时间暂停",
+		Permission: PermGameControl, Body: RconGameWarmPauseRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigGameMode", Method: http.MethodPost, Path: "/rcon/game/config/gamemode", Tag: "rcon", Summary: "设置游戏模式",
+		Permission: PermGameConfig, Body: RconGameConfigGameModeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigGameType", Method: http.MethodPost, Path: "/rcon/game/config/gametype", Tag: "rcon", Summary: "设置游戏类型",
+		Permission: PermGameConfig, Body: RconGameConfigGameTypeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigMaxRounds", Method: http.MethodPost, Path: "/rcon/game/config/maxrounds", Tag: "rcon", Summary: "设置最大回合数",
+		Permission: PermGameConfig, Body: RconGameConfigMaxRoundsRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigTimeLimit", Method: http.MethodPost, Path: "/rcon/game/config/timelimit", Tag: "rcon", Summary: "设置比赛时间限制",
+		Permission: PermGameConfig, Body: RconGameConfigTimeLimitRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigRoundTime", Method: http.MethodPost, Path: "/rcon/game/config/roundtime", Tag: "rcon", Summary: "设置每回合时间",
+		Permission: PermGameConfig, Body: RconGameConfigRoundTimeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigFreezetime", Method: http.MethodPost, Path: "/rcon/game/config/freezetime", Tag: "rcon", Summary: "设置冻结时间",
+		Permission: PermGameConfig, Body: RconGameConfigFreezetimeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigBuytime", Method: http.MethodPost, Path: "/rcon/game/config/buytime", Tag: "rcon", Summary: "设置购买时间",
+		Permission: PermGameConfig, Body: RconGameConfigBuytimeRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigBuyAnywhere", Method: http.MethodPost, Path: "/rcon/game/config/buyanywhere", Tag: "rcon", Summary: "设置是否允许在地图任意位置购买装备",
+		Permission: PermGameConfig, Body: RconGameConfigBuyAnywhereRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigStartMoney", Method: http.MethodPost, Path: "/rcon/game/config/startmoney", Tag: "rcon", Summary: "设置初始金钱",
+		Permission: PermGameConfig, Body: RconGameConfigStartMoneyRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigMaxMoney", Method: http.MethodPost, Path: "/rcon/game/config/maxmoney", Tag: "rcon", Summary: "设置最大金钱",
+		Permission: PermGameConfig, Body: RconGameConfigMaxMoneyRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigAutoTeamBalance", Method: http.MethodPost, Path: "/rcon/game/config/autoteambalance", Tag: "rcon", Summary: "设置自动队伍平衡",
+		Permission: PermGameConfig, Body: RconGameConfigAutoTeamBalanceRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigAutoKick", Method: http.MethodPost, Path: "/rcon/game/config/autokick", Tag: "rcon", Summary: "设置自动踢出空闲玩家",
+		Permission: PermGameConfig, Body: RconGameConfigAutoKickRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigLimitTeams", Method: http.MethodPost, Path: "/rcon/game/config/limitteams", Tag: "rcon", Summary: "设置队伍人数差异上限",
+		Permission: PermGameConfig, Body: RconGameConfigLimitTeamsRequest{}, Response: RconResponse{}},
+	{ID: "rconGameConfigC4Timer", Method: http.MethodPost, Path: "/rcon/game/config/c4timer", Tag: "rcon", Summary: "设置 C4 爆炸倒计时",
+		Permission: PermGameConfig, Body: RconGameConfigC4TimerRequest{}, Response: RconResponse{}},
+	{ID: "rconGameUserKick", Method: http.MethodPost, Path: "/rcon/game/user/kick", Tag: "rcon", Summary: "踢出玩家",
+		Permission: PermGameUser, Body: RconGameUserKickRequest{}, Response: RconResponse{}},
+	{ID: "rconMapNow", Method: http.MethodGet, Path: "/rcon/map/now", Tag: "rcon", Summary: "获取当前地图",
+		Permission: PermContainerView, Params: RconMapNowRequest{}, Response: RconMapNowResponse{}},
+	{ID: "rconMapChange", Method: http.MethodPost, Path: "/rcon/map/change", Tag: "rcon", Summary: "切换地图",
+		Permission: PermMapChange, Body: RconMapChangeRequest{}, Response: RconMapChangeResponse{}},
+	{ID: "rconMapRotationGet", Method: http.MethodGet, Path: "/rcon/map/rotation", Tag: "rcon", Summary: "获取服务器地图轮换配置与当前进度",
+		Permission: PermContainerView, Params: RconMapRotationRequest{}, Response: RotationGetResponse{}},
+	{ID: "rconMapRotationSet", Method: http.MethodPost, Path: "/rcon/map/rotation", Tag: "rcon", Summary: "设置服务器地图轮换",
+		Permission: PermMapChange, Body: RconMapRotationSetRequest{}, Response: RotationSetResponse{}},
+	{ID: "rconMapRotationNext", Method: http.MethodPost, Path: "/rcon/map/rotation/next", Tag: "rcon", Summary: "立即切换到轮换中下一张地图",
+		Permission: PermMapChange, Body: RconMapRotationNextRequest{}, Response: RotationNextResponse{}},
+
+	// v2 servers
+	{ID: "serverList", Method: http.MethodGet, Path: "/v2/servers", Tag: "servers", Summary: "分页列出服务器",
+		Permission: PermContainerView, Params: PageRequest{}, Response: ServerListResponse{}},
+	{ID: "serverCreate", Method: http.MethodPost, Path: "/v2/servers", Tag: "servers", Summary: "创建服务器",
+		Permission: PermContainerAdmin, Body: ContainerCreateRequest{}, Response: ContainerResponse{}, Statuses: []int{http.StatusCreated}},
+	{ID: "serverGet", Method: http.MethodGet, Path: "/v2/servers/:name", Tag: "servers", Summary: "获取服务器详情",
+		Permission: PermContainerView, Params: ServerRevealRequest{}, Response: ContainerInspectResponse{}},
+	{ID: "serverUpdate", Method: http.MethodPatch, Path: "/v2/servers/:name", Tag: "servers", Summary: "修改服务器设置",
+		Permission: PermContainerAdmin, Body: ServerUpdateRequest{}, Response: ContainerResponse{}},
+	{ID: "serverDelete", Method: http.MethodDelete, Path: "/v2/servers/:name", Tag: "servers", Summary: "删除服务器",
+		Permission: PermContainerAdmin, Response: ServerActionResponse{}},
+	{ID: "serverStart", Method: http.MethodPost, Path: "/v2/servers/:name/actions/start", Tag: "servers", Summary: "启动服务器",
+		Permission: PermContainerAdmin, Body: ServerStartRequest{}, Response: ServerActionResponse{}},
+	{ID: "serverStop", Method: http.MethodPost, Path: "/v2/servers/:name/actions/stop", Tag: "servers", Summary: "停止服务器",
+		Permission: PermContainerAdmin, Response: ServerActionResponse{}},
+	{ID: "serverRestart", Method: http.MethodPost, Path: "/v2/servers/:name/actions/restart", Tag: "servers", Summary: "重启服务器",
+		Permission: PermContainerAdmin, Response: ServerActionResponse{}},
+	{ID: "serverPlayerList", Method: http.MethodGet, Path: "/v2/servers/:name/players", Tag: "servers", Summary: "分页列出在线玩家",
+		Permission: PermContainerView, Params: PageRequest{}, Response: ServerPlayerListResponse{}},
+	{ID: "serverPlayerKick", Method: http.MethodDelete, Path: "/v2/servers/:name/players/:id", Tag: "servers", Summary: "按玩家 ID 踢出玩家",
+		Permission: PermGameUser, Response: RconResponse{}},
+	{ID: "serverCvarGet", Method: http.MethodGet, Path: "/v2/servers/:name/cvars/:cvar", Tag: "servers", Summary: "读取 cvar 的当前值",
+		Permission: PermContainerView, Params: ServerRevealRequest{}, Response: ServerCvarResponse{}},
+	{ID: "serverCvarSet", Method: http.MethodPut, Path: "/v2/servers/:name/cvars/:cvar", Tag: "servers", Summary: "修改 cvar 的值",
+		Permission: PermGameConfig, Body: ServerCvarSetRequest{}, Response: ServerCvarResponse{}},
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+// OpenAPISpec 生成 OpenAPI 文档
+func OpenAPISpec() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "CS2Panel API",
+		Version:     "1",
+		Description: "CS2Panel 面板接口。错误响应统一为 ErrorResponse，code 为稳定的错误码；x-permission 为访问接口所需的面板权限",
+	}, "/api", ErrorResponse{}, map[string]*openapi.SecurityScheme{
+		securitySession: {Type: "apiKey", In: "cookie", Name: SessionCookieName},
+		securityToken:   {Type: "http", Scheme: "bearer"},
+	})
+	for _, op := range apiOperations {
+		b.Add(op)
+	}
+	return b.Document()
+}
+
+// openAPIDocumentJSON 序列化后的 OpenAPI 文档，只生成一次
+func openAPIDocumentJSON() ([]byte, error) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, openAPIErr = json.Marshal(OpenAPISpec())
+	})
+	return openAPIJSON, openAPIErr
+}
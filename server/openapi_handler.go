@@ -0,0 +1,18 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIHandler 处理获取 OpenAPI 文档的请求
+func openAPIHandler(c *gin.Context) {
+	data, err := openAPIDocumentJSON()
+	if err != nil {
+		handleErrorResponse(c, "生成 OpenAPI 文档失败", err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// checkResponse 检查实际响应的状态码与响应体是否符合生成的 OpenAPI 文档
+func checkResponse(t *testing.T, method, specPath string, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if err := OpenAPISpec().ValidateResponse(method, specPath, w.Code, w.Header().Get("Content-Type"), w.Body.Bytes()); err != nil {
+		t.Fatalf("%s %s 的响应不符合文档: %v\n%s", method, specPath, err, w.Body.String())
+	}
+}
+
+func TestOpenAPIErrorResponse(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	testDocker.setContainers()
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		specPath string
+		body     any
+		session  bool
+		status   int
+		code     ErrorCode
+	}{
+		{"unauthorized", http.MethodGet, "/api/v2/servers", "/v2/servers", nil, false, http.StatusUnauthorized, CodeUnauthorized},
+		{"invalid query", http.MethodGet, "/api/v2/servers?limit=1000", "/v2/servers", nil, true, http.StatusBadRequest, CodeInvalidRequest},
+		{"invalid body", http.MethodPost, "/api/docker/container/stop", "/docker/container/stop", map[string]any{"names": "a"}, true, http.StatusBadRequest, CodeInvalidRequest},
+		{"not found", http.MethodGet, "/api/v2/servers/missing", "/v2/servers/{name}", nil, true, http.StatusNotFound, CodeNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serveJSON(router, tc.method, tc.path, tc.body)
+			if tc.session {
+				w = serveJSON(router, tc.method, tc.path, tc.body, session)
+			}
+			if w.Code != tc.status {
+				t.Fatalf("状态码应为 %d，实际 %d: %s", tc.status, w.Code, w.Body.String())
+			}
+			body := w.Body.Bytes()
+			checkResponse(t, tc.method, tc.specPath, w)
+
+			var resp ErrorResponse
+			if err := json.Unmarshal(body, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Code != tc.code {
+				t.Errorf("错误码应为 %s，实际 %s", tc.code, resp.Code)
+			}
+			if resp.RequestID == "" || resp.RequestID != w.Header().Get(requestIDHeader) {
+				t.Errorf("响应体中的请求 ID %q 与响应头 %q 不一致", resp.RequestID, w.Header().Get(requestIDHeader))
+			}
+		})
+	}
+}
+
+func TestOpenAPIBatchResponse(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	testDocker.setContainers(panelContainer("c1", "one", "running"), panelContainer("c2", "two", "exited"))
+
+	w := serveJSON(router, http.MethodPost, "/api/docker/container/stop", BatchRequest{Names: []string{"one", "two", "missing"}}, session)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("部分失败时状态码应为 207，实际 %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.Bytes()
+	checkResponse(t, http.MethodPost, "/docker/container/stop", w)
+
+	var resp ContainerStopResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"one": BatchStatusSuccess, "two": BatchStatusAlready, "missing": BatchStatusError}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("应返回 %d 个结果，实际 %+v", len(want), resp.Results)
+	}
+	for _, r := range resp.Results {
+		if r.Status != want[r.Name] {
+			t.Errorf("%s 的状态应为 %s，实际 %s", r.Name, want[r.Name], r.Status)
+		}
+	}
+}
+
+func TestOpenAPIServerList(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	testDocker.setContainers(panelContainer("c1", "one", "running"), panelContainer("c2", "two", "exited"))
+
+	w := serveJSON(router, http.MethodGet, "/api/v2/servers?limit=1&offset=1", nil, session)
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码应为 200，实际 %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.Bytes()
+	checkResponse(t, http.MethodGet, "/v2/servers", w)
+
+	var resp ServerListResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 2 || len(resp.Servers) != 1 || resp.Servers[0].ServerName != "two" {
+		t.Errorf("分页结果不正确: %+v", resp)
+	}
+}
@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrPresetNotFound 游戏预设不存在
+var ErrPresetNotFound = errors.New("游戏预设不存在")
+
+// mapLoadTimeout 切换地图后等待地图加载的时间
+const mapLoadTimeout = 90 * time.Second
+
+// GamePreset 游戏预设：game_type/game_mode、一组 cvar 以及可选的地图与地图组
+type GamePreset struct {
+	Name     string            `json:"name"`  // 预设名称（唯一标识）
+	Label    string            `json:"label"` // 显示名称
+	GameType string            `json:"game_type"`
+	GameMode string            `json:"game_mode"`
+	Cvars    map[string]string `json:"cvars"`
+	Map      string            `json:"map"`      // 可选，应用时切换到该地图
+	MapGroup string            `json:"mapgroup"` // 可选，应用时设置地图组
+	BuiltIn  bool              `json:"builtin"`  // 是否为内置预设
+}
+
+// builtinPresets 内置预设
+var builtinPresets = []GamePreset{
+	{
+		Name: "competitive", Label: "竞技", GameType: "0", GameMode: "1",
+		Cvars: map[string]string{
+			"mp_maxrounds": "24", "mp_roundtime": "1.92", "mp_roundtime_defuse": "1.92",
+			"mp_freezetime": "15", "mp_buytime": "20", "mp_startmoney": "800",
+			"mp_maxmoney": "16000", "mp_c4timer": "40", "mp_overtime_enable": "1",
+			"mp_friendlyfire": "1",
+		},
+	},
+	{
+		Name: "wingman", Label: "决斗", GameType: "0", GameMode: "2",
+		Cvars: map[string]string{
+			"mp_maxrounds": "16", "mp_roundtime": "1.5", "mp_roundtime_defuse": "1.5",
+			"mp_freezetime": "10", "mp_buytime": "15", "mp_startmoney": "800",
+			"mp_maxmoney": "8000", "mp_c4timer": "40", "mp_friendlyfire": "1",
+		},
+	},
+	{
+		Name: "casual", Label: "休闲", GameType: "0", GameMode: "0",
+		Cvars: map[string]string{
+			"mp_maxrounds": "15", "mp_roundtime": "2.25", "mp_roundtime_defuse": "2.25",
+			"mp_freezetime": "6", "mp_buytime": "45", "mp_startmoney": "1000",
+			"mp_maxmoney": "10000", "mp_friendlyfire": "0",
+		},
+	},
+	{
+		Name: "deathmatch", Label: "死亡竞赛", GameType: "1", GameMode: "2",
+		Cvars: map[string]string{
+			"mp_timelimit": "10", "mp_roundtime": "10", "mp_buy_anywhere": "1",
+			"mp_buytime": "9999", "mp_respawn_on_death_ct": "1", "mp_respawn_on_death_t": "1",
+			"mp_friendlyfire": "0",
+		},
+	},
+	{
+		Name: "armsrace", Label: "军备竞赛", GameType: "1", GameMode: "0", MapGroup: "mg_armsrace",
+		Cvars: map[string]string{
+			"mp_timelimit": "0", "mp_respawn_on_death_ct": "1", "mp_respawn_on_death_t": "1",
+			"mp_friendlyfire": "0",
+		},
+	},
+	{
+		Name: "practice", Label: "练习", GameType: "0", GameMode: "1",
+		Cvars: map[string]string{
+			"sv_cheats": "1", "mp_limitteams": "0", "mp_autoteambalance": "0",
+			"mp_roundtime": "60", "mp_roundtime_defuse": "60", "mp_freezetime": "0",
+			"mp_buy_anywhere": "1", "mp_buytime": "9999", "mp_startmoney": "60000",
+			"mp_maxmoney": "60000", "sv_infinite_ammo": "1", "ammo_grenade_limit_total": "5",
+			"mp_respawn_on_death_ct": "1", "mp_respawn_on_death_t": "1",
+		},
+	},
+}
+
+// 标记内置预设
+func init() {
+	for i := range builtinPresets {
+		builtinPresets[i].BuiltIn = true
+	}
+}
+
+// presetFile 自定义预设在面板数据目录下的文件名
+const presetFile = "presets.json"
+
+// PresetStore 管理内置与自定义游戏预设
+type PresetStore struct {
+	mu sync.Mutex
+}
+
+// 全局预设存储
+var presetStore = &PresetStore{}
+
+// loadCustom 读取自定义预设，调用方需持有锁
+func (s *PresetStore) loadCustom() (map[string]GamePreset, error) {
+	custom := make(map[string]GamePreset)
+	if _, err := loadPanelJSON(&custom, presetFile); err != nil {
+		return nil, err
+	}
+	return custom, nil
+}
+
+// List 列出所有预设，内置预设在前
+func (s *PresetStore) List() ([]GamePreset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	custom, err := s.loadCustom()
+	if err != nil {
+		return nil, err
+	}
+
+	presets := append([]GamePreset{}, builtinPresets...)
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		presets = append(presets, custom[name])
+	}
+	return presets, nil
+}
+
+// Get 按名称获取预设，自定义预设不能覆盖内置预设
+func (s *PresetStore) Get(name string) (GamePreset, error) {
+	for _, preset := range builtinPresets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	custom, err := s.loadCustom()
+	if err != nil {
+		return GamePreset{}, err
+	}
+	preset, ok := custom[name]
+	if !ok {
+		return GamePreset{}, fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	return preset, nil
+}
+
+// Save 保存自定义预设
+func (s *PresetStore) Save(preset GamePreset) error {
+	if !profileNameRegex.MatchString(preset.Name) {
+		return invalidf("无效的预设名称 %q，只允许小写字母、数字与下划线", preset.Name)
+	}
+	for _, builtin := range builtinPresets {
+		if builtin.Name == preset.Name {
+			return conflictf("不能覆盖内置预设 %s", preset.Name)
+		}
+	}
+	if err := validateCvars(preset.Cvars); err != nil {
+		return err
+	}
+	if err := validateCvars(map[string]string{
+		"game_type": preset.GameType, "game_mode": preset.GameMode,
+	}); err != nil {
+		return err
+	}
+	for _, v := range []string{preset.Map, preset.MapGroup} {
+		if v != "" && !cvarNameRegex.MatchString(v) {
+			return invalidf("无效的地图或地图组名称 %q", v)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	custom, err := s.loadCustom()
+	if err != nil {
+		return err
+	}
+	preset.BuiltIn = false
+	custom[preset.Name] = preset
+	return savePanelJSON(custom, presetFile)
+}
+
+// Delete 删除自定义预设
+func (s *PresetStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	custom, err := s.loadCustom()
+	if err != nil {
+		return err
+	}
+	if _, ok := custom[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	delete(custom, name)
+	return savePanelJSON(custom, presetFile)
+}
+
+// ExpectedCvars 预设应用后期望的 cvar 值，包括 game_type 与 game_mode
+func (p GamePreset) ExpectedCvars() map[string]string {
+	expected := make(map[string]string, len(p.Cvars)+2)
+	for name, value := range p.Cvars {
+		expected[name] = value
+	}
+	if p.GameType != "" {
+		expected["game_type"] = p.GameType
+	}
+	if p.GameMode != "" {
+		expected["game_mode"] = p.GameMode
+	}
+	return expected
+}
+
+// PresetResult 应用预设的结果
+type PresetResult struct {
+	Preset    string      `json:"preset"`
+	Map       string      `json:"map,omitempty"` // 切换地图后确认的当前地图
+	Responses []string    `json:"responses"`
+	Checks    []CvarCheck `json:"checks"`
+	Failed    []CvarCheck `json:"failed"` // 未生效的 cvar
+}
+
+// ApplyPreset 在服务器上应用预设并回读验证
+// 先设置 game_type/game_mode 与地图组，指定地图时切换地图并等待加载完成，
+// 再设置 cvar（地图加载会执行模式配置覆盖之前的值），最后逐个回读 cvar
+func ApplyPreset(ctx context.Context, server string, preset GamePreset) (*PresetResult, error) {
+	result := &PresetResult{Preset: preset.Name}
+
+	var commands []string
+	if preset.GameType != "" {
+		commands = append(commands, "game_type "+preset.GameType)
+	}
+	if preset.GameMode != "" {
+		commands = append(commands, "game_mode "+preset.GameMode)
+	}
+	if preset.MapGroup != "" {
+		commands = append(commands, "mapgroup "+preset.MapGroup)
+	}
+	responses, err := ExecRconCommands(ctx, server, commands)
+	if err != nil {
+		return nil, err
+	}
+	result.Responses = append(result.Responses, responses...)
+
+	if preset.Map != "" {
+		response, err := ExecRconCommand(ctx, server, "map "+preset.Map)
+		if err != nil {
+			return nil, err
+		}
+		result.Responses = append(result.Responses, response)
+		if result.Map, err = WaitForMap(server, preset.Map, mapLoadTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	// 内置预设由面板定义（练习预设需要开启 sv_cheats），不受 RCON 命令策略限制；自定义预设仍按策略检查
+	cvarCtx := ctx
+	if preset.BuiltIn {
+		cvarCtx = withoutRconPolicy(ctx)
+	}
+	responses, err = ExecRconCommands(cvarCtx, server, CvarCommands(preset.Cvars))
+	if err != nil {
+		return nil, err
+	}
+	result.Responses = append(result.Responses, responses...)
+
+	result.Checks = VerifyCvars(server, preset.ExpectedCvars())
+	result.Failed = failedChecks(result.Checks)
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// 内置的练习预设需要开启 sv_cheats，在默认的全局策略下也能应用；自定义预设仍按策略检查
+func TestApplyPracticePreset(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	rcon := startFakeRcon(t)
+	testDocker.setContainers(panelContainer("p1", "practice-srv", "running"))
+	testDocker.setEnv("p1", rcon.env()...)
+
+	w := serveJSON(router, http.MethodPost, "/api/rcon/game/preset/apply", RconGamePresetApplyRequest{Name: "practice-srv", Preset: "practice"}, session)
+	if w.Code != http.StatusOK {
+		t.Fatalf("应用练习预设失败: %d %s", w.Code, w.Body.String())
+	}
+	if !slices.Contains(rcon.received(), "sv_cheats 1") {
+		t.Errorf("没有发送 sv_cheats 1，收到的命令: %q", rcon.received())
+	}
+
+	custom := GamePreset{Name: "cheats", Label: "作弊", Cvars: map[string]string{"sv_cheats": "1"}}
+	if err := presetStore.Save(custom); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = presetStore.Delete("cheats") })
+	w = serveJSON(router, http.MethodPost, "/api/rcon/game/preset/apply", RconGamePresetApplyRequest{Name: "practice-srv", Preset: "cheats"}, session)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("自定义预设开启 sv_cheats 应被策略拒绝，实际 %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// PresetListResponse 游戏预设列表的响应
+type PresetListResponse struct {
+	Presets []GamePreset `json:"presets"`
+}
+
+// rconGamePresetListHandler 处理获取游戏预设列表的请求
+func rconGamePresetListHandler(c *gin.Context) {
+	presets, err := presetStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取预设列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PresetListResponse{
+		Presets: presets,
+	})
+}
+
+// RconGamePresetApplyRequest 在服务器上应用游戏预设的请求参数
+type RconGamePresetApplyRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Preset string `json:"preset" binding:"required"`
+}
+
+// PresetApplyResponse 应用游戏预设的响应
+type PresetApplyResponse struct {
+	Message string        `json:"message"`
+	Result  *PresetResult `json:"result"`
+}
+
+// rconGamePresetApplyHandler 处理在服务器上应用游戏预设的请求，返回未生效的 cvar
+func rconGamePresetApplyHandler(c *gin.Context) {
+	var req RconGamePresetApplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	preset, err := presetStore.Get(req.Preset)
+	if err != nil {
+		handleErrorResponse(c, "获取预设失败", err)
+		return
+	}
+
+	result, err := ApplyPreset(c.Request.Context(), req.Name, preset)
+	if err != nil {
+		handleErrorResponse(c, "应用预设失败", err)
+		return
+	}
+
+	message := "应用预设成功"
+	if len(result.Failed) > 0 {
+		message = fmt.Sprintf("应用预设完成，%d 个设置未生效", len(result.Failed))
+	}
+	c.JSON(http.StatusOK, PresetApplyResponse{
+		Message: message,
+		Result:  result,
+	})
+
+	util.Info(fmt.Sprintf("应用预设 服务器: %s 预设: %s 未生效: %d", req.Name, req.Preset, len(result.Failed)))
+}
+
+// rconGamePresetSaveHandler 处理保存自定义游戏预设的请求
+func rconGamePresetSaveHandler(c *gin.Context) {
+	var req GamePreset
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := presetStore.Save(req); err != nil {
+		handleErrorResponse(c, "保存预设失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "保存预设成功",
+	})
+
+	util.Info("保存预设成功 预设: " + req.Name)
+}
+
+// RconGamePresetDeleteRequest 删除自定义游戏预设的请求参数
+type RconGamePresetDeleteRequest struct {
+	Preset string `json:"preset" binding:"required"`
+}
+
+// rconGamePresetDeleteHandler 处理删除自定义游戏预设的请求
+func rconGamePresetDeleteHandler(c *gin.Context) {
+	var req RconGamePresetDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := presetStore.Delete(req.Preset); err != nil {
+		handleErrorResponse(c, "删除预设失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "删除预设成功",
+	})
+
+	util.Info("删除预设成功 预设: " + req.Preset)
+}
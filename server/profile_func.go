@@ -0,0 +1,406 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// profileNameRegex cfg 配置名称只允许小写字母、数字与下划线，用作服务器 cfg 文件名
+var profileNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// ErrProfileNotFound cfg 配置或版本不存在
+var ErrProfileNotFound = errors.New("cfg 配置不存在")
+
+// profileDir cfg 配置在面板数据目录下的存放目录
+const profileDir = "cfg_profiles"
+
+// ProfileVersion cfg 配置的一个历史版本
+type ProfileVersion struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Comment   string    `json:"comment"`
+	Size      int       `json:"size"`
+}
+
+// CfgProfile cfg 配置的元数据
+type CfgProfile struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Latest      int              `json:"latest"`
+	Versions    []ProfileVersion `json:"versions"`
+}
+
+// DiffLine 差异中的一行，Op 为 " " 未变、"+" 新增、"-" 删除
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// ProfileStore 管理面板数据目录下的 cfg 配置
+type ProfileStore struct {
+	mu sync.Mutex
+}
+
+// 全局 cfg 配置存储
+var profileStore = &ProfileStore{}
+
+// validateProfileName 校验 cfg 配置名称
+func validateProfileName(name string) error {
+	if !profileNameRegex.MatchString(name) {
+		return invalidf("无效的配置名称 %q，只允许小写字母、数字与下划线", name)
+	}
+	return nil
+}
+
+// loadMeta 读取配置元数据，调用方需持有锁
+func (s *ProfileStore) loadMeta(name string) (*CfgProfile, error) {
+	var profile CfgProfile
+	ok, err := loadPanelJSON(&profile, profileDir, name, "meta.json")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	return &profile, nil
+}
+
+// List 列出所有 cfg 配置
+func (s *ProfileStore) List() ([]CfgProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := panelDataPath(profileDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []CfgProfile{}, nil
+		}
+		return nil, fmt.Errorf("读取目录 %s 失败：%w", dir, err)
+	}
+
+	profiles := make([]CfgProfile, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		profile, err := s.loadMeta(entry.Name())
+		if err != nil {
+			continue
+		}
+		profiles = append(profiles, *profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// Get 获取配置元数据
+func (s *ProfileStore) Get(name string) (*CfgProfile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadMeta(name)
+}
+
+// Content 读取配置指定版本的内容，version 为 0 时读取最新版本
+func (s *ProfileStore) Content(name string, version int) (string, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, err := s.loadMeta(name)
+	if err != nil {
+		return "", 0, err
+	}
+	if version == 0 {
+		version = profile.Latest
+	}
+	if version < 1 || version > profile.Latest {
+		return "", 0, fmt.Errorf("%w: %s 版本 %d", ErrProfileNotFound, name, version)
+	}
+
+	filePath, err := panelDataPath(profileDir, name, fmt.Sprintf("v%d.cfg", version))
+	if err != nil {
+		return "", 0, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("读取文件 %s 失败：%w", filePath, err)
+	}
+	return string(data), version, nil
+}
+
+// Save 保存配置内容，内容与最新版本不同时生成新版本
+func (s *ProfileStore) Save(name, description, content, comment string) (*CfgProfile, error) {
+	if err := validateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, err := s.loadMeta(name)
+	if errors.Is(err, ErrProfileNotFound) {
+		profile = &CfgProfile{Name: name, Versions: []ProfileVersion{}}
+	} else if err != nil {
+		return nil, err
+	}
+	if description != "" {
+		profile.Description = description
+	}
+
+	changed := true
+	if profile.Latest > 0 {
+		filePath, err := panelDataPath(profileDir, name, fmt.Sprintf("v%d.cfg", profile.Latest))
+		if err != nil {
+			return nil, err
+		}
+		if latest, err := os.ReadFile(filePath); err == nil && string(latest) == content {
+			changed = false
+		}
+	}
+
+	if changed {
+		version := profile.Latest + 1
+		if err := writePanelFile([]byte(content), 0644, profileDir, name, fmt.Sprintf("v%d.cfg", version)); err != nil {
+			return nil, err
+		}
+		profile.Latest = version
+		profile.Versions = append(profile.Versions, ProfileVersion{
+			Version:   version,
+			CreatedAt: time.Now(),
+			Comment:   comment,
+			Size:      len(content),
+		})
+	}
+
+	if err := savePanelJSON(profile, profileDir, name, "meta.json"); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Delete 删除配置及其全部历史版本
+func (s *ProfileStore) Delete(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := panelDataPath(profileDir, name)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("删除目录 %s 失败：%w", dir, err)
+	}
+	return nil
+}
+
+// profileCfgName 配置推送到服务器 cfg 目录后的文件名（不含扩展名）
+func profileCfgName(profile string) string {
+	return "panel_" + profile
+}
+
+// autoexecCfgName 服务器自动执行配置的文件名（不含扩展名）
+func autoexecCfgName(server string) string {
+	return "panel_autoexec_" + server
+}
+
+// PushProfile 将配置写入服务器的 cfg 目录，返回写入的版本
+func PushProfile(ctx context.Context, server, profile string, version int) (int, error) {
+	content, version, err := profileStore.Content(profile, version)
+	if err != nil {
+		return 0, err
+	}
+
+	ctr, err := ResolveContainer(ctx, server)
+	if err != nil {
+		return 0, err
+	}
+	file := path.Join(gameRootDir, "cfg", profileCfgName(profile)+".cfg")
+	if err := writeContainerFile(ctx, ctr.ID, file, []byte(content)); err != nil {
+		return 0, fmt.Errorf("写入 %s 失败: %w", relGamePath(file), err)
+	}
+	return version, nil
+}
+
+// ExecProfile 推送配置并通过 RCON 立即执行
+func ExecProfile(ctx context.Context, server, profile string, version int) (int, string, error) {
+	version, err := PushProfile(ctx, server, profile, version)
+	if err != nil {
+		return 0, "", err
+	}
+	response, err := ExecRconCommand(ctx, server, "exec "+profileCfgName(profile))
+	if err != nil {
+		return version, "", err
+	}
+	return version, response, nil
+}
+
+// autoexecFile 服务器自动执行配置的分配记录文件
+const autoexecFile = "autoexec.json"
+
+// Autoexec 获取服务器当前的自动执行配置
+func (s *ProfileStore) Autoexec(server string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignments := make(map[string]string)
+	if _, err := loadPanelJSON(&assignments, profileDir, autoexecFile); err != nil {
+		return "", err
+	}
+	return assignments[server], nil
+}
+
+// setAutoexec 保存服务器的自动执行配置，profile 为空时取消
+func (s *ProfileStore) setAutoexec(server, profile string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assignments := make(map[string]string)
+	if _, err := loadPanelJSON(&assignments, profileDir, autoexecFile); err != nil {
+		return err
+	}
+	if profile == "" {
+		delete(assignments, server)
+	} else {
+		assignments[server] = profile
+	}
+	return savePanelJSON(assignments, profileDir, autoexecFile)
+}
+
+// SetAutoexec 设置服务器每次加载地图时自动执行的配置，profile 为空时取消
+//
+// 所有服务器共用同一个游戏卷，server.cfg 无法区分服务器，因此为每个服务器生成
+// panel_autoexec_<name>.cfg（先执行 server.cfg，再执行所选配置），并通过
+// servercfgfile 让服务器在加载地图时执行该文件。运行中的服务器通过 RCON 立即生效；
+// persist 为 true 时同时把 +servercfgfile 写入容器的 CS2_ADDITIONAL_ARGS，
+// 重建容器后重启服务器也能保留。
+func SetAutoexec(ctx context.Context, server, profile string, persist bool) error {
+	ctr, err := ResolveContainer(ctx, server)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{"// 由 CS2Panel 生成，请勿手动修改", "exec server"}
+	if profile != "" {
+		if _, err := PushProfile(ctx, server, profile, 0); err != nil {
+			return err
+		}
+		lines = append(lines, "exec "+profileCfgName(profile))
+	}
+	file := path.Join(gameRootDir, "cfg", autoexecCfgName(server)+".cfg")
+	if err := writeContainerFile(ctx, ctr.ID, file, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", relGamePath(file), err)
+	}
+
+	if ctr.State == "running" {
+		if _, err := ExecRconCommand(ctx, server, "servercfgfile "+autoexecCfgName(server)+".cfg"); err != nil {
+			return err
+		}
+	}
+
+	if persist {
+		arg := "+servercfgfile " + autoexecCfgName(server) + ".cfg"
+		_, err := RecreateContainer(ctx, ctr.ID, func(cfg *container.Config, _ *container.HostConfig) error {
+			args := envMap(cfg.Env)["CS2_ADDITIONAL_ARGS"]
+			if !strings.Contains(args, arg) {
+				args = strings.TrimSpace(args + " " + arg)
+			}
+			cfg.Env = MergeEnv(cfg.Env, map[string]string{"CS2_ADDITIONAL_ARGS": args})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		rconPool.Remove(server)
+	}
+
+	return profileStore.setAutoexec(server, profile)
+}
+
+// maxDiffCells 逐行比较时最长公共子序列表的最大单元数（约 16MB），超过时不再逐行比较
+const maxDiffCells = 4 << 20
+
+// DiffLines 按行比较两段文本，基于最长公共子序列生成差异
+// 去掉公共前后缀后行数仍过多时不逐行比较，中间部分整体显示为删除与新增，第二个返回值为 false
+func DiffLines(a, b string) ([]DiffLine, bool) {
+	x := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	y := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	// 去掉公共前后缀，缩小需要计算的范围
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	mx, my := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+
+	diff := make([]DiffLine, 0, len(x)+len(y))
+	for _, line := range x[:prefix] {
+		diff = append(diff, DiffLine{Op: " ", Text: line})
+	}
+	exact := (len(mx)+1)*(len(my)+1) <= maxDiffCells
+
+	// lcs[i][j] 为 mx[i:] 与 my[j:] 的最长公共子序列长度
+	var lcs [][]int32
+	if exact {
+		lcs = make([][]int32, len(mx)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(my)+1)
+		}
+		for i := len(mx) - 1; i >= 0; i-- {
+			for j := len(my) - 1; j >= 0; j-- {
+				if mx[i] == my[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for exact && i < len(mx) && j < len(my) {
+		switch {
+		case mx[i] == my[j]:
+			diff = append(diff, DiffLine{Op: " ", Text: mx[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: "-", Text: mx[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: "+", Text: my[j]})
+			j++
+		}
+	}
+	for ; i < len(mx); i++ {
+		diff = append(diff, DiffLine{Op: "-", Text: mx[i]})
+	}
+	for ; j < len(my); j++ {
+		diff = append(diff, DiffLine{Op: "+", Text: my[j]})
+	}
+	for _, line := range x[len(x)-suffix:] {
+		diff = append(diff, DiffLine{Op: " ", Text: line})
+	}
+	return diff, exact
+}
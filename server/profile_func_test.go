@@ -0,0 +1,30 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff, exact := DiffLines("a\nb\nc\n", "a\nx\nc\nd\n")
+	want := []DiffLine{{" ", "a"}, {"-", "b"}, {"+", "x"}, {" ", "c"}, {"+", "d"}}
+	if !exact || !reflect.DeepEqual(diff, want) {
+		t.Errorf("DiffLines = %v, %v，应为 %v", diff, exact, want)
+	}
+
+	// 行数过多时不逐行比较，公共前后缀仍保留
+	var a, b strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&a, "a%d\n", i)
+		fmt.Fprintf(&b, "b%d\n", i)
+	}
+	diff, exact = DiffLines("head\n"+a.String()+"tail\n", "head\n"+b.String()+"tail\n")
+	if exact {
+		t.Fatal("行数过多时应返回简化的差异")
+	}
+	if len(diff) != 6002 || diff[0] != (DiffLine{" ", "head"}) || diff[1].Op != "-" || diff[3001].Op != "+" || diff[6001] != (DiffLine{" ", "tail"}) {
+		t.Errorf("简化的差异不正确: 共 %d 行", len(diff))
+	}
+}
@@ -0,0 +1,304 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ProfileListResponse cfg 配置列表的响应
+type ProfileListResponse struct {
+	Profiles []CfgProfile `json:"profiles"`
+}
+
+// profileListHandler 处理获取 cfg 配置列表的请求
+func profileListHandler(c *gin.Context) {
+	profiles, err := profileStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取配置列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileListResponse{
+		Profiles: profiles,
+	})
+}
+
+// ProfileGetRequest 获取 cfg 配置内容的请求参数
+type ProfileGetRequest struct {
+	Profile string `form:"profile" binding:"required"`
+	Version int    `form:"version"` // 版本号，默认最新版本
+}
+
+// ProfileGetResponse cfg 配置内容的响应
+type ProfileGetResponse struct {
+	Profile *CfgProfile `json:"profile"`
+	Version int         `json:"version"` // 返回内容的版本号
+	Content string      `json:"content"`
+}
+
+// profileGetHandler 处理获取 cfg 配置内容的请求
+func profileGetHandler(c *gin.Context) {
+	var req ProfileGetRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	profile, err := profileStore.Get(req.Profile)
+	if err != nil {
+		handleErrorResponse(c, "获取配置失败", err)
+		return
+	}
+	content, version, err := profileStore.Content(req.Profile, req.Version)
+	if err != nil {
+		handleErrorResponse(c, "获取配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileGetResponse{
+		Profile: profile,
+		Version: version,
+		Content: content,
+	})
+}
+
+// ProfileSaveRequest 保存 cfg 配置的请求参数
+type ProfileSaveRequest struct {
+	Profile     string `json:"profile" binding:"required"` // 配置名称，例如 competitive
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	Comment     string `json:"comment"` // 本次修改的说明
+}
+
+// ProfileSaveResponse 保存 cfg 配置的响应
+type ProfileSaveResponse struct {
+	Message string      `json:"message"`
+	Profile *CfgProfile `json:"profile"`
+}
+
+// profileSaveHandler 处理保存 cfg 配置的请求，内容变化时生成新版本
+func profileSaveHandler(c *gin.Context) {
+	var req ProfileSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	if len(req.Content) > maxTextFileSize {
+		handleErrorResponse(c, "无效的请求参数", invalidf("配置内容超过上限 %d 字节", maxTextFileSize))
+		return
+	}
+
+	profile, err := profileStore.Save(req.Profile, req.Description, req.Content, req.Comment)
+	if err != nil {
+		handleErrorResponse(c, "保存配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileSaveResponse{
+		Message: "配置保存成功",
+		Profile: profile,
+	})
+
+	util.Info(fmt.Sprintf("配置保存成功 配置: %s 版本: %d", profile.Name, profile.Latest))
+}
+
+// ProfileDeleteRequest 删除 cfg 配置的请求参数
+type ProfileDeleteRequest struct {
+	Profile string `json:"profile" binding:"required"`
+}
+
+// profileDeleteHandler 处理删除 cfg 配置的请求
+func profileDeleteHandler(c *gin.Context) {
+	var req ProfileDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := profileStore.Delete(req.Profile); err != nil {
+		handleErrorResponse(c, "删除配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "配置删除成功",
+	})
+
+	util.Info("配置删除成功 配置: " + req.Profile)
+}
+
+// ProfileDiffRequest 比较 cfg 配置两个版本的请求参数
+type ProfileDiffRequest struct {
+	Profile string `form:"profile" binding:"required"`
+	From    int    `form:"from" binding:"required"`
+	To      int    `form:"to"` // 默认最新版本
+}
+
+// ProfileDiffResponse 比较 cfg 配置版本的响应
+type ProfileDiffResponse struct {
+	From       int        `json:"from"`
+	To         int        `json:"to"`
+	Diff       []DiffLine `json:"diff"`
+	Simplified bool       `json:"simplified"` // 差异过大时为 true，不同的部分整体显示为删除与新增
+}
+
+// profileDiffHandler 处理比较 cfg 配置两个版本的请求
+func profileDiffHandler(c *gin.Context) {
+	var req ProfileDiffRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	from, fromVersion, err := profileStore.Content(req.Profile, req.From)
+	if err != nil {
+		handleErrorResponse(c, "获取配置失败", err)
+		return
+	}
+	to, toVersion, err := profileStore.Content(req.Profile, req.To)
+	if err != nil {
+		handleErrorResponse(c, "获取配置失败", err)
+		return
+	}
+
+	diff, exact := DiffLines(from, to)
+	c.JSON(http.StatusOK, ProfileDiffResponse{
+		From:       fromVersion,
+		To:         toVersion,
+		Diff:       diff,
+		Simplified: !exact,
+	})
+}
+
+// ProfilePushRequest 将 cfg 配置写入服务器 cfg 目录的请求参数
+type ProfilePushRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Profile string `json:"profile" binding:"required"`
+	Version int    `json:"version"`
+}
+
+// ProfilePushResponse 推送 cfg 配置的响应
+type ProfilePushResponse struct {
+	Message string `json:"message"`
+	Version int    `json:"version"`
+	File    string `json:"file"` // 写入的文件，相对于 game/csgo
+}
+
+// profilePushHandler 处理将 cfg 配置写入服务器 cfg 目录的请求
+func profilePushHandler(c *gin.Context) {
+	var req ProfilePushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	version, err := PushProfile(c.Request.Context(), req.Name, req.Profile, req.Version)
+	if err != nil {
+		handleErrorResponse(c, "推送配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfilePushResponse{
+		Message: "配置推送成功",
+		Version: version,
+		File:    "cfg/" + profileCfgName(req.Profile) + ".cfg",
+	})
+
+	util.Info(fmt.Sprintf("配置推送成功 服务器: %s 配置: %s 版本: %d", req.Name, req.Profile, version))
+}
+
+// ProfileExecRequest 推送并立即执行 cfg 配置的请求参数
+type ProfileExecRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Profile string `json:"profile" binding:"required"`
+	Version int    `json:"version"`
+}
+
+// ProfileExecResponse 执行 cfg 配置的响应
+type ProfileExecResponse struct {
+	Message  string `json:"message"`
+	Version  int    `json:"version"`
+	Response string `json:"response"` // exec 命令的原始输出
+}
+
+// profileExecHandler 处理推送并立即执行 cfg 配置的请求
+func profileExecHandler(c *gin.Context) {
+	var req ProfileExecRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	version, response, err := ExecProfile(c.Request.Context(), req.Name, req.Profile, req.Version)
+	if err != nil {
+		handleErrorResponse(c, "执行配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileExecResponse{
+		Message:  "执行配置成功",
+		Version:  version,
+		Response: response,
+	})
+
+	util.Info(fmt.Sprintf("执行配置成功 服务器: %s 配置: %s 版本: %d", req.Name, req.Profile, version))
+}
+
+// ProfileAutoexecGetRequest 获取服务器自动执行配置的请求参数
+type ProfileAutoexecGetRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// ProfileAutoexecResponse 自动执行配置的响应
+type ProfileAutoexecResponse struct {
+	Profile string `json:"profile"` // 为空表示未设置
+}
+
+// profileAutoexecGetHandler 处理获取服务器自动执行配置的请求
+func profileAutoexecGetHandler(c *gin.Context) {
+	var req ProfileAutoexecGetRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	profile, err := profileStore.Autoexec(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取自动执行配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ProfileAutoexecResponse{
+		Profile: profile,
+	})
+}
+
+// ProfileAutoexecSetRequest 设置服务器自动执行配置的请求参数
+type ProfileAutoexecSetRequest struct {
+	Name    string `json:"name" binding:"required"`
+	Profile string `json:"profile"`
+	Persist bool   `json:"persist"` // 是否写入容器启动参数（会重建容器）
+}
+
+// profileAutoexecSetHandler 处理设置服务器自动执行配置的请求，profile 为空时取消
+func profileAutoexecSetHandler(c *gin.Context) {
+	var req ProfileAutoexecSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := SetAutoexec(c.Request.Context(), req.Name, req.Profile, req.Persist); err != nil {
+		handleErrorResponse(c, "设置自动执行配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "设置自动执行配置成功",
+	})
+
+	util.Info(fmt.Sprintf("设置自动执行配置成功 服务器: %s 配置: %s", req.Name, req.Profile))
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitSweepInterval 清理空闲令牌桶的间隔
+const rateLimitSweepInterval = 5 * time.Minute
+
+// tokenBucket 一个令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter 按键（用户、服务器）划分的令牌桶限流器
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // 每秒补充的令牌数，0 为不限制
+	burst     float64 // 桶容量
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter 创建限流器，burst 小于 1 时按 1 处理
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:      rate,
+		burst:     math.Max(float64(burst), 1),
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow 取出一个令牌；令牌不足时返回 false 与需要等待的时间
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweepLocked(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// sweepLocked 定期删除已经补满的令牌桶，调用方需持有锁
+func (l *RateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+var (
+	rateLimitOnce sync.Once
+	// clientLimiter 每个用户或 API 令牌的限流器
+	clientLimiter *RateLimiter
+	// serverLimiter 每个服务器的限流器
+	serverLimiter *RateLimiter
+	// loginLimiter 每个用户名与每个客户端 IP 的登录限流器
+	loginLimiter *RateLimiter
+)
+
+// rateLimiters 按配置创建全局限流器
+func rateLimiters() (*RateLimiter, *RateLimiter) {
+	rateLimitOnce.Do(func() {
+		cfg := config.GlobalConfig.RateLimit
+		clientLimiter = NewRateLimiter(cfg.ClientRate, cfg.ClientBurst)
+		serverLimiter = NewRateLimiter(cfg.ServerRate, cfg.ServerBurst)
+		loginLimiter = NewRateLimiter(cfg.LoginRate, cfg.LoginBurst)
+	})
+	return clientLimiter, serverLimiter
+}
+
+// allowLogin 按用户名与客户端 IP 限制登录尝试，成功与失败的尝试都计数；超出限制时返回 429 并返回 false
+func allowLogin(c *gin.Context, username string) bool {
+	rateLimiters()
+	for _, key := range []string{"user:" + username, "ip:" + c.ClientIP()} {
+		if ok, wait := loginLimiter.Allow(key); !ok {
+			abortRateLimited(c, "登录 "+key, wait)
+			return false
+		}
+	}
+	return true
+}
+
+// rateLimitClient 限流使用的客户端标识：API 令牌、登录用户或客户端 IP
+func rateLimitClient(c *gin.Context) string {
+	if t, ok := currentToken(c); ok {
+		return "token:" + t.ID
+	}
+	if u, ok := currentUser(c); ok {
+		return "user:" + u.Username
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// abortRateLimited 返回 429 与 Retry-After
+func abortRateLimited(c *gin.Context, scope string, wait time.Duration) {
+	retryAfter := max(int(math.Ceil(wait.Seconds())), 1)
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	err := &APIError{Code: CodeRateLimited, Err: fmt.Errorf("%s 超出限制", scope)}
+	abortWithError(c, "请求过于频繁，请稍后重试", err, func(resp *ErrorResponse) {
+		resp.RetryAfter = retryAfter
+	})
+}
+
+// contextServerRateLimitKey 标记请求需要按目标服务器限流
+const contextServerRateLimitKey = "server_rate_limit"
+
+// RconRateLimit 对 RCON 接口按用户与目标服务器限流的中间件，需在 AuthRequired 之后使用
+// 用户的令牌桶在此计数；服务器的令牌桶由 RequireServer 在权限检查通过后计数，
+// 避免没有权限的请求耗尽服务器的配额
+func RconRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clients, _ := rateLimiters()
+
+		client := rateLimitClient(c)
+		if ok, wait := clients.Allow(client); !ok {
+			abortRateLimited(c, "客户端: "+client, wait)
+			return
+		}
+		c.Set(contextServerRateLimitKey, true)
+		c.Next()
+	}
+}
+
+// chargeServerRate 按目标服务器计数，超出限制时返回 429 并返回 false；请求不在 RconRateLimit 之下时不计数
+func chargeServerRate(c *gin.Context, targets []string) bool {
+	if !c.GetBool(contextServerRateLimitKey) {
+		return true
+	}
+	_, servers := rateLimiters()
+	for _, server := range targets {
+		if ok, wait := servers.Allow(server); !ok {
+			abortRateLimited(c, "服务器: "+server, wait)
+			return false
+		}
+	}
+	return true
+}
+
+// rconReadCall 一次只读命令的调用，完成前其他相同的调用等待它的结果
+type rconReadCall struct {
+	done     chan struct{}
+	response string
+	err      error
+	at       time.Time // 完成时间
+}
+
+// RconReadCache 合并同一服务器上并发的相同只读命令（status、status_json），并短时间缓存结果
+type RconReadCache struct {
+	mu    sync.Mutex
+	calls map[string]map[string]*rconReadCall // 服务器 -> 命令 -> 调用
+}
+
+// 全局只读命令缓存
+var rconReadCache = &RconReadCache{calls: make(map[string]map[string]*rconReadCall)}
+
+// Exec 执行只读命令：有相同命令正在执行时等待其结果，上次成功的结果未过期时直接返回
+// 失败的结果不缓存
+func (rc *RconReadCache) Exec(name, command string) (string, error) {
+	ttl := time.Duration(config.GlobalConfig.RateLimit.StatusCacheTTL) * time.Millisecond
+
+	rc.mu.Lock()
+	calls := rc.calls[name]
+	if calls == nil {
+		calls = make(map[string]*rconReadCall)
+		rc.calls[name] = calls
+	}
+	if call, ok := calls[command]; ok {
+		select {
+		case <-call.done:
+			if call.err == nil && time.Since(call.at) < ttl {
+				rc.mu.Unlock()
+				return call.response, nil
+			}
+		default:
+			rc.mu.Unlock()
+			<-call.done
+			return call.response, call.err
+		}
+	}
+	call := &rconReadCall{done: make(chan struct{})}
+	calls[command] = call
+	rc.mu.Unlock()
+
+	call.response, call.err = ExecRconCommand(context.Background(), name, command)
+	call.at = time.Now()
+	close(call.done)
+
+	if call.err != nil {
+		rc.mu.Lock()
+		if rc.calls[name][command] == call {
+			delete(rc.calls[name], command)
+		}
+		rc.mu.Unlock()
+	}
+	return call.response, call.err
+}
+
+// Forget 清除服务器的缓存结果，发送修改类命令或连接断开后调用
+// 正在执行的调用仍会把结果交给已在等待的调用方，之后的调用重新执行
+func (rc *RconReadCache) Forget(name string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	delete(rc.calls, name)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 没有权限的请求不消耗服务器的令牌桶
+func TestServerRateLimitAfterAuthorization(t *testing.T) {
+	router := testRouter()
+	testDocker.setContainers()
+
+	_, _ = rateLimiters()
+	saved := serverLimiter
+	serverLimiter = NewRateLimiter(0.001, 1)
+	defer func() { serverLimiter = saved }()
+
+	outsider := userSession(t, router, "rate-outsider", RoleBinding{Role: "viewer", Servers: []string{"other"}})
+	for i := 0; i < 3; i++ {
+		if w := serveJSON(router, http.MethodGet, "/api/rcon/game/status?name=limited", nil, outsider); w.Code != http.StatusForbidden {
+			t.Fatalf("没有权限的请求应返回 403，实际 %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	admin := adminSession(t, router)
+	if w := serveJSON(router, http.MethodGet, "/api/rcon/game/status?name=limited", nil, admin); w.Code == http.StatusTooManyRequests {
+		t.Fatal("没有权限的请求不应消耗服务器的令牌桶")
+	}
+	w := serveJSON(router, http.MethodGet, "/api/v2/servers/limited/players", nil, admin)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("超出服务器限制时应返回 429，实际 %d: %s", w.Code, w.Body.String())
+	}
+	checkResponse(t, http.MethodGet, "/v2/servers/{name}/players", w)
+}
+
+// 登录按用户名与客户端 IP 限流，成功与失败的尝试都计数
+func TestLoginRateLimit(t *testing.T) {
+	router := testRouter()
+	_, _ = rateLimiters()
+	saved := loginLimiter
+	defer func() { loginLimiter = saved }()
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		return serveJSON(router, http.MethodPost, "/api/auth/login", AuthLoginRequest{Username: username, Password: password})
+	}
+
+	// 同一用户名：用尽后正确的密码也被拒绝
+	loginLimiter = NewRateLimiter(0.001, 2)
+	for i := 0; i < 2; i++ {
+		if w := login(bootstrapAdminName, "wrong-password"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("第 %d 次尝试应返回 401，实际 %d", i+1, w.Code)
+		}
+	}
+	w := login(bootstrapAdminName, testAdminPassword)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("超出用户名的限制时应返回 429，实际 %d: %s", w.Code, w.Body.String())
+	}
+	checkResponse(t, http.MethodPost, "/auth/login", w)
+
+	// 同一客户端 IP：换用户名也被拒绝
+	loginLimiter = NewRateLimiter(0.001, 2)
+	for _, username := range []string{"guess-1", "guess-2"} {
+		if w := login(username, "x"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("%s 应返回 401，实际 %d", username, w.Code)
+		}
+	}
+	if w := login("guess-3", "x"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("超出客户端 IP 的限制时应返回 429，实际 %d", w.Code)
+	}
+}
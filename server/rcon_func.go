@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -63,7 +64,7 @@ func (rp *RconPool) GetConnection(name, port, passwd string) (*RconConnection, e
 
 	// 检查连接池大小
 	if len(rp.connections) >= rp.maxSize {
-		return nil, fmt.Errorf("连接池已满")
+		return nil, upstreamf("连接池已满")
 	}
 
 	// 创建新的RCON客户端，减少超时时间
@@ -107,26 +108,50 @@ func (rp *RconPool) Close() {
 	rp.connections = make(map[string]*RconConnection)
 }
 
+// 移除指定服务器的连接，下次执行命令时重新建立
+func (rp *RconPool) Remove(name string) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	delete(rp.connections, name)
+	rconReadCache.Forget(name)
+}
+
 // 全局连接池
 var rconPool = NewRconPool(20)
 
+// 执行单个RCON命令并记录审计日志，ctx 携带请求的审计记录时命令归入该记录
+// 请求中发送的命令先按操作者的 RCON 策略检查，被拒绝时不发送
+func ExecRconCommand(ctx context.Context, name string, command string) (string, error) {
+	if err := CheckRconCommands(ctx, name, command); err != nil {
+		return "", err
+	}
+	start := time.Now()
+	response, err := execRconCommand(name, command)
+	auditRconCommand(ctx, name, command, err, time.Since(start))
+	if command != "status" && command != "status_json" {
+		rconReadCache.Forget(name)
+	}
+	return response, err
+}
+
 // 执行单个RCON命令 - 优化版本
-func ExecRconCommand(name string, command string) (string, error) {
+func execRconCommand(name string, command string) (string, error) {
 	// 获取环境变量
-	port, err := GetEnvValue(name, "CS2_RCON_PORT")
+	port, err := ServerPort(name, "CS2_RCON_PORT", "tcp")
 	if err != nil {
-		return "", fmt.Errorf("获取Rcon端口失败: %v", err)
+		return "", fmt.Errorf("获取Rcon端口失败: %w", err)
 	}
 
-	passwd, err := GetEnvValue(name, "CS2_RCONPW")
+	passwd, err := rconPassword(name)
 	if err != nil {
-		return "", fmt.Errorf("获取Rcon密码失败: %v", err)
+		return "", fmt.Errorf("获取Rcon密码失败: %w", err)
 	}
 
 	// 获取连接
 	conn, err := rconPool.GetConnection(name, port, passwd)
 	if err != nil {
-		return "", fmt.Errorf("获取连接失败: %v", err)
+		return "", upstreamf("获取连接失败: %w", err)
 	}
 
 	// 对单个连接加锁，而不是全局锁
@@ -138,26 +163,31 @@ func ExecRconCommand(name string, command string) (string, error) {
 	if err != nil {
 		// 如果执行失败，标记连接为不可用
 		conn.connected = false
-		return "", fmt.Errorf("执行Rcon命令失败: %v", err)
+		return "", upstreamf("执行Rcon命令失败: %w", err)
 	}
 
 	return response, nil
 }
 
 // 批量执行RCON命令 - 优化版本
-func ExecRconCommands(name string, commands []string) ([]string, error) {
+func ExecRconCommands(ctx context.Context, name string, commands []string) ([]string, error) {
 	if len(commands) == 0 {
 		return []string{}, nil
 	}
 
+	// 先检查所有命令，有命令被拒绝时一条都不发送
+	if err := CheckRconCommands(ctx, name, commands...); err != nil {
+		return nil, err
+	}
+
 	// 对于同一个服务器的多个命令，使用单个连接顺序执行
 	// 这样可以避免连接竞争，提高效率
 	responses := make([]string, len(commands))
 
 	for i, cmd := range commands {
-		response, err := ExecRconCommand(name, cmd)
+		response, err := ExecRconCommand(ctx, name, cmd)
 		if err != nil {
-			return responses, fmt.Errorf("执行第%d个命令失败: %v", i+1, err)
+			return responses, fmt.Errorf("执行第%d个命令失败: %w", i+1, err)
 		}
 		responses[i] = response
 	}
@@ -166,7 +196,7 @@ func ExecRconCommands(name string, commands []string) ([]string, error) {
 }
 
 // 并发执行多个服务器的命令
-func ExecRconCommandsConcurrent(serverCommands map[string][]string) (map[string][]string, error) {
+func ExecRconCommandsConcurrent(ctx context.Context, serverCommands map[string][]string) (map[string][]string, error) {
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	results := make(map[string][]string)
@@ -177,7 +207,7 @@ func ExecRconCommandsConcurrent(serverCommands map[string][]string) (map[string]
 		go func(name string, cmds []string) {
 			defer wg.Done()
 
-			responses, err := ExecRconCommands(name, cmds)
+			responses, err := ExecRconCommands(ctx, name, cmds)
 
 			mu.Lock()
 			if err != nil {
@@ -266,9 +296,9 @@ type PlayerInfo struct {
 
 // 获取服务器状态（主函数调用）
 func GetServerStatus(name string) (ServerStatus, error) {
-	statusOutput, err := ExecRconCommand(name, "status")
+	statusOutput, err := rconReadCache.Exec(name, "status")
 	if err != nil {
-		return ServerStatus{}, fmt.Errorf("获取服务器状态失败: %v", err)
+		return ServerStatus{}, fmt.Errorf("获取服务器状态失败: %w", err)
 	}
 	return ParseCS2Status(statusOutput)
 }
@@ -320,9 +350,9 @@ type ClientInfo struct {
 
 // 修改 GetServerStatusJSON 函数
 func GetServerStatusJSON(name string) (*ServerStatusJSON, error) {
-	statusOutput, err := ExecRconCommand(name, "status_json")
+	statusOutput, err := rconReadCache.Exec(name, "status_json")
 	if err != nil {
-		return nil, fmt.Errorf("获取服务器状态JSON失败: %v", err)
+		return nil, fmt.Errorf("获取服务器状态JSON失败: %w", err)
 	}
 
 	// 解析JSON字符串为结构体
@@ -335,6 +365,43 @@ func GetServerStatusJSON(name string) (*ServerStatusJSON, error) {
 	return &status, nil
 }
 
+// WaitForMap 在地图切换后轮询 status_json，直到服务器响应且当前地图为 mapName
+func WaitForMap(name, mapName string, timeout time.Duration) (string, error) {
+	return waitForMap(name, timeout, "地图 "+mapName, func(current string) bool {
+		return strings.EqualFold(current, mapName)
+	})
+}
+
+// WaitForMapChange 在地图切换后轮询 status_json，直到服务器响应且当前地图不再是 previous，返回新的地图
+// 用于事先不知道地图名称的情况，如首次下载的创意工坊地图与合集
+func WaitForMapChange(name, previous string, timeout time.Duration) (string, error) {
+	return waitForMap(name, timeout, "地图从 "+previous+" 切换", func(current string) bool {
+		return current != "" && !strings.EqualFold(current, previous)
+	})
+}
+
+// waitForMap 轮询 status_json 直到 done 对当前地图返回 true
+func waitForMap(name string, timeout time.Duration, target string, done func(current string) bool) (string, error) {
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		status, err := GetServerStatusJSON(name)
+		if err == nil {
+			if done(status.Server.Map) {
+				return status.Server.Map, nil
+			}
+			lastErr = fmt.Errorf("当前地图为 %s", status.Server.Map)
+		} else {
+			lastErr = err
+		}
+
+		if time.Now().After(deadline) {
+			return "", timeoutf("等待%s超时: %v", target, lastErr)
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
 // ====================== 工具函数 ======================
 
 // 解析 status 输出文本为结构体
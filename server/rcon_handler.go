@@ -2,59 +2,72 @@ package server
 
 import (
 	"encoding/json"
+	"net/http"
 
 	"github.com/VanVodkaer/CS2Panel/config"
 	"github.com/VanVodkaer/CS2Panel/util"
 	"github.com/gin-gonic/gin"
 )
 
-// containerExecHandler 执行命令
-func rconExecHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type ContainerExecRequest struct {
-		Name string   `json:"name" binding:"required"`
-		Cmds []string `json:"cmds" binding:"required"`
-	}
+// RconExecRequest 执行命令的请求参数
+type RconExecRequest struct {
+	Name string   `json:"name" binding:"required"`
+	Cmds []string `json:"cmds" binding:"required"`
+}
 
+// rconExecHandler 执行命令
+func rconExecHandler(c *gin.Context) {
 	// 从请求中解析参数
-	var req ContainerExecRequest
+	var req RconExecRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	// 先检查所有命令，有命令被拒绝时一条都不发送
+	if err := CheckRconCommands(c.Request.Context(), req.Name, req.Cmds...); err != nil {
+		handleErrorResponse(c, "执行命令失败", err)
 		return
 	}
 
-	var responses []string
+	responses := make([]string, 0, len(req.Cmds))
 
 	for _, cmd := range req.Cmds {
-		response, err := ExecRconCommand(FullName(req.Name), cmd)
+		response, err := ExecRconCommand(c.Request.Context(), req.Name, cmd)
 		if err != nil {
 			handleErrorResponse(c, "执行命令失败", err)
 			return
 		} else {
 			responses = append(responses, response)
-			util.Info("执行命令成功 命令: " + cmd + " 响应: " + response)
+			util.Info("执行命令成功 命令: " + redactCommand(cmd) + " 响应: " + response)
 		}
 	}
 	// 返回执行命令的响应
-	c.JSON(200, gin.H{
-		"message":   "执行命令成功",
-		"responses": responses,
+	c.JSON(http.StatusOK, RconResponses{
+		Message:   "执行命令成功",
+		Responses: responses,
 	})
 }
 
+// RconGameStatusRequest 获取游戏状态的请求参数
+type RconGameStatusRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// RconGameStatusResponse 游戏状态（status）的响应
+type RconGameStatusResponse struct {
+	Message string       `json:"message"`
+	Status  ServerStatus `json:"status"`
+}
+
 // rconGameStatusHandler 获取游戏状态
 func rconGameStatusHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameStatusRequest struct {
-		Name string `form:"name" binding:"required"`
-	}
-
 	var req RconGameStatusRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := GetServerStatus(FullName(req.Name))
+	response, err := GetServerStatus(req.Name)
 	if err != nil {
 		handleErrorResponse(c, "获取服务器状态失败", err)
 		return
@@ -70,24 +83,32 @@ func rconGameStatusHandler(c *gin.Context) {
 			}
 		}
 	}
-	c.JSON(200, gin.H{
-		"message": "获取游戏状态成功",
-		"status":  response,
+	c.JSON(http.StatusOK, RconGameStatusResponse{
+		Message: "获取游戏状态成功",
+		Status:  response,
 	})
 }
 
+// RconGameStatusJSONRequest 获取游戏状态 status_json 的请求参数
+type RconGameStatusJSONRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// RconGameStatusJSONResponse 游戏状态（status_json）的响应
+type RconGameStatusJSONResponse struct {
+	Message string            `json:"message"`
+	Status  *ServerStatusJSON `json:"status"` // 解析后的 status_json
+}
+
 // rconGameStatusJSONHandler 获取游戏状态 status_json
 func rconGameStatusJSONHandler(c *gin.Context) {
-	type RconGameStatusJSONRequest struct {
-		Name string `form:"name" binding:"required"`
-	}
 	var req RconGameStatusJSONRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
-	status, err := GetServerStatusJSON(FullName(req.Name))
+	status, err := GetServerStatusJSON(req.Name)
 	if err != nil {
 		handleErrorResponse(c, "获取服务器状态失败", err)
 		return
@@ -102,55 +123,55 @@ func rconGameStatusJSONHandler(c *gin.Context) {
 		}
 	}
 
-	c.JSON(200, gin.H{
-		"message": "获取游戏状态成功",
-		"status":  status, // 返回解析后的结构体对象
+	c.JSON(http.StatusOK, RconGameStatusJSONResponse{
+		Message: "获取游戏状态成功",
+		Status:  status,
 	})
 }
 
+// RconGameRestartRequest 重启游戏的请求参数
+type RconGameRestartRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"`
+}
+
 // rconGameRestartHandler 重启游戏
 func rconGameRestartHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameRestartRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"`
-	}
-
 	var req RconGameRestartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_restartgame "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_restartgame "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_restartgame " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
-// rconGameConfigModeHandler
-func rconGameConfigModeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigGameModeRequest struct {
-		Name     string `json:"name" binding:"required"`
-		GameMode string `json:"gamemode"`
-		GameType string `json:"gametype"`
-	}
+// RconGameConfigModeRequest 同时设置游戏模式与游戏类型的请求参数
+type RconGameConfigModeRequest struct {
+	Name     string `json:"name" binding:"required"`
+	GameMode string `json:"gamemode" binding:"omitempty,numeric"`
+	GameType string `json:"gametype" binding:"omitempty,numeric"`
+}
 
-	var req RconGameConfigGameModeRequest
+// rconGameConfigModeHandler 同时设置游戏模式与游戏类型
+func rconGameConfigModeHandler(c *gin.Context) {
+	var req RconGameConfigModeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	var responses []string
+	responses := []string{}
 	if req.GameMode != "" {
-		response, err := ExecRconCommand(FullName(req.Name), "game_mode "+req.GameMode)
+		response, err := ExecRconCommand(c.Request.Context(), req.Name, "game_mode "+req.GameMode)
 		if err != nil {
 			handleErrorResponse(c, "执行命令失败", err)
 			return
@@ -160,7 +181,7 @@ func rconGameConfigModeHandler(c *gin.Context) {
 		}
 	}
 	if req.GameType != "" {
-		response, err := ExecRconCommand(FullName(req.Name), "game_type "+req.GameType)
+		response, err := ExecRconCommand(c.Request.Context(), req.Name, "game_type "+req.GameType)
 		if err != nil {
 			handleErrorResponse(c, "执行命令失败", err)
 			return
@@ -169,230 +190,231 @@ func rconGameConfigModeHandler(c *gin.Context) {
 			responses = append(responses, response)
 		}
 	}
-	c.JSON(200, gin.H{
-		"message":   "执行命令成功",
-		"responses": responses,
+	c.JSON(http.StatusOK, RconResponses{
+		Message:   "执行命令成功",
+		Responses: responses,
 	})
 }
 
+// RconGameWarmStartRequest 立刻切换到热身模式的请求参数
+type RconGameWarmStartRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
 // rconGameWarmStartHandler 立刻切换到热身模式
 func rconGameWarmStartHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameWarmStartRequest struct {
-		Name string `json:"name" binding:"required"`
-	}
-
 	var req RconGameWarmStartRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_warmup_start")
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_warmup_start")
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_warmup_start 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
-// rconGameWarmEndHandler  立刻结束热身模式
-func rconGameWarmEndHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameWarmEndRequest struct {
-		Name string `json:"name" binding:"required"`
-	}
+// RconGameWarmEndRequest 立刻结束热身模式的请求参数
+type RconGameWarmEndRequest struct {
+	Name string `json:"name" binding:"required"`
+}
 
+// rconGameWarmEndHandler 立刻结束热身模式
+func rconGameWarmEndHandler(c *gin.Context) {
 	var req RconGameWarmEndRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_warmup_end")
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_warmup_end")
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
+		return
 	} else {
 		util.Info("执行命令成功 命令: warmup_end 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameWarmTimeRequest 设置热身时间的请求参数
+type RconGameWarmTimeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 热身时长 无参数返回当前时长
+}
+
 // rconGameWarmTimeHandler 设置热身时间
 func rconGameWarmTimeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameWarmTimeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 热身时长 无参数返回当前时长
-	}
-
 	var req RconGameWarmTimeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_warmuptime "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_warmuptime "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_warmuptime " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameWarmPauseRequest 控制热身时间暂停的请求参数
+type RconGameWarmPauseRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,oneof=0 1 true false"` // 0/false 关闭; 1/true 开启 无参数返回当前状态
+}
+
 // rconGameWarmPauseHandler 控制热身时间暂停
 func rconGameWarmPauseHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameWarmPauseRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 0/false 关闭; 1/true 开启 无参数返回当前状态
-	}
-
 	var req RconGameWarmPauseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_warmup_pausetimer "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_warmup_pausetimer "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_warmup_pausetimer " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigGameModeRequest 设置游戏模式的请求参数
+type RconGameConfigGameModeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 游戏模式 无参数返回当前游戏模式
+}
+
 // rconGameConfigGameModeHandler 设置游戏模式
 func rconGameConfigGameModeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigGameModeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 游戏模式 无参数返回当前游戏模式
-	}
-
 	var req RconGameConfigGameModeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "game_mode "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "game_mode "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: game_mode " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigGameTypeRequest 设置游戏类型的请求参数
+type RconGameConfigGameTypeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 游戏类型 无参数返回当前游戏类型
+}
+
 // rconGameConfigGameTypeHandler 设置游戏类型
 func rconGameConfigGameTypeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigGameTypeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 游戏类型 无参数返回当前游戏类型
-	}
-
 	var req RconGameConfigGameTypeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "game_type "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "game_type "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行RCON命令失败", err)
 		return
 	}
 	util.Info("执行命令成功 命令: game_type " + req.Value + " 响应: " + response)
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigMaxRoundsRequest 设置最大回合数的请求参数
+type RconGameConfigMaxRoundsRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 最大回合数 无参数返回当前最大回合数
+}
+
 // rconGameConfigMaxRoundsHandler 设置最大回合数
 func rconGameConfigMaxRoundsHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigMaxRoundsRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 最大回合数 无参数返回当前最大回合数
-	}
-
 	var req RconGameConfigMaxRoundsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_maxrounds "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_maxrounds "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_maxrounds " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigTimeLimitRequest 设置比赛时间限制的请求参数
+type RconGameConfigTimeLimitRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 比赛时间限制 无参数返回当前时间限制
+}
+
 // rconGameConfigTimeLimitHandler 设置比赛时间限制
 // 每个游戏的最大持续时间，以分钟为单位。默认情况下，此设置处于禁用状态 (设置为 0)。如果当前地图的总持续时间超过此值，当前地图将结束，下一个地图将开始游戏。
 func rconGameConfigTimeLimitHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigTimeLimitRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 比赛时间限制 无参数返回当前时间限制
-	}
-
 	var req RconGameConfigTimeLimitRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_timelimit "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_timelimit "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_timelimit " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigRoundTimeRequest 设置每回合时间的请求参数
+type RconGameConfigRoundTimeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 回合时间 无参数返回当前回合时间
+	Mode  string `json:"mode"`                              // 模式 可选参数 defuse 拆弹模式, hostage 人质解救
+}
+
 // rconGameConfigRoundTimeHandler 设置每回合时间
 func rconGameConfigRoundTimeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigRoundTimeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 回合时间 无参数返回当前回合时间
-		Mode  string `json:"mode"`  // 模式 可选参数 defuse 拆弹模式, hostage 人质解救
-	}
-
 	var req RconGameConfigRoundTimeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 	var command string
@@ -404,326 +426,365 @@ func rconGameConfigRoundTimeHandler(c *gin.Context) {
 		command = "mp_roundtime " + req.Value
 	}
 
-	response, err := ExecRconCommand(FullName(req.Name), command)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, command)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: " + command + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigFreezetimeRequest 设置冻结时间的请求参数
+type RconGameConfigFreezetimeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 冻结时间 无参数返回当前冻结时间
+}
+
 // rconGameConfigFreezetimeHandler 设置冻结时间
 func rconGameConfigFreezetimeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigFreezetimeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 冻结时间 无参数返回当前冻结时间
-	}
-
 	var req RconGameConfigFreezetimeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_freezetime "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_freezetime "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_freezetime " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigBuytimeRequest 设置购买时间的请求参数
+type RconGameConfigBuytimeRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"`
+}
+
 // rconGameConfigBuytimeHandler 设置购买时间
 func rconGameConfigBuytimeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigBuytimeRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"`
-	}
-
 	var req RconGameConfigBuytimeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_buytime "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_buytime "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_buytime " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigBuyAnywhereRequest 设置是否允许在地图任意位置购买装备的请求参数
+type RconGameConfigBuyAnywhereRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"`
+}
+
 // rconGameConfigBuyAnywhereHandler 设置是否允许在地图任意位置购买装备
 func rconGameConfigBuyAnywhereHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigBuyAnywhereRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"`
-	}
-
 	var req RconGameConfigBuyAnywhereRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_buy_anywhere "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_buy_anywhere "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_buy_anywhere " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigStartMoneyRequest 设置初始金钱的请求参数
+type RconGameConfigStartMoneyRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 初始金钱 无参数返回当前初始金钱
+}
+
 // rconGameConfigStartMoneyHandler 设置初始金钱
 func rconGameConfigStartMoneyHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigStartMoneyRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 初始金钱 无参数返回当前初始金钱
-	}
-
 	var req RconGameConfigStartMoneyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_startmoney "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_startmoney "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_startmoney " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigMaxMoneyRequest 设置最大金钱的请求参数
+type RconGameConfigMaxMoneyRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 最大金钱 无参数返回当前最大金钱
+}
+
 // rconGameConfigMaxMoneyHandler 设置最大金钱
 func rconGameConfigMaxMoneyHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigMaxMoneyRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 最大金钱 无参数返回当前最大金钱
-	}
-
 	var req RconGameConfigMaxMoneyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_maxmoney "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_maxmoney "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_maxmoney " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigAutoTeamBalanceRequest 设置自动队伍平衡的请求参数
+type RconGameConfigAutoTeamBalanceRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,oneof=0 1 true false"`
+}
+
 // rconGameConfigAutoTeamBalanceHandler 设置自动队伍平衡
 func rconGameConfigAutoTeamBalanceHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigAutoTeamBalanceRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"`
-	}
-
 	var req RconGameConfigAutoTeamBalanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_autoteambalance "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_autoteambalance "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_autoteambalance " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigAutoKickRequest 设置自动踢出空闲玩家的请求参数
+type RconGameConfigAutoKickRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,oneof=0 1 true false"`
+}
+
 // rconGameConfigAutoKickHandler 设置自动踢出空闲玩家
 func rconGameConfigAutoKickHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigAutoKickRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"`
-	}
-
 	var req RconGameConfigAutoKickRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_autokick "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_autokick "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_autokick " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigLimitTeamsRequest 设置队伍人数差异上限的请求参数
+type RconGameConfigLimitTeamsRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // 允许存在的玩家差异数量的最大值 无参数返回当前最大值
+}
+
 // rconGameConfigLimitTeamsHandler 设置两个队伍之间允许存在的玩家差异数量的最大值，0为无限制
 func rconGameConfigLimitTeamsHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigLimitTeamsRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // 允许存在的玩家差异数量的最大值 无参数返回当前最大值
-	}
-
 	var req RconGameConfigLimitTeamsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_limitteams "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_limitteams "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_limitteams " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconGameConfigC4TimerRequest 设置 C4 爆炸倒计时的请求参数
+type RconGameConfigC4TimerRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"omitempty,numeric"` // C4 爆炸倒计时 无参数返回当前倒计时
+}
+
 // rconGameConfigC4TimerHandler 设置 C4 爆炸倒计时
 func rconGameConfigC4TimerHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconGameConfigC4TimerRequest struct {
-		Name  string `json:"name" binding:"required"`
-		Value string `json:"value"` // C4 爆炸倒计时 无参数返回当前倒计时
-	}
-
 	var req RconGameConfigC4TimerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "mp_c4timer "+req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "mp_c4timer "+req.Value)
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	} else {
 		util.Info("执行命令成功 命令: mp_c4timer " + req.Value + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
 
+// RconMapNowRequest 获取当前地图的请求参数
+type RconMapNowRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// RconMapNowResponse 当前地图的响应
+type RconMapNowResponse struct {
+	Message  string         `json:"message"`
+	Map      string         `json:"map"`
+	Rotation *RotationState `json:"rotation,omitempty"` // 设置了地图轮换时返回轮换进度
+}
+
 // rconMapNowHandler 获取当前地图
 func rconMapNowHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconMapNowRequest struct {
-		Name string `form:"name" binding:"required"`
-	}
-
 	var req RconMapNowRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := GetServerStatus(FullName(req.Name))
+	response, err := GetServerStatus(req.Name)
 	if err != nil {
 		handleErrorResponse(c, "获取服务器状态失败", err)
 		return
+	} else if len(response.Spawngroups) == 0 {
+		handleErrorResponse(c, "获取当前地图失败", upstreamf("服务器未返回已加载的地图"))
+		return
 	} else {
 		util.Info("获取当前地图成功 响应: " + response.Spawngroups[0].Path)
 	}
-	c.JSON(200, gin.H{
-		"message": "获取当前地图成功",
-		"map":     response.Spawngroups[0].Path,
-	})
+	resp := RconMapNowResponse{
+		Message: "获取当前地图成功",
+		Map:     response.Spawngroups[0].Path,
+	}
+	// 设置了地图轮换时一并返回轮换进度
+	if _, ok, err := rotationStore.Config(req.Name); err == nil && ok {
+		if state, err := rotationStore.State(req.Name); err == nil {
+			resp.Rotation = &state
+		}
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// rconMapChangeHandler
-func rconMapChangeHandler(c *gin.Context) {
-	// 定义请求参数结构体
-	type RconMapChangeRequest struct {
-		Name string `json:"name" binding:"required"`
-		Map  string `json:"map" binding:"required"`
-	}
+// RconMapChangeRequest 切换地图的请求参数
+// map 可以是地图名，也可以是登记的创意工坊名称或 ID；workshop_id 直接指定创意工坊物品
+// 地图不支持当前模式时默认拒绝，preset 指定切换前应用的预设，switch_preset 自动选择匹配的内置预设
+type RconMapChangeRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Map          string `json:"map" binding:"required_without=WorkshopID"`
+	WorkshopID   string `json:"workshop_id" binding:"omitempty,numeric"`
+	Method       string `json:"method" binding:"omitempty,oneof=map changelevel"`
+	Preset       string `json:"preset"`
+	SwitchPreset bool   `json:"switch_preset"`
+}
 
+// RconMapChangeResponse 切换地图的响应
+type RconMapChangeResponse struct {
+	Message  string           `json:"message"`
+	Response string           `json:"response"` // 切换命令的原始输出
+	Result   *MapChangeResult `json:"result"`
+}
+
+// rconMapChangeHandler 切换地图
+func rconMapChangeHandler(c *gin.Context) {
 	var req RconMapChangeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
-	response, err := ExecRconCommand(FullName(req.Name), "map "+req.Map)
+
+	result, err := ChangeMap(c.Request.Context(), req.Name, util.DefaultIfEmpty(req.WorkshopID, req.Map), MapChangeOptions{
+		Method:       req.Method,
+		Preset:       req.Preset,
+		SwitchPreset: req.SwitchPreset,
+	})
 	if err != nil {
-		handleErrorResponse(c, "执行命令失败", err)
+		handleErrorResponse(c, "切换地图失败", err)
 		return
-	} else {
-		util.Info("执行命令成功 命令: map " + req.Map + " 响应: " + response)
 	}
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	util.Info("切换地图成功 服务器: " + req.Name + " 命令: " + result.Command + " 当前地图: " + result.CurrentMap)
+
+	c.JSON(http.StatusOK, RconMapChangeResponse{
+		Message:  "切换地图成功",
+		Response: result.Response,
+		Result:   result,
 	})
 }
 
+// RconGameUserKickRequest 踢出玩家的请求参数
+type RconGameUserKickRequest struct {
+	Name string `json:"name" binding:"required"`
+	User string `json:"user" binding:"required,excludesall=\";\n\r"` // 玩家名称，不能包含引号、分号与换行
+}
+
+// rconGameUserKickHandler 踢出玩家
 func rconGameUserKickHandler(c *gin.Context) {
-	type RconGameUserKickRequest struct {
-		Name string `json:"name" binding:"required"`
-		User string `json:"user" binding:"required"`
-	}
 	var req RconGameUserKickRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		handleErrorResponse(c, "无效的请求参数", err)
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
 		return
 	}
 
 	util.Debug("rconGameUserKickHandler 请求参数: " + "Name: " + req.Name + ", User: " + req.User)
 
-	response, err := ExecRconCommand(FullName(req.Name), "kick \""+req.User+"\"")
+	response, err := ExecRconCommand(c.Request.Context(), req.Name, "kick \""+req.User+"\"")
 	if err != nil {
 		handleErrorResponse(c, "执行命令失败", err)
 		return
 	}
 	util.Info("执行命令成功 命令: kick \"" + req.User + "\" 响应: " + response)
 
-	c.JSON(200, gin.H{
-		"message":  "执行命令成功",
-		"response": response,
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "执行命令成功",
+		Response: response,
 	})
 }
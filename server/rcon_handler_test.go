@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// 游戏控制与参数接口只接受数字或布尔值，踢人的玩家名称不能拼接出其他命令
+func TestRconGameArgs(t *testing.T) {
+	router := testRouter()
+	rcon := startFakeRcon(t)
+	testDocker.setContainers(panelContainer("s1", "scrim-1", "running"))
+	testDocker.setEnv("s1", rcon.env()...)
+	session := userSession(t, router, "args-match-admin", RoleBinding{Role: "match_admin", Servers: []string{"scrim-1"}})
+
+	cases := []struct {
+		path    string
+		body    map[string]string
+		status  int
+		command string // 应发送的命令
+	}{
+		{"/api/rcon/game/config/maxrounds", map[string]string{"value": "30"}, http.StatusOK, "mp_maxrounds 30"},
+		{"/api/rcon/game/config/maxrounds", map[string]string{"value": "30; bot_kick"}, http.StatusBadRequest, ""},
+		{"/api/rcon/game/config/roundtime", map[string]string{"value": "1.92"}, http.StatusOK, "mp_roundtime 1.92"},
+		{"/api/rcon/game/config/autokick", map[string]string{"value": "false"}, http.StatusOK, "mp_autokick false"},
+		{"/api/rcon/game/config/autokick", map[string]string{"value": "0\nbot_kick"}, http.StatusBadRequest, ""},
+		{"/api/rcon/game/restart", map[string]string{"value": "1;bot_kick"}, http.StatusBadRequest, ""},
+		{"/api/rcon/game/mode", map[string]string{"gamemode": "1", "gametype": "0 ; bot_kick"}, http.StatusBadRequest, ""},
+		{"/api/rcon/game/user/kick", map[string]string{"user": "Player One"}, http.StatusOK, `kick "Player One"`},
+		{"/api/rcon/game/user/kick", map[string]string{"user": `x"; mp_restartgame 1`}, http.StatusBadRequest, ""},
+		{"/api/rcon/game/user/kick", map[string]string{"user": "x\nbot_kick"}, http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		tc.body["name"] = "scrim-1"
+		before := len(rcon.received())
+		w := serveJSON(router, http.MethodPost, tc.path, tc.body, session)
+		if w.Code != tc.status {
+			t.Errorf("%s %q: 状态码应为 %d，实际 %d: %s", tc.path, tc.body, tc.status, w.Code, w.Body.String())
+			continue
+		}
+		sent := rcon.received()[before:]
+		if tc.command == "" && len(sent) > 0 {
+			t.Errorf("%s %q: 被拒绝的请求不应发送命令，实际发送 %q", tc.path, tc.body, sent)
+		}
+		if tc.command != "" && !slices.Equal(sent, []string{tc.command}) {
+			t.Errorf("%s %q: 应发送 %q，实际 %q", tc.path, tc.body, tc.command, sent)
+		}
+	}
+}
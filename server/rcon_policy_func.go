@@ -0,0 +1,369 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+)
+
+const (
+	// rconPolicyFile RCON 命令策略在面板数据目录下的文件名
+	rconPolicyFile = "rcon_policies.json"
+	// RconPolicyGlobal 全局策略的名称，作用于所有用户（包括管理员）
+	RconPolicyGlobal = "*"
+)
+
+// 策略规则的动作
+const (
+	RconActionAllow = "allow" // 允许
+	RconActionDeny  = "deny"  // 拒绝
+)
+
+// ErrRconCommandDenied RCON 命令被策略拒绝
+var ErrRconCommandDenied = errors.New("RCON 命令被策略拒绝")
+
+// RconRule 一条策略规则，按顺序匹配，第一条匹配的规则生效
+type RconRule struct {
+	Command string `json:"command"` // 命令名通配符（path.Match 语法），不区分大小写
+	Args    string `json:"args"`    // 参数正则，不区分大小写，为空时匹配任意参数
+	Action  string `json:"action"`  // allow 或 deny
+	Note    string `json:"note"`    // 说明，命令被拒绝时返回给调用者
+}
+
+// RconPolicy 一个角色的 RCON 命令策略
+type RconPolicy struct {
+	Role    string     `json:"role"`    // 角色名称，* 为全局策略
+	Default string     `json:"default"` // 没有规则匹配时的动作
+	Rules   []RconRule `json:"rules"`
+}
+
+// defaultGlobalPolicy 未设置全局策略时使用：禁止关闭服务器、修改 RCON 密码（应使用轮换接口）、
+// 开启作弊、执行 cfg 目录之外的文件以及把日志发送到外部地址
+// 别名在调用时才展开，策略无法检查别名中的命令，因此禁止定义别名；sv_cheats 只允许查询与设置为 0
+var defaultGlobalPolicy = RconPolicy{
+	Role:    RconPolicyGlobal,
+	Default: RconActionAllow,
+	Rules: []RconRule{
+		{Command: "quit", Action: RconActionDeny, Note: "请使用面板停止服务器"},
+		{Command: "exit", Action: RconActionDeny, Note: "请使用面板停止服务器"},
+		{Command: "_restart", Action: RconActionDeny, Note: "请使用面板重启服务器"},
+		{Command: "rcon_password", Action: RconActionDeny, Note: "请使用 RCON 密码轮换接口"},
+		{Command: "alias", Action: RconActionDeny, Note: "不允许定义别名"},
+		{Command: "sv_cheats", Args: `^(0|"\s*0\s*")?$`, Action: RconActionAllow},
+		{Command: "sv_cheats", Action: RconActionDeny, Note: "不允许开启作弊"},
+		{Command: "exec*", Args: `\.\.|^"?\s*[/\\]|:`, Action: RconActionDeny, Note: "只能执行 cfg 目录下的文件"},
+		{Command: "logaddress_*", Action: RconActionDeny, Note: "不允许把日志发送到外部地址"},
+	},
+}
+
+// compiledRconRule 预编译参数正则的规则
+type compiledRconRule struct {
+	RconRule
+	args *regexp.Regexp
+}
+
+// compileRconPolicy 校验并编译策略
+func compileRconPolicy(p RconPolicy) ([]compiledRconRule, error) {
+	if p.Default != RconActionAllow && p.Default != RconActionDeny {
+		return nil, invalidf("默认动作只能是 allow 或 deny")
+	}
+	rules := make([]compiledRconRule, 0, len(p.Rules))
+	for i, r := range p.Rules {
+		if r.Command == "" {
+			return nil, invalidf("第%d条规则缺少命令名", i+1)
+		}
+		if _, err := path.Match(strings.ToLower(r.Command), ""); err != nil {
+			return nil, invalidf("第%d条规则的命令名通配符无效: %q", i+1, r.Command)
+		}
+		if r.Action != RconActionAllow && r.Action != RconActionDeny {
+			return nil, invalidf("第%d条规则的动作只能是 allow 或 deny", i+1)
+		}
+		rule := compiledRconRule{RconRule: r}
+		if r.Args != "" {
+			re, err := regexp.Compile("(?i)" + r.Args)
+			if err != nil {
+				return nil, invalidf("第%d条规则的参数正则无效: %w", i+1, err)
+			}
+			rule.args = re
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// evaluateRconPolicy 按策略判断一条命令：返回是否允许以及拒绝的原因
+func evaluateRconPolicy(p RconPolicy, rules []compiledRconRule, name, args string) (bool, string) {
+	for _, r := range rules {
+		if ok, _ := path.Match(strings.ToLower(r.Command), name); !ok {
+			continue
+		}
+		if r.args != nil && !r.args.MatchString(args) {
+			continue
+		}
+		if r.Action == RconActionAllow {
+			return true, ""
+		}
+		return false, util.DefaultIfEmpty(r.Note, "匹配规则 "+r.Command)
+	}
+	if p.Default == RconActionAllow {
+		return true, ""
+	}
+	return false, "策略默认拒绝"
+}
+
+// SplitRconScript 把脚本拆分为单条命令：按换行与引号外的分号拆分，去掉 // 注释与空命令，
+// 与服务器控制台的解析方式一致，避免在允许的命令后用分号或换行附带其他命令
+func SplitRconScript(script string) []string {
+	var commands []string
+	var current strings.Builder
+	flush := func() {
+		if cmd := strings.TrimSpace(current.String()); cmd != "" {
+			commands = append(commands, cmd)
+		}
+		current.Reset()
+	}
+
+	inQuote, inComment := false, false
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case ch == '\n' || ch == '\r':
+			flush()
+			inQuote, inComment = false, false
+		case inComment:
+		case ch == '"':
+			inQuote = !inQuote
+			current.WriteByte(ch)
+		case !inQuote && ch == ';':
+			flush()
+		case !inQuote && ch == '/' && i+1 < len(script) && script[i+1] == '/':
+			inComment = true
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	flush()
+	return commands
+}
+
+// splitRconCommand 拆分命令名（小写）与参数
+func splitRconCommand(command string) (string, string) {
+	command = strings.TrimSpace(command)
+	name, args := command, ""
+	if i := strings.IndexFunc(command, unicode.IsSpace); i >= 0 {
+		name, args = command[:i], strings.TrimSpace(command[i:])
+	}
+	return strings.ToLower(strings.Trim(name, `"`)), args
+}
+
+// RconPolicyStore 管理 RCON 命令策略：角色名称 -> 策略
+type RconPolicyStore struct {
+	mu sync.Mutex
+}
+
+// 全局策略存储
+var rconPolicyStore = &RconPolicyStore{}
+
+// load 读取所有策略，未设置全局策略时补上默认全局策略，调用方需持有锁
+func (s *RconPolicyStore) load() (map[string]RconPolicy, error) {
+	policies := make(map[string]RconPolicy)
+	if _, err := loadPanelJSON(&policies, rconPolicyFile); err != nil {
+		return nil, err
+	}
+	if _, ok := policies[RconPolicyGlobal]; !ok {
+		policies[RconPolicyGlobal] = defaultGlobalPolicy
+	}
+	return policies, nil
+}
+
+// List 列出所有策略，全局策略在最前
+func (s *RconPolicyStore) List() ([]RconPolicy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policies, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]RconPolicy, 0, len(policies))
+	for _, p := range policies {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Role == RconPolicyGlobal) != (result[j].Role == RconPolicyGlobal) {
+			return result[i].Role == RconPolicyGlobal
+		}
+		return result[i].Role < result[j].Role
+	})
+	return result, nil
+}
+
+// Save 保存角色或全局策略
+func (s *RconPolicyStore) Save(p RconPolicy) error {
+	if _, err := compileRconPolicy(p); err != nil {
+		return err
+	}
+	if p.Role != RconPolicyGlobal {
+		roles, err := roleStore.roleMap()
+		if err != nil {
+			return err
+		}
+		if _, ok := roles[p.Role]; !ok {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, p.Role)
+		}
+	}
+	if p.Rules == nil {
+		p.Rules = []RconRule{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policies, err := s.load()
+	if err != nil {
+		return err
+	}
+	policies[p.Role] = p
+	return savePanelJSON(policies, rconPolicyFile)
+}
+
+// Delete 删除角色策略，删除全局策略时恢复默认全局策略
+func (s *RconPolicyStore) Delete(role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	policies, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(policies, role)
+	return savePanelJSON(policies, rconPolicyFile)
+}
+
+// Check 按全局策略与用户在该服务器上的角色策略检查一条命令（可以是多行或分号连接的脚本）
+// 全局策略对所有用户生效；管理员不受角色策略限制。用户在该服务器上的角色只要有一个设置了策略，
+// 就只按设置了策略的角色判断，任一角色允许即可；都没有设置策略时不限制
+func (s *RconPolicyStore) Check(a *Authorizer, server, script string) error {
+	s.mu.Lock()
+	policies, err := s.load()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	type scoped struct {
+		policy RconPolicy
+		rules  []compiledRconRule
+	}
+	compile := func(p RconPolicy) (scoped, error) {
+		rules, err := compileRconPolicy(p)
+		if err != nil {
+			return scoped{}, fmt.Errorf("RCON 策略 %s 无效: %w", p.Role, err)
+		}
+		return scoped{policy: p, rules: rules}, nil
+	}
+
+	global, err := compile(policies[RconPolicyGlobal])
+	if err != nil {
+		return err
+	}
+	var roleScoped []scoped
+	if a != nil && !a.admin {
+		for _, role := range a.RolesFor(server) {
+			if p, ok := policies[role]; ok {
+				sp, err := compile(p)
+				if err != nil {
+					return err
+				}
+				roleScoped = append(roleScoped, sp)
+			}
+		}
+	}
+
+	for _, command := range SplitRconScript(script) {
+		name, args := splitRconCommand(command)
+		if ok, reason := evaluateRconPolicy(global.policy, global.rules, name, args); !ok {
+			return fmt.Errorf("%w: %s（%s）", ErrRconCommandDenied, name, reason)
+		}
+		if len(roleScoped) == 0 {
+			continue
+		}
+		var reasons []string
+		allowed := false
+		for _, sp := range roleScoped {
+			ok, reason := evaluateRconPolicy(sp.policy, sp.rules, name, args)
+			if ok {
+				allowed = true
+				break
+			}
+			reasons = append(reasons, "角色 "+sp.policy.Role+": "+reason)
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s（%s）", ErrRconCommandDenied, name, strings.Join(reasons, "；"))
+		}
+	}
+	return nil
+}
+
+// rconSubject 请求的操作者，用于检查请求中发送的 RCON 命令；权限在第一次发送命令时加载
+type rconSubject struct {
+	user  User
+	token *APIToken
+	once  sync.Once
+	auth  *Authorizer
+	err   error
+}
+
+// rconSubjectKey context 中保存操作者的键
+type rconSubjectKey struct{}
+
+// rconPolicyExemptKey context 中标记面板自身生成的命令（如密码轮换），不受策略限制
+type rconPolicyExemptKey struct{}
+
+// withRconSubject 在 ctx 中记录请求的操作者，由 AuthRequired 调用
+func withRconSubject(ctx context.Context, u User, token *APIToken) context.Context {
+	return context.WithValue(ctx, rconSubjectKey{}, &rconSubject{user: u, token: token})
+}
+
+// withoutRconPolicy 标记 ctx 中发送的命令由面板生成，不做策略检查，仍会记录审计日志
+func withoutRconPolicy(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rconPolicyExemptKey{}, true)
+}
+
+// CheckRconCommands 检查请求中要发送的命令；后台任务与面板生成的命令不检查
+// 被拒绝的命令记录警告日志并归入请求的审计记录
+func CheckRconCommands(ctx context.Context, server string, commands ...string) error {
+	subject, _ := ctx.Value(rconSubjectKey{}).(*rconSubject)
+	if subject == nil || ctx.Value(rconPolicyExemptKey{}) != nil {
+		return nil
+	}
+	subject.once.Do(func() {
+		subject.auth, subject.err = NewAuthorizer(subject.user, subject.token)
+	})
+	if subject.err != nil {
+		return subject.err
+	}
+
+	for _, command := range commands {
+		err := rconPolicyStore.Check(subject.auth, server, command)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, ErrRconCommandDenied) {
+			util.Warn("拒绝 RCON 命令 用户: " + subject.user.Username + " 服务器: " + server + " 命令: " + redactCommand(command) + " 原因: " + err.Error())
+			if auditTrailFrom(ctx) != nil {
+				auditRconCommand(ctx, server, command, err, 0)
+			}
+		}
+		return err
+	}
+	return nil
+}
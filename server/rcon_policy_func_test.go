@@ -0,0 +1,137 @@
+package server
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitRconScript(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"single", "status", []string{"status"}},
+		{"semicolon", "mp_warmup_end; mp_restartgame 1", []string{"mp_warmup_end", "mp_restartgame 1"}},
+		{"quoted semicolon", `say "a;b"; status`, []string{`say "a;b"`, "status"}},
+		{"comment", "say hi // quit", []string{"say hi"}},
+		{"comment in quotes", `say "http://x"`, []string{`say "http://x"`}},
+		{"comment ends at newline", "say hi // x\nquit", []string{"say hi", "quit"}},
+		{"crlf", "status\r\nquit\r\n", []string{"status", "quit"}},
+		{"quote ends at newline", "say \"x\nquit", []string{`say "x`, "quit"}},
+		{"empty", "  ;; \n\t", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SplitRconScript(tc.script); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SplitRconScript(%q) = %q，应为 %q", tc.script, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitRconCommand(t *testing.T) {
+	cases := []struct {
+		command, name, args string
+	}{
+		{"status", "status", ""},
+		{"  SV_Cheats 1 ", "sv_cheats", "1"},
+		{"exec\tgamemode.cfg", "exec", "gamemode.cfg"},
+		{`"quit"`, "quit", ""},
+		{`"sv_cheats" "1"`, "sv_cheats", `"1"`},
+	}
+	for _, tc := range cases {
+		name, args := splitRconCommand(tc.command)
+		if name != tc.name || args != tc.args {
+			t.Errorf("splitRconCommand(%q) = %q, %q，应为 %q, %q", tc.command, name, args, tc.name, tc.args)
+		}
+	}
+}
+
+func TestEvaluateRconPolicy(t *testing.T) {
+	rules, err := compileRconPolicy(defaultGlobalPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		command string
+		allowed bool
+	}{
+		{"status", true},
+		{"mp_restartgame 1", true},
+		{"quit", false},
+		{"QUIT", false},
+		{`"quit"`, false},
+		{"exit", false},
+		{"rcon_password x", false},
+		{"sv_cheats", true},
+		{"sv_cheats 0", true},
+		{`sv_cheats "0"`, true},
+		{`sv_cheats " 0 "`, true},
+		{"sv_cheats 1", false},
+		{"sv_cheats 01", false},
+		{"sv_cheats -1", false},
+		{"sv_cheats 0x1", false},
+		{"sv_cheats 0 1", false},
+		{`sv_cheats "1"`, false},
+		{`"sv_cheats" 1`, false},
+		{"alias q quit", false},
+		{`alias "q" "quit"`, false},
+		{"exec gamemode.cfg", true},
+		{"exec ../server.cfg", false},
+		{"exec /etc/passwd", false},
+		{`exec "\\x.cfg"`, false},
+		{"execifexists c:x.cfg", false},
+		{"logaddress_add 1.2.3.4:27500", false},
+	}
+	for _, tc := range cases {
+		name, args := splitRconCommand(tc.command)
+		if allowed, reason := evaluateRconPolicy(defaultGlobalPolicy, rules, name, args); allowed != tc.allowed {
+			t.Errorf("%q: allowed = %v（%s），应为 %v", tc.command, allowed, reason, tc.allowed)
+		}
+	}
+
+	// 规则按顺序匹配，没有规则匹配时使用默认动作
+	role := RconPolicy{Role: "ops", Default: RconActionDeny, Rules: []RconRule{
+		{Command: "mp_*", Args: `^\d+$`, Action: RconActionAllow},
+		{Command: "mp_*", Action: RconActionDeny, Note: "只能设置数字"},
+		{Command: "say", Action: RconActionAllow},
+	}}
+	roleRules, err := compileRconPolicy(role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for command, want := range map[string]bool{"mp_maxrounds 24": true, "mp_maxrounds x": false, "say hi": true, "status": false} {
+		name, args := splitRconCommand(command)
+		if allowed, _ := evaluateRconPolicy(role, roleRules, name, args); allowed != want {
+			t.Errorf("角色策略 %q: allowed = %v，应为 %v", command, allowed, want)
+		}
+	}
+}
+
+// 脚本逐条检查，任一条被拒绝时整批拒绝
+func TestRconPolicyCheckScript(t *testing.T) {
+	cases := []struct {
+		script  string
+		allowed bool
+	}{
+		{"status; mp_warmup_end", true},
+		{`say "quit; sv_cheats 1"`, true},
+		{"status; quit", false},
+		{"status\r\nquit", false},
+		{"say hi // x\nquit", false},
+		{"alias q quit\nq", false},
+		{"sv_cheats 0;sv_cheats 01", false},
+	}
+	for _, tc := range cases {
+		err := rconPolicyStore.Check(nil, "test", tc.script)
+		if err != nil && !errors.Is(err, ErrRconCommandDenied) {
+			t.Fatalf("%q: %v", tc.script, err)
+		}
+		if allowed := err == nil; allowed != tc.allowed {
+			t.Errorf("%q: allowed = %v（%v），应为 %v", tc.script, allowed, err, tc.allowed)
+		}
+	}
+}
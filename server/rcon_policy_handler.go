@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RconPolicyListResponse RCON 命令策略列表的响应
+type RconPolicyListResponse struct {
+	Policies []RconPolicy `json:"policies"`
+}
+
+// rconPolicyListHandler 处理获取 RCON 命令策略列表的请求
+func rconPolicyListHandler(c *gin.Context) {
+	policies, err := rconPolicyStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取 RCON 策略失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RconPolicyListResponse{
+		Policies: policies,
+	})
+}
+
+// rconPolicySaveHandler 处理保存角色或全局 RCON 命令策略的请求
+func rconPolicySaveHandler(c *gin.Context) {
+	var req RconPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := rconPolicyStore.Save(req); err != nil {
+		handleErrorResponse(c, "保存 RCON 策略失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "保存 RCON 策略成功",
+	})
+
+	util.Info("保存 RCON 策略成功 角色: " + req.Role)
+}
+
+// RconPolicyDeleteRequest 删除 RCON 命令策略的请求参数
+type RconPolicyDeleteRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// rconPolicyDeleteHandler 处理删除 RCON 命令策略的请求，删除全局策略即恢复默认
+func rconPolicyDeleteHandler(c *gin.Context) {
+	var req RconPolicyDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := rconPolicyStore.Delete(req.Role); err != nil {
+		handleErrorResponse(c, "删除 RCON 策略失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "删除 RCON 策略成功",
+	})
+
+	util.Info("删除 RCON 策略成功 角色: " + req.Role)
+}
+
+// RconPolicyCheckRequest 检查 RCON 命令的请求参数
+type RconPolicyCheckRequest struct {
+	Name string   `json:"name" binding:"required"`
+	Cmds []string `json:"cmds" binding:"required"`
+}
+
+// RconPolicyCheckResult 一条命令的检查结果
+type RconPolicyCheckResult struct {
+	Cmd     string `json:"cmd"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"` // 被拒绝的原因
+}
+
+// RconPolicyCheckResponse 检查 RCON 命令的响应
+type RconPolicyCheckResponse struct {
+	Results []RconPolicyCheckResult `json:"results"`
+}
+
+// rconPolicyCheckHandler 按当前用户的策略检查命令而不发送，供控制台提前提示
+func rconPolicyCheckHandler(c *gin.Context) {
+	var req RconPolicyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	a, err := authorizer(c)
+	if err != nil {
+		handleErrorResponse(c, "读取角色失败", err)
+		return
+	}
+	results := make([]RconPolicyCheckResult, 0, len(req.Cmds))
+	for _, cmd := range req.Cmds {
+		result := RconPolicyCheckResult{Cmd: cmd, Allowed: true}
+		if err := rconPolicyStore.Check(a, req.Name, cmd); err != nil {
+			result.Allowed = false
+			result.Reason = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, RconPolicyCheckResponse{
+		Results: results,
+	})
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// minMemoryLimit Docker 允许的最小内存限制
+const minMemoryLimit = 6 * 1024 * 1024
+
+// ContainerResources 服务器容器的资源限制与重启策略
+// 字段为 nil 表示不修改（创建时使用 Docker 默认值）
+type ContainerResources struct {
+	CPUs              *float64 `json:"cpus"`                // CPU 核数上限，例如 1.5，0 表示不限制
+	CpusetCPUs        *string  `json:"cpuset_cpus"`         // 绑定的 CPU，例如 "0-3" 或 "1,3"，空字符串表示不绑定
+	Memory            *string  `json:"memory"`              // 内存上限，例如 "4g"，空字符串表示不限制
+	RestartPolicy     *string  `json:"restart_policy"`      // 重启策略 no, always, unless-stopped, on-failure
+	RestartMaxRetries *int     `json:"restart_max_retries"` // on-failure 策略下的最大重试次数
+	StopTimeout       *int     `json:"stop_timeout"`        // 停止容器时等待退出的秒数
+}
+
+// Validate 根据 docker info 中的主机资源校验限制是否合理
+func (r *ContainerResources) Validate(ctx context.Context) error {
+	info, err := docker.Cli.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("获取 Docker 主机信息失败: %w", err)
+	}
+
+	if r.CPUs != nil {
+		if *r.CPUs < 0 || *r.CPUs > float64(info.NCPU) {
+			return invalidf("cpus 必须在 0 到 %d 之间", info.NCPU)
+		}
+	}
+	if r.CpusetCPUs != nil && *r.CpusetCPUs != "" {
+		if err := validateCpuset(*r.CpusetCPUs, info.NCPU); err != nil {
+			return err
+		}
+	}
+	if r.Memory != nil && *r.Memory != "" {
+		memory, err := units.RAMInBytes(*r.Memory)
+		if err != nil {
+			return invalidf("无效的 memory: %w", err)
+		}
+		if memory < minMemoryLimit {
+			return invalidf("memory 不能小于 %s", units.BytesSize(minMemoryLimit))
+		}
+		if memory > info.MemTotal {
+			return invalidf("memory 超出主机内存 %s", units.BytesSize(float64(info.MemTotal)))
+		}
+	}
+	if r.RestartPolicy != nil {
+		switch container.RestartPolicyMode(*r.RestartPolicy) {
+		case container.RestartPolicyDisabled, container.RestartPolicyAlways,
+			container.RestartPolicyOnFailure, container.RestartPolicyUnlessStopped:
+		default:
+			return invalidf("无效的 restart_policy %q", *r.RestartPolicy)
+		}
+	}
+	if r.RestartMaxRetries != nil {
+		if *r.RestartMaxRetries < 0 {
+			return invalidf("restart_max_retries 不能为负数")
+		}
+		if *r.RestartMaxRetries > 0 && (r.RestartPolicy == nil || *r.RestartPolicy != string(container.RestartPolicyOnFailure)) {
+			return invalidf("restart_max_retries 只能与 on-failure 重启策略一起使用")
+		}
+	}
+	if r.StopTimeout != nil && *r.StopTimeout < 0 {
+		return invalidf("stop_timeout 不能为负数")
+	}
+	return nil
+}
+
+// Apply 将资源限制写入容器配置，调用前需先通过 Validate 校验
+func (r *ContainerResources) Apply(cfg *container.Config, hostCfg *container.HostConfig) {
+	if r.CPUs != nil {
+		hostCfg.NanoCPUs = int64(*r.CPUs * 1e9)
+	}
+	if r.CpusetCPUs != nil {
+		hostCfg.CpusetCpus = *r.CpusetCPUs
+	}
+	if r.Memory != nil {
+		hostCfg.Memory = 0
+		if *r.Memory != "" {
+			hostCfg.Memory, _ = units.RAMInBytes(*r.Memory)
+		}
+	}
+	if r.RestartPolicy != nil {
+		hostCfg.RestartPolicy = container.RestartPolicy{Name: container.RestartPolicyMode(*r.RestartPolicy)}
+		if r.RestartMaxRetries != nil {
+			hostCfg.RestartPolicy.MaximumRetryCount = *r.RestartMaxRetries
+		}
+	}
+	if r.StopTimeout != nil {
+		timeout := *r.StopTimeout
+		cfg.StopTimeout = &timeout
+	}
+}
+
+// ResourcesFromConfig 从容器配置中读取当前的资源限制
+func ResourcesFromConfig(cfg *container.Config, hostCfg *container.HostConfig) ContainerResources {
+	cpus := float64(hostCfg.NanoCPUs) / 1e9
+	cpuset := hostCfg.CpusetCpus
+	memory := ""
+	if hostCfg.Memory > 0 {
+		memory = units.BytesSize(float64(hostCfg.Memory))
+	}
+	policy := string(hostCfg.RestartPolicy.Name)
+	if policy == "" {
+		policy = string(container.RestartPolicyDisabled)
+	}
+	retries := hostCfg.RestartPolicy.MaximumRetryCount
+
+	return ContainerResources{
+		CPUs:              &cpus,
+		CpusetCPUs:        &cpuset,
+		Memory:            &memory,
+		RestartPolicy:     &policy,
+		RestartMaxRetries: &retries,
+		StopTimeout:       cfg.StopTimeout,
+	}
+}
+
+// validateCpuset 校验 "0-3,5" 形式的 CPU 列表，每个 CPU 都必须小于主机 CPU 数量
+// 只检查区间的两端，不展开区间，避免 "0-100000000" 这类输入占用大量内存
+func validateCpuset(s string, ncpu int) error {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil || start < 0 {
+			return invalidf("无效的 cpuset_cpus %q", s)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(hi)
+			if err != nil || end < start {
+				return invalidf("无效的 cpuset_cpus %q", s)
+			}
+		}
+		if end >= ncpu {
+			return invalidf("cpuset_cpus 中的 CPU %d 超出主机 CPU 数量 %d", end, ncpu)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package server
+
+import "testing"
+
+func TestValidateCpuset(t *testing.T) {
+	cases := []struct {
+		cpuset string
+		valid  bool
+	}{
+		{"0", true},
+		{"0-3", true},
+		{"0-1, 3", true},
+		{"4", false},
+		{"2-4", false},
+		{"0-100000000", false},
+		{"3-1", false},
+		{"-1", false},
+		{"a", false},
+		{"0,", false},
+	}
+	for _, tc := range cases {
+		if err := validateCpuset(tc.cpuset, 4); (err == nil) != tc.valid {
+			t.Errorf("validateCpuset(%q, 4) = %v，应为 valid=%v", tc.cpuset, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+// MessageResponse 只返回提示消息的响应
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// RconResponse 执行单条 RCON 命令的响应
+type RconResponse struct {
+	Message  string `json:"message"`
+	Response string `json:"response"` // 服务器的原始输出
+}
+
+// RconResponses 依次执行多条 RCON 命令的响应
+type RconResponses struct {
+	Message   string   `json:"message"`
+	Responses []string `json:"responses"` // 各条命令的原始输出
+}
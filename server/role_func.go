@@ -0,0 +1,519 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 权限，按路由组划分
+const (
+	PermAll            = "*"                // 全部权限
+	PermContainerView  = "container.view"   // 查看容器、状态、地图与配置列表
+	PermContainerAdmin = "container.manage" // 创建、修改、启停、删除与导入容器，拉取镜像
+	PermRconExec       = "rcon.exec"        // 执行任意 RCON 命令
+	PermGameControl    = "game.control"     // 重启对局、切换模式、热身、应用预设与偏移修复
+	PermGameConfig     = "game.config"      // 修改 rcon/game/config 下的对局参数，以及 v2 接口中对应的 cvar
+	PermGameUser       = "game.user"        // 踢出玩家
+	PermMapChange      = "map.change"       // 切换地图与地图轮换
+	PermFileRead       = "file.read"        // 浏览与下载服务器文件
+	PermFileWrite      = "file.write"       // 写入、上传文件，推送与执行 cfg 配置
+	PermSecretView     = "secret.view"      // 查看服务器密码
+	PermCatalogManage  = "catalog.manage"   // 修改预设、cfg 配置、地图组、创意工坊登记与地图元数据
+	PermAuditView      = "audit.view"       // 查询与导出审计日志，限定服务器时只能看到这些服务器的记录
+)
+
+// AllPermissions 所有可分配的权限
+var AllPermissions = []string{
+	PermContainerView, PermContainerAdmin, PermRconExec, PermGameControl, PermGameConfig,
+	PermGameUser, PermMapChange, PermFileRead, PermFileWrite, PermSecretView, PermCatalogManage, PermAuditView,
+}
+
+const (
+	// roleFile 自定义角色在面板数据目录下的文件名
+	roleFile = "roles.json"
+	// roleBindingFile 用户角色分配在面板数据目录下的文件名
+	roleBindingFile = "role_bindings.json"
+	// contextAuthorizerKey gin.Context 中保存权限判断的键
+	contextAuthorizerKey = "authorizer"
+)
+
+var (
+	// ErrRoleNotFound 角色不存在
+	ErrRoleNotFound = errors.New("角色不存在")
+	// ErrForbidden 没有权限
+	ErrForbidden = errors.New("没有权限执行该操作")
+)
+
+// roleNameRegex 角色名称格式
+var roleNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Role 角色：一组权限
+type Role struct {
+	Name        string   `json:"name"`        // 角色名称（唯一标识）
+	Label       string   `json:"label"`       // 显示名称
+	Permissions []string `json:"permissions"` // 权限列表，* 表示全部
+	Builtin     bool     `json:"builtin"`     // 是否为内置角色
+}
+
+// builtinRoles 内置角色，不可修改或删除
+var builtinRoles = []Role{
+	{Name: "operator", Label: "运维", Permissions: []string{PermAll}, Builtin: true},
+	{Name: "match_admin", Label: "比赛管理员", Permissions: []string{PermContainerView, PermGameControl, PermGameConfig, PermGameUser, PermMapChange}, Builtin: true},
+	{Name: "viewer", Label: "只读", Permissions: []string{PermContainerView}, Builtin: true},
+}
+
+// RoleBinding 用户的一条角色分配，Servers 为服务器名称或通配符（path.Match 语法），为空表示所有服务器
+type RoleBinding struct {
+	Role    string   `json:"role"`
+	Servers []string `json:"servers"`
+}
+
+// Has 角色是否包含权限
+func (r Role) Has(perm string) bool {
+	for _, p := range r.Permissions {
+		if p == PermAll || p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers 分配是否作用于服务器
+func (b RoleBinding) Covers(server string) bool {
+	if len(b.Servers) == 0 {
+		return true
+	}
+	for _, pattern := range b.Servers {
+		if ok, _ := path.Match(pattern, server); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleStore 管理自定义角色与用户的角色分配
+type RoleStore struct {
+	mu sync.Mutex
+}
+
+// 全局角色存储
+var roleStore = &RoleStore{}
+
+// load 读取自定义角色，调用方需持有锁
+func (s *RoleStore) load() (map[string]Role, error) {
+	roles := make(map[string]Role)
+	if _, err := loadPanelJSON(&roles, roleFile); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// loadBindings 读取角色分配：用户名 -> 分配列表，调用方需持有锁
+func (s *RoleStore) loadBindings() (map[string][]RoleBinding, error) {
+	bindings := make(map[string][]RoleBinding)
+	if _, err := loadPanelJSON(&bindings, roleBindingFile); err != nil {
+		return nil, err
+	}
+	return bindings, nil
+}
+
+// List 列出内置角色与自定义角色
+func (s *RoleStore) List() ([]Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := append([]Role{}, builtinRoles...)
+	custom := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		custom = append(custom, role)
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i].Name < custom[j].Name })
+	return append(result, custom...), nil
+}
+
+// roleMap 内置与自定义角色的索引，调用方需持有锁
+func (s *RoleStore) roleMap() (map[string]Role, error) {
+	roles, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range builtinRoles {
+		roles[role.Name] = role
+	}
+	return roles, nil
+}
+
+// Save 保存自定义角色
+func (s *RoleStore) Save(role Role) error {
+	if !roleNameRegex.MatchString(role.Name) {
+		return invalidf("无效的角色名称 %q，只允许小写字母、数字与下划线", role.Name)
+	}
+	for _, builtin := range builtinRoles {
+		if builtin.Name == role.Name {
+			return conflictf("不能修改内置角色 %s", role.Name)
+		}
+	}
+	if len(role.Permissions) == 0 {
+		return invalidf("角色至少需要一个权限")
+	}
+	for _, perm := range role.Permissions {
+		if perm != PermAll && !slices.Contains(AllPermissions, perm) {
+			return invalidf("未知的权限 %q", perm)
+		}
+	}
+	role.Builtin = false
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles, err := s.load()
+	if err != nil {
+		return err
+	}
+	roles[role.Name] = role
+	return savePanelJSON(roles, roleFile)
+}
+
+// Delete 删除自定义角色，仍有用户使用时拒绝删除
+func (s *RoleStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := roles[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, name)
+	}
+	bindings, err := s.loadBindings()
+	if err != nil {
+		return err
+	}
+	for username, list := range bindings {
+		for _, b := range list {
+			if b.Role == name {
+				return conflictf("角色 %s 仍分配给用户 %s", name, username)
+			}
+		}
+	}
+	delete(roles, name)
+	return savePanelJSON(roles, roleFile)
+}
+
+// Bindings 获取用户的角色分配
+func (s *RoleStore) Bindings(username string) ([]RoleBinding, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bindings, err := s.loadBindings()
+	if err != nil {
+		return nil, err
+	}
+	if bindings[username] == nil {
+		return []RoleBinding{}, nil
+	}
+	return bindings[username], nil
+}
+
+// SetBindings 设置用户的角色分配，列表为空时移除该用户的所有分配
+func (s *RoleStore) SetBindings(username string, list []RoleBinding) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles, err := s.roleMap()
+	if err != nil {
+		return err
+	}
+	for _, b := range list {
+		if _, ok := roles[b.Role]; !ok {
+			return fmt.Errorf("%w: %s", ErrRoleNotFound, b.Role)
+		}
+		for _, pattern := range b.Servers {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return invalidf("无效的服务器通配符 %q", pattern)
+			}
+		}
+	}
+
+	bindings, err := s.loadBindings()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		delete(bindings, username)
+	} else {
+		bindings[username] = list
+	}
+	return savePanelJSON(bindings, roleBindingFile)
+}
+
+// Grants 用户的角色分配及对应角色，跳过已不存在的角色
+func (s *RoleStore) Grants(username string) ([]RoleBinding, map[string]Role, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles, err := s.roleMap()
+	if err != nil {
+		return nil, nil, err
+	}
+	bindings, err := s.loadBindings()
+	if err != nil {
+		return nil, nil, err
+	}
+	return bindings[username], roles, nil
+}
+
+// Authorizer 某个用户的权限判断，管理员拥有全部权限；使用 API 令牌时再与令牌范围取交集
+type Authorizer struct {
+	admin    bool
+	bindings []RoleBinding
+	roles    map[string]Role
+	token    *APIToken
+}
+
+// NewAuthorizer 加载用户的角色分配，token 不为空时限制在令牌范围内
+func NewAuthorizer(u User, token *APIToken) (*Authorizer, error) {
+	if u.Admin {
+		return &Authorizer{admin: true, token: token}, nil
+	}
+	bindings, roles, err := roleStore.Grants(u.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &Authorizer{bindings: bindings, roles: roles, token: token}, nil
+}
+
+// Allowed 是否拥有服务器上的权限；server 为空时只要任一分配包含该权限即可，用于不针对具体服务器的接口
+func (a *Authorizer) Allowed(perm, server string) bool {
+	if a.token != nil && !a.token.Allows(perm, server) {
+		return false
+	}
+	if a.admin {
+		return true
+	}
+	for _, b := range a.bindings {
+		if role, ok := a.roles[b.Role]; ok && role.Has(perm) && (server == "" || b.Covers(server)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedEverywhere 是否在所有服务器上都拥有权限：管理员，或通过不限服务器的角色分配获得权限
+// 令牌限制了服务器时返回 false
+func (a *Authorizer) AllowedEverywhere(perm string) bool {
+	if a.token != nil && (!a.token.Allows(perm, "") || len(a.token.Servers) > 0) {
+		return false
+	}
+	if a.admin {
+		return true
+	}
+	for _, b := range a.bindings {
+		if role, ok := a.roles[b.Role]; ok && role.Has(perm) && len(b.Servers) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesFor 列出作用于服务器的角色，管理员返回空
+func (a *Authorizer) RolesFor(server string) []string {
+	var roles []string
+	for _, b := range a.bindings {
+		if _, ok := a.roles[b.Role]; ok && b.Covers(server) && !slices.Contains(roles, b.Role) {
+			roles = append(roles, b.Role)
+		}
+	}
+	return roles
+}
+
+// Permissions 列出在任一服务器上拥有的权限，供前端显示
+func (a *Authorizer) Permissions() []string {
+	if a.admin && a.token == nil {
+		return []string{PermAll}
+	}
+	result := make([]string, 0)
+	for _, perm := range AllPermissions {
+		if a.Allowed(perm, "") {
+			result = append(result, perm)
+		}
+	}
+	return result
+}
+
+// requestServers 从请求中取出所有目标服务器：v2 路径参数、查询参数与表单中的 name，请求体 JSON 中的 name 与 names
+// 处理函数可能从任一来源绑定参数，因此全部收集并逐个检查；读取请求体后会重新写回，处理函数可以照常绑定参数
+// 请求体无法按处理函数的方式解析时返回错误，调用方不能据此放行
+func requestServers(c *gin.Context) ([]string, error) {
+	var servers []string
+	if name := c.Param("name"); name != "" {
+		servers = append(servers, name)
+	}
+	if name := c.Query("name"); name != "" {
+		servers = append(servers, name)
+	}
+	if c.Request.Method == http.MethodGet || c.Request.Body == nil {
+		return servers, nil
+	}
+
+	switch c.ContentType() {
+	case gin.MIMEMultipartPOSTForm, gin.MIMEPOSTForm:
+		if name := c.PostForm("name"); name != "" {
+			servers = append(servers, name)
+		}
+		return servers, nil
+	}
+
+	// 处理函数使用 ShouldBindJSON 时不检查 Content-Type，其余类型的请求体也按 JSON 解析
+	body, err := peekBody(c)
+	if err != nil {
+		return servers, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return servers, nil
+	}
+
+	// 与 gin 的 JSON 绑定一样使用 json.Decoder：类型不符时 Decoder 仍会填充其余字段，
+	// 之后的多余数据也会被忽略，因此任何解码错误或多余数据都视为无效请求，避免漏检处理函数实际使用的 name
+	var target struct {
+		Name  string   `json:"name"`
+		Names []string `json:"names"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if err := decoder.Decode(&target); err != nil {
+		return servers, invalidf("无效的请求体: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return servers, invalidf("请求体在 JSON 之后包含多余的数据")
+	}
+	if target.Name != "" {
+		servers = append(servers, target.Name)
+	}
+	return append(servers, target.Names...), nil
+}
+
+// peekBody 读取请求体并重新写回，处理函数可以照常绑定参数
+func peekBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+// authorizer 获取当前请求的权限判断，同一请求内只加载一次
+func authorizer(c *gin.Context) (*Authorizer, error) {
+	if v, ok := c.Get(contextAuthorizerKey); ok {
+		return v.(*Authorizer), nil
+	}
+	u, ok := currentUser(c)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+	var token *APIToken
+	if t, ok := currentToken(c); ok {
+		token = &t
+	}
+	a, err := NewAuthorizer(u, token)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(contextAuthorizerKey, a)
+	return a, nil
+}
+
+// abortForbidden 返回 403
+func abortForbidden(c *gin.Context, perm string, servers []string) {
+	detail := perm
+	if len(servers) > 0 {
+		detail += " 服务器: " + strings.Join(servers, ",")
+	}
+	if t, ok := currentToken(c); ok {
+		detail += " 令牌: " + t.Name
+	}
+	abortWithError(c, ErrForbidden.Error(), fmt.Errorf("%w: 权限 %s", ErrForbidden, detail), func(resp *ErrorResponse) {
+		resp.Permission = perm
+	})
+}
+
+// Require 要求不针对具体服务器的权限，需在 AuthRequired 之后使用
+func Require(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a, err := authorizer(c)
+		if err != nil {
+			abortWithError(c, "读取角色失败", err, nil)
+			return
+		}
+		if !a.Allowed(perm, "") {
+			abortForbidden(c, perm, nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireServer 要求对请求中所有目标服务器拥有权限，需在 AuthRequired 之后使用
+// 请求没有指定服务器时按 Require 处理，由处理函数校验参数；在 RconRateLimit 之下时权限检查通过后按服务器限流
+func RequireServer(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a, err := authorizer(c)
+		if err != nil {
+			abortWithError(c, "读取角色失败", err, nil)
+			return
+		}
+		servers, err := requestServers(c)
+		if err != nil {
+			abortWithError(c, "无效的请求参数", err, nil)
+			return
+		}
+		if len(servers) == 0 && !a.Allowed(perm, "") {
+			abortForbidden(c, perm, nil)
+			return
+		}
+		for _, server := range servers {
+			if !a.Allowed(perm, server) {
+				abortForbidden(c, perm, servers)
+				return
+			}
+		}
+		if !chargeServerRate(c, servers) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// serverAllowed 处理函数中判断当前用户是否拥有服务器上的权限，用于过滤列表
+func serverAllowed(c *gin.Context, perm, server string) bool {
+	a, err := authorizer(c)
+	return err == nil && a.Allowed(perm, server)
+}
+
+// requireServers 处理函数中按参数追加的权限检查，缺少任一服务器上的权限时返回 403 并返回 false
+func requireServers(c *gin.Context, perm string, servers []string) bool {
+	for _, server := range servers {
+		if !serverAllowed(c, perm, server) {
+			abortForbidden(c, perm, servers)
+			return false
+		}
+	}
+	return true
+}
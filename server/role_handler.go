@@ -0,0 +1,137 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthRoleListResponse 角色列表的响应
+type AuthRoleListResponse struct {
+	Permissions []string `json:"permissions"` // 所有可分配的权限
+	Roles       []Role   `json:"roles"`
+}
+
+// authRoleListHandler 处理获取角色列表的请求，同时返回所有可分配的权限
+func authRoleListHandler(c *gin.Context) {
+	roles, err := roleStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取角色列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthRoleListResponse{
+		Permissions: AllPermissions,
+		Roles:       roles,
+	})
+}
+
+// authRoleSaveHandler 处理保存自定义角色的请求
+func authRoleSaveHandler(c *gin.Context) {
+	var req Role
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := roleStore.Save(req); err != nil {
+		handleErrorResponse(c, "保存角色失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "保存角色成功",
+	})
+
+	util.Info("保存角色成功 角色: " + req.Name)
+}
+
+// AuthRoleDeleteRequest 删除自定义角色的请求参数
+type AuthRoleDeleteRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// authRoleDeleteHandler 处理删除自定义角色的请求
+func authRoleDeleteHandler(c *gin.Context) {
+	var req AuthRoleDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := roleStore.Delete(req.Name); err != nil {
+		handleErrorResponse(c, "删除角色失败", err)
+		return
+	}
+	if err := rconPolicyStore.Delete(req.Name); err != nil {
+		util.Error("删除角色的 RCON 策略失败", err)
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "删除角色成功",
+	})
+
+	util.Info("删除角色成功 角色: " + req.Name)
+}
+
+// AuthRoleBindingGetRequest 获取用户角色分配的请求参数
+type AuthRoleBindingGetRequest struct {
+	Username string `form:"username" binding:"required"`
+}
+
+// AuthRoleBindingResponse 用户角色分配的响应
+type AuthRoleBindingResponse struct {
+	Username string        `json:"username"`
+	Bindings []RoleBinding `json:"bindings"`
+}
+
+// authRoleBindingGetHandler 处理获取用户角色分配的请求
+func authRoleBindingGetHandler(c *gin.Context) {
+	var req AuthRoleBindingGetRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	bindings, err := roleStore.Bindings(req.Username)
+	if err != nil {
+		handleErrorResponse(c, "获取角色分配失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthRoleBindingResponse{
+		Username: req.Username,
+		Bindings: bindings,
+	})
+}
+
+// AuthRoleBindingSetRequest 设置用户角色分配的请求参数
+type AuthRoleBindingSetRequest struct {
+	Username string        `json:"username" binding:"required"`
+	Bindings []RoleBinding `json:"bindings"`
+}
+
+// authRoleBindingSetHandler 处理设置用户角色分配的请求，整体替换该用户的分配
+func authRoleBindingSetHandler(c *gin.Context) {
+	var req AuthRoleBindingSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if _, err := userStore.Get(req.Username); err != nil {
+		handleErrorResponse(c, "设置角色分配失败", err)
+		return
+	}
+	if err := roleStore.SetBindings(req.Username, req.Bindings); err != nil {
+		handleErrorResponse(c, "设置角色分配失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "设置角色分配成功",
+	})
+
+	util.Info("设置角色分配成功 用户: " + req.Username)
+}
@@ -0,0 +1,707 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	// RotationSequential 按列表顺序轮换
+	RotationSequential = "sequential"
+	// RotationRandom 随机选择
+	RotationRandom = "random"
+	// RotationWeighted 按权重随机选择
+	RotationWeighted = "weighted"
+
+	// rotationFile 轮换配置在面板数据目录下的文件名
+	rotationFile = "rotations.json"
+	// rotationStateFile 轮换进度在面板数据目录下的文件名
+	rotationStateFile = "rotation_state.json"
+	// rotationHistorySize 保留的最近地图数量
+	rotationHistorySize = 20
+	// rotationRetryDelay 日志流断开（容器停止、重建等）后重新连接的间隔
+	rotationRetryDelay = 10 * time.Second
+	// defaultRotationChangeDelay 比赛结束后默认等待多久切换地图，单位秒
+	defaultRotationChangeDelay = 10
+)
+
+var (
+	// gameOverRegex 比赛结束日志，如 Game Over: competitive mg_active de_dust2 score 13:5 after 35 min
+	gameOverRegex = regexp.MustCompile(`Game Over:`)
+	// chatRegex 玩家聊天日志，如 "Name<2><[U:1:123]><CT>" say "!1"
+	chatRegex = regexp.MustCompile(`"(.*)<\d+><([^>]*)><[^>]*>" say(?:_team)? "(.*)"`)
+	// voteRegex 投票聊天内容
+	voteRegex = regexp.MustCompile(`^[!.](\d)$`)
+)
+
+// RotationMap 轮换列表中的地图
+type RotationMap struct {
+	Map    string `json:"map"`    // 地图名，或登记的创意工坊名称/ID
+	Weight int    `json:"weight"` // 权重，仅 weighted 模式使用，未填写时为 1
+}
+
+// RotationVote 比赛结束时的地图投票设置
+type RotationVote struct {
+	Enabled  bool `json:"enabled"`
+	Options  int  `json:"options"`  // 候选地图数量，2-9
+	Duration int  `json:"duration"` // 投票时长，单位秒
+}
+
+// RotationConfig 服务器的地图轮换设置
+type RotationConfig struct {
+	Enabled     bool          `json:"enabled"`
+	Mode        string        `json:"mode"` // sequential、random 或 weighted
+	Maps        []RotationMap `json:"maps"`
+	SkipRecent  int           `json:"skip_recent"`  // 跳过最近玩过的地图数量
+	ChangeDelay int           `json:"change_delay"` // 比赛结束（或投票结束）后等待多久切换，单位秒，0 使用默认值
+	Vote        RotationVote  `json:"vote"`
+}
+
+// RotationVoteState 正在进行的投票
+type RotationVoteState struct {
+	Options  []string       `json:"options"`
+	Votes    map[string]int `json:"votes"` // 玩家 SteamID -> 选项序号（从 1 开始）
+	Deadline time.Time      `json:"deadline"`
+}
+
+// Tally 统计每个选项的票数
+func (v *RotationVoteState) Tally() []int {
+	tally := make([]int, len(v.Options))
+	for _, choice := range v.Votes {
+		if choice >= 1 && choice <= len(tally) {
+			tally[choice-1]++
+		}
+	}
+	return tally
+}
+
+// RotationState 服务器的轮换进度
+type RotationState struct {
+	Position   int                `json:"position"` // 当前地图在轮换列表中的位置，-1 表示尚未轮换
+	Next       string             `json:"next"`     // 计划的下一张地图
+	History    []string           `json:"history"`  // 最近切换的地图，最新的在最后
+	Vote       *RotationVoteState `json:"vote,omitempty"`
+	Watching   bool               `json:"watching"` // 是否正在监听服务器日志
+	LastChange time.Time          `json:"last_change"`
+	LastError  string             `json:"last_error,omitempty"`
+}
+
+// RotationStore 管理轮换配置与进度
+type RotationStore struct {
+	mu sync.Mutex
+}
+
+// 全局轮换存储
+var rotationStore = &RotationStore{}
+
+// Config 获取服务器的轮换配置
+func (s *RotationStore) Config(server string) (RotationConfig, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	configs := make(map[string]RotationConfig)
+	if _, err := loadPanelJSON(&configs, rotationFile); err != nil {
+		return RotationConfig{}, false, err
+	}
+	cfg, ok := configs[server]
+	return cfg, ok, nil
+}
+
+// Configs 获取所有服务器的轮换配置
+func (s *RotationStore) Configs() (map[string]RotationConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	configs := make(map[string]RotationConfig)
+	if _, err := loadPanelJSON(&configs, rotationFile); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// SetConfig 保存服务器的轮换配置
+func (s *RotationStore) SetConfig(server string, cfg RotationConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	configs := make(map[string]RotationConfig)
+	if _, err := loadPanelJSON(&configs, rotationFile); err != nil {
+		return err
+	}
+	configs[server] = cfg
+	return savePanelJSON(configs, rotationFile)
+}
+
+// State 获取服务器的轮换进度
+func (s *RotationStore) State(server string) (RotationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.loadStates()
+	if err != nil {
+		return RotationState{}, err
+	}
+	state, ok := states[server]
+	if !ok {
+		state = RotationState{Position: -1}
+	}
+	return state, nil
+}
+
+// UpdateState 修改并保存服务器的轮换进度
+func (s *RotationStore) UpdateState(server string, fn func(*RotationState)) (RotationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.loadStates()
+	if err != nil {
+		return RotationState{}, err
+	}
+	state, ok := states[server]
+	if !ok {
+		state = RotationState{Position: -1}
+	}
+	fn(&state)
+	states[server] = state
+	return state, savePanelJSON(states, rotationStateFile)
+}
+
+// loadStates 读取所有轮换进度，调用方需持有锁
+func (s *RotationStore) loadStates() (map[string]RotationState, error) {
+	states := make(map[string]RotationState)
+	if _, err := loadPanelJSON(&states, rotationStateFile); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// Validate 校验轮换配置，地图必须在地图目录中或为登记的创意工坊物品；关闭轮换时地图列表可以为空
+func (cfg *RotationConfig) Validate(ctx context.Context) error {
+	if !cfg.Enabled && len(cfg.Maps) == 0 {
+		return nil
+	}
+	switch cfg.Mode {
+	case RotationSequential, RotationRandom, RotationWeighted:
+	default:
+		return invalidf("无效的轮换模式 %q", cfg.Mode)
+	}
+	if len(cfg.Maps) == 0 {
+		return invalidf("轮换列表至少需要一张地图")
+	}
+	if cfg.SkipRecent < 0 || cfg.SkipRecent >= len(cfg.Maps) {
+		return invalidf("skip_recent 需在 0 到 %d 之间", len(cfg.Maps)-1)
+	}
+	if cfg.ChangeDelay < 0 || cfg.ChangeDelay > 60 {
+		return invalidf("change_delay 需在 0 到 60 秒之间")
+	}
+	if cfg.ChangeDelay == 0 {
+		cfg.ChangeDelay = defaultRotationChangeDelay
+	}
+	if cfg.Vote.Enabled {
+		if cfg.Vote.Options < 2 || cfg.Vote.Options > 9 || cfg.Vote.Options > len(cfg.Maps) {
+			return invalidf("投票候选数量需在 2 到 %d 之间", min(9, len(cfg.Maps)))
+		}
+		if cfg.Vote.Duration < 10 || cfg.Vote.Duration > 120 {
+			return invalidf("投票时长需在 10 到 120 秒之间")
+		}
+	}
+
+	catalog, err := BuildMapCatalog(ctx, false)
+	if catalog == nil {
+		return err
+	}
+	for i, m := range cfg.Maps {
+		if _, ok := findGroupMap(catalog, m.Map); !ok {
+			return fmt.Errorf("%w: %s", ErrMapUnknown, m.Map)
+		}
+		if m.Weight < 0 {
+			return invalidf("地图 %s 的权重不能为负数", m.Map)
+		}
+		if m.Weight == 0 {
+			cfg.Maps[i].Weight = 1
+		}
+	}
+	return nil
+}
+
+// recentMaps 最近玩过的 n 张地图
+func recentMaps(history []string, n int) map[string]bool {
+	recent := make(map[string]bool)
+	for i := len(history) - 1; i >= 0 && len(history)-i <= n; i-- {
+		recent[strings.ToLower(history[i])] = true
+	}
+	return recent
+}
+
+// eligibleMaps 可选的地图下标，跳过最近玩过的地图；全部被跳过时只排除上一张地图
+func eligibleMaps(cfg RotationConfig, history []string, exclude map[int]bool) []int {
+	for _, n := range []int{cfg.SkipRecent, 1, 0} {
+		recent := recentMaps(history, n)
+		var result []int
+		for i, m := range cfg.Maps {
+			if !exclude[i] && !recent[strings.ToLower(m.Map)] {
+				result = append(result, i)
+			}
+		}
+		if len(result) > 0 {
+			return result
+		}
+	}
+	return nil
+}
+
+// pickRotationMap 按轮换模式选择下一张地图的下标，exclude 中的下标不会被选中
+func pickRotationMap(cfg RotationConfig, state RotationState, exclude map[int]bool) (int, bool) {
+	candidates := eligibleMaps(cfg, state.History, exclude)
+	if len(candidates) == 0 {
+		return 0, false
+	}
+
+	switch cfg.Mode {
+	case RotationSequential:
+		// 从当前位置之后找第一张可选的地图
+		allowed := make(map[int]bool, len(candidates))
+		for _, i := range candidates {
+			allowed[i] = true
+		}
+		for step := 1; step <= len(cfg.Maps); step++ {
+			i := (state.Position + step + len(cfg.Maps)) % len(cfg.Maps)
+			if allowed[i] {
+				return i, true
+			}
+		}
+	case RotationWeighted:
+		total := 0
+		for _, i := range candidates {
+			total += max(cfg.Maps[i].Weight, 1)
+		}
+		r := rand.Intn(total)
+		for _, i := range candidates {
+			r -= max(cfg.Maps[i].Weight, 1)
+			if r < 0 {
+				return i, true
+			}
+		}
+	}
+	return candidates[rand.Intn(len(candidates))], true
+}
+
+// rotationIndex 地图在轮换列表中的下标
+func rotationIndex(cfg RotationConfig, mapName string) int {
+	for i, m := range cfg.Maps {
+		if strings.EqualFold(m.Map, mapName) {
+			return i
+		}
+	}
+	return -1
+}
+
+// planNext 计算下一张地图并写入进度
+func planNext(cfg RotationConfig, state *RotationState) {
+	state.Next = ""
+	if i, ok := pickRotationMap(cfg, *state, nil); ok {
+		state.Next = cfg.Maps[i].Map
+	}
+}
+
+// rotateTo 切换到轮换中的地图并更新进度
+func rotateTo(ctx context.Context, server string, cfg RotationConfig, mapName string) (*MapChangeResult, error) {
+	result, changeErr := ChangeMap(ctx, server, mapName, MapChangeOptions{})
+
+	_, err := rotationStore.UpdateState(server, func(state *RotationState) {
+		state.Vote = nil
+		if changeErr != nil {
+			state.LastError = changeErr.Error()
+			return
+		}
+		state.LastError = ""
+		state.LastChange = time.Now()
+		state.Position = rotationIndex(cfg, mapName)
+		state.History = append(state.History, mapName)
+		if len(state.History) > rotationHistorySize {
+			state.History = state.History[len(state.History)-rotationHistorySize:]
+		}
+		planNext(cfg, state)
+	})
+	if changeErr != nil {
+		return nil, changeErr
+	}
+	return result, err
+}
+
+// AdvanceRotation 立即切换到计划的下一张地图
+func AdvanceRotation(ctx context.Context, server string) (*MapChangeResult, error) {
+	cfg, ok, err := rotationStore.Config(server)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || len(cfg.Maps) == 0 {
+		return nil, conflictf("服务器 %s 未设置地图轮换", server)
+	}
+	state, err := rotationStore.State(server)
+	if err != nil {
+		return nil, err
+	}
+	next := state.Next
+	if next == "" || rotationIndex(cfg, next) < 0 {
+		planNext(cfg, &state)
+		next = state.Next
+	}
+	return rotateTo(ctx, server, cfg, next)
+}
+
+// SetRotation 保存轮换配置，重新计划下一张地图并启动或停止日志监听
+func SetRotation(server string, cfg RotationConfig) (RotationState, error) {
+	if err := rotationStore.SetConfig(server, cfg); err != nil {
+		return RotationState{}, err
+	}
+	state, err := rotationStore.UpdateState(server, func(state *RotationState) {
+		state.Vote = nil
+		planNext(cfg, state)
+	})
+	if err != nil {
+		return state, err
+	}
+
+	if cfg.Enabled {
+		rotationControllers.Start(server)
+	} else {
+		rotationControllers.Stop(server)
+	}
+	return state, nil
+}
+
+// RotationControllers 每个启用轮换的服务器一个日志监听协程
+type RotationControllers struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	gens    map[string]uint64 // 服务器当前控制器的代号，被替换的控制器退出时不能改写新控制器的监听状态
+	lastGen uint64
+}
+
+// newRotationControllers 创建轮换控制器集合
+func newRotationControllers() *RotationControllers {
+	return &RotationControllers{
+		cancels: make(map[string]context.CancelFunc),
+		gens:    make(map[string]uint64),
+	}
+}
+
+// 全局轮换控制器
+var rotationControllers = newRotationControllers()
+
+// Start 启动服务器的轮换控制器，已启动时重新启动以应用新配置
+func (rc *RotationControllers) Start(server string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if cancel, ok := rc.cancels[server]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	rc.lastGen++
+	rc.cancels[server] = cancel
+	rc.gens[server] = rc.lastGen
+	go rc.run(ctx, server, rc.lastGen)
+}
+
+// Stop 停止服务器的轮换控制器，并清除其监听状态
+func (rc *RotationControllers) Stop(server string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if cancel, ok := rc.cancels[server]; ok {
+		cancel()
+		delete(rc.cancels, server)
+		delete(rc.gens, server)
+		saveWatching(server, false)
+	}
+}
+
+// setWatching 记录代号为 gen 的控制器是否正在监听日志，控制器已被替换或停止时不做修改
+func (rc *RotationControllers) setWatching(server string, gen uint64, watching bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.gens[server] != gen {
+		return
+	}
+	saveWatching(server, watching)
+}
+
+// startRotationControllers 为所有启用轮换的服务器启动控制器
+func startRotationControllers() {
+	configs, err := rotationStore.Configs()
+	if err != nil {
+		util.Error("读取地图轮换配置失败", err)
+		return
+	}
+	for server, cfg := range configs {
+		if cfg.Enabled {
+			rotationControllers.Start(server)
+		}
+	}
+}
+
+// followServerLog 持续读取服务器容器从现在开始的日志，逐行写入 lines，日志流结束时关闭 lines
+func followServerLog(ctx context.Context, server string, lines chan<- string) error {
+	defer close(lines)
+
+	ctr, err := ResolveContainer(ctx, server)
+	if err != nil {
+		return err
+	}
+	if ctr.State != "running" {
+		return conflictf("服务器 %s 未运行", server)
+	}
+	logs, err := docker.Cli.ContainerLogs(ctx, ctr.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Since:      strconv.FormatInt(time.Now().Unix(), 10),
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	for scanner.Scan() {
+		select {
+		case lines <- scanner.Text():
+		case <-ctx.Done():
+			pr.Close()
+			return ctx.Err()
+		}
+	}
+	return scanner.Err()
+}
+
+// prepareRotationServer 关闭服务器自带的下一张地图投票，并延长比赛结束后的等待时间，
+// 让面板在服务器自动换图之前完成投票与切换
+func prepareRotationServer(server string, cfg RotationConfig) {
+	delay := cfg.ChangeDelay + 30
+	if cfg.Vote.Enabled {
+		delay += cfg.Vote.Duration
+	}
+	commands := []string{
+		CvarCommand("mp_endmatch_votenextmap", "0"),
+		CvarCommand("mp_match_restart_delay", strconv.Itoa(delay)),
+	}
+	if _, err := ExecRconCommands(context.Background(), server, commands); err != nil {
+		util.Warn(fmt.Sprintf("服务器 %s 设置轮换参数失败: %v", server, err))
+	}
+}
+
+// run 监听服务器日志，在比赛结束时按轮换设置切换地图，日志流断开后自动重连
+func (rc *RotationControllers) run(ctx context.Context, server string, gen uint64) {
+	for ctx.Err() == nil {
+		cfg, ok, err := rotationStore.Config(server)
+		if err != nil || !ok || !cfg.Enabled {
+			if err != nil {
+				util.Error(fmt.Sprintf("读取服务器 %s 轮换配置失败", server), err)
+			}
+			return
+		}
+
+		lines := make(chan string, 64)
+		done := make(chan error, 1)
+		go func() { done <- followServerLog(ctx, server, lines) }()
+
+		rc.setWatching(server, gen, true)
+		prepareRotationServer(server, cfg)
+		runRotationLoop(ctx, server, cfg, lines)
+		rc.setWatching(server, gen, false)
+
+		if err := <-done; err != nil && ctx.Err() == nil {
+			util.Debug(fmt.Sprintf("服务器 %s 日志监听中断: %v", server, err))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(rotationRetryDelay):
+		}
+	}
+}
+
+// saveWatching 保存是否正在监听日志，停止监听时丢弃进行中的投票
+func saveWatching(server string, watching bool) {
+	if _, err := rotationStore.UpdateState(server, func(state *RotationState) {
+		state.Watching = watching
+		if !watching {
+			state.Vote = nil
+		}
+	}); err != nil {
+		util.Error("保存轮换进度失败", err)
+	}
+}
+
+// runRotationLoop 处理一次日志连接中的事件，lines 关闭时返回
+func runRotationLoop(ctx context.Context, server string, cfg RotationConfig, lines <-chan string) {
+	var (
+		timer   <-chan time.Time
+		pending string // 等待切换的地图
+		vote    *RotationVoteState
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if vote != nil {
+				if m := chatRegex.FindStringSubmatch(line); m != nil {
+					recordVote(server, vote, m[2], m[3])
+				}
+				continue
+			}
+			if timer != nil || !gameOverRegex.MatchString(line) {
+				continue
+			}
+
+			state, err := rotationStore.State(server)
+			if err != nil {
+				util.Error("读取轮换进度失败", err)
+				continue
+			}
+			if state.Next == "" {
+				planNext(cfg, &state)
+			}
+			if cfg.Vote.Enabled {
+				vote = startVote(server, cfg, state)
+				timer = time.After(time.Until(vote.Deadline))
+			} else {
+				pending = state.Next
+				announce(server, fmt.Sprintf("下一张地图: %s", pending))
+				timer = time.After(time.Duration(cfg.ChangeDelay) * time.Second)
+			}
+
+		case <-timer:
+			timer = nil
+			if vote != nil {
+				pending = finishVote(server, vote)
+				vote = nil
+				timer = time.After(time.Duration(cfg.ChangeDelay) * time.Second)
+				continue
+			}
+			if pending == "" {
+				continue
+			}
+			if _, err := rotateTo(ctx, server, cfg, pending); err != nil {
+				util.Error(fmt.Sprintf("服务器 %s 轮换到 %s 失败", server, pending), err)
+			} else {
+				util.Info(fmt.Sprintf("服务器 %s 轮换到地图 %s", server, pending))
+			}
+			pending = ""
+		}
+	}
+}
+
+// announce 通过 say 向服务器内的玩家发送消息
+func announce(server, message string) {
+	if _, err := ExecRconCommand(context.Background(), server, "say "+message); err != nil {
+		util.Warn(fmt.Sprintf("服务器 %s 发送消息失败: %v", server, err))
+	}
+}
+
+// startVote 选出候选地图并开始投票，计划的下一张地图总在第一个
+func startVote(server string, cfg RotationConfig, state RotationState) *RotationVoteState {
+	vote := &RotationVoteState{
+		Votes:    make(map[string]int),
+		Deadline: time.Now().Add(time.Duration(cfg.Vote.Duration) * time.Second),
+	}
+	exclude := make(map[int]bool)
+	if i := rotationIndex(cfg, state.Next); i >= 0 {
+		vote.Options = append(vote.Options, state.Next)
+		exclude[i] = true
+	}
+	for len(vote.Options) < cfg.Vote.Options {
+		i, ok := pickRotationMap(cfg, state, exclude)
+		if !ok {
+			break
+		}
+		exclude[i] = true
+		vote.Options = append(vote.Options, cfg.Maps[i].Map)
+	}
+
+	saveVote(server, vote)
+	announce(server, fmt.Sprintf("下一张地图投票，%d 秒内在聊天中输入 !序号 投票", cfg.Vote.Duration))
+	for i, m := range vote.Options {
+		announce(server, fmt.Sprintf("!%d %s", i+1, m))
+	}
+	return vote
+}
+
+// recordVote 记录玩家投票，同一玩家以最后一次为准
+func recordVote(server string, vote *RotationVoteState, player, text string) {
+	m := voteRegex.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return
+	}
+	choice, _ := strconv.Atoi(m[1])
+	if choice < 1 || choice > len(vote.Options) {
+		return
+	}
+	vote.Votes[player] = choice
+	saveVote(server, vote)
+}
+
+// finishVote 结束投票并返回得票最多的地图，平票时取序号靠前的
+func finishVote(server string, vote *RotationVoteState) string {
+	tally := vote.Tally()
+	winner := 0
+	for i, n := range tally {
+		if n > tally[winner] {
+			winner = i
+		}
+	}
+	if len(vote.Options) == 0 {
+		return ""
+	}
+	result := vote.Options[winner]
+	announce(server, fmt.Sprintf("投票结束，下一张地图: %s（%d 票）", result, tally[winner]))
+	if _, err := rotationStore.UpdateState(server, func(state *RotationState) {
+		state.Vote = nil
+		state.Next = result
+	}); err != nil {
+		util.Error("保存轮换进度失败", err)
+	}
+	return result
+}
+
+// saveVote 保存投票进度，供接口查看
+func saveVote(server string, vote *RotationVoteState) {
+	snapshot := *vote
+	snapshot.Votes = make(map[string]int, len(vote.Votes))
+	for k, v := range vote.Votes {
+		snapshot.Votes[k] = v
+	}
+	if _, err := rotationStore.UpdateState(server, func(state *RotationState) {
+		state.Vote = &snapshot
+	}); err != nil {
+		util.Error("保存轮换进度失败", err)
+	}
+}
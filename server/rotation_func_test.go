@@ -0,0 +1,51 @@
+package server
+
+import (
+	"context"
+	"testing"
+)
+
+// 关闭轮换时地图列表可以为空，启用时至少需要一张地图
+func TestRotationValidateDisable(t *testing.T) {
+	disable := RotationConfig{Enabled: false}
+	if err := disable.Validate(context.Background()); err != nil {
+		t.Errorf("关闭轮换不应要求地图列表: %v", err)
+	}
+
+	enable := RotationConfig{Enabled: true, Mode: RotationSequential}
+	if err := enable.Validate(context.Background()); classifyError(err) != CodeInvalidRequest {
+		t.Errorf("启用轮换且地图列表为空时应返回参数错误，实际 %v", err)
+	}
+}
+
+// 被替换的控制器退出时不能清除新控制器的监听状态
+func TestRotationControllersWatching(t *testing.T) {
+	const server = "rotation-watching"
+	rc := newRotationControllers()
+	watching := func() bool {
+		t.Helper()
+		state, err := rotationStore.State(server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return state.Watching
+	}
+
+	// 服务器没有轮换配置，启动的协程会立即退出，这里直接模拟两代控制器的状态更新
+	rc.Start(server)
+	rc.Start(server)
+	rc.setWatching(server, 2, true)
+	rc.setWatching(server, 1, false)
+	if !watching() {
+		t.Fatal("旧控制器退出时清除了新控制器的监听状态")
+	}
+
+	rc.Stop(server)
+	if watching() {
+		t.Fatal("停止控制器后监听状态应被清除")
+	}
+	rc.setWatching(server, 2, true)
+	if watching() {
+		t.Error("已停止的控制器不应再修改监听状态")
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RconMapRotationRequest 获取服务器地图轮换配置与当前进度的请求参数
+type RconMapRotationRequest struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// RotationGetResponse 地图轮换配置与进度的响应
+type RotationGetResponse struct {
+	Config RotationConfig `json:"config"`
+	State  RotationState  `json:"state"`
+}
+
+// rconMapRotationGetHandler 处理获取服务器地图轮换配置与当前进度的请求
+func rconMapRotationGetHandler(c *gin.Context) {
+	var req RconMapRotationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	cfg, _, err := rotationStore.Config(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取地图轮换配置失败", err)
+		return
+	}
+	state, err := rotationStore.State(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取地图轮换进度失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RotationGetResponse{
+		Config: cfg,
+		State:  state,
+	})
+}
+
+// RconMapRotationSetRequest 设置服务器地图轮换的请求参数
+type RconMapRotationSetRequest struct {
+	Name   string         `json:"name" binding:"required"`
+	Config RotationConfig `json:"config"`
+}
+
+// RotationSetResponse 保存地图轮换配置的响应
+type RotationSetResponse struct {
+	Message string        `json:"message"`
+	State   RotationState `json:"state"`
+}
+
+// rconMapRotationSetHandler 处理设置服务器地图轮换的请求
+func rconMapRotationSetHandler(c *gin.Context) {
+	var req RconMapRotationSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := req.Config.Validate(c.Request.Context()); err != nil {
+		handleErrorResponse(c, "无效的地图轮换配置", err)
+		return
+	}
+	state, err := SetRotation(req.Name, req.Config)
+	if err != nil {
+		handleErrorResponse(c, "保存地图轮换配置失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RotationSetResponse{
+		Message: "保存地图轮换配置成功",
+		State:   state,
+	})
+
+	util.Info("保存地图轮换配置成功 服务器: " + req.Name)
+}
+
+// RconMapRotationNextRequest 立即切换到轮换中下一张地图的请求参数
+type RconMapRotationNextRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// RotationNextResponse 切换到下一张地图的响应
+type RotationNextResponse struct {
+	Message string           `json:"message"`
+	Result  *MapChangeResult `json:"result"`
+	State   RotationState    `json:"state"`
+}
+
+// rconMapRotationNextHandler 处理立即切换到轮换中下一张地图的请求
+func rconMapRotationNextHandler(c *gin.Context) {
+	var req RconMapRotationNextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	result, err := AdvanceRotation(c.Request.Context(), req.Name)
+	if err != nil {
+		handleErrorResponse(c, "切换到下一张地图失败", err)
+		return
+	}
+	state, err := rotationStore.State(req.Name)
+	if err != nil {
+		handleErrorResponse(c, "获取地图轮换进度失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, RotationNextResponse{
+		Message: "切换地图成功",
+		Result:  result,
+		State:   state,
+	})
+
+	util.Info("轮换切换地图成功 服务器: " + req.Name + " 地图: " + result.Map)
+}
@@ -7,26 +7,79 @@ import (
 // ServerSetRouter 设置 API 接口路由
 func ServerSetRouter(router *gin.Engine) {
 
-	apiGroup := router.Group("/api")
+	// 无需登录的接口
+	publicGroup := router.Group("/api")
 	{
+		publicGroup.POST("/auth/login", AuthAuditLog(), authLoginHandler)
+		publicGroup.POST("/auth/logout", AuthAuditLog(), authLogoutHandler)
+		publicGroup.GET("/openapi.json", openAPIHandler)
+	}
+
+	apiGroup := router.Group("/api", AuthRequired(), AuditLog())
+	{
+		authGroup := apiGroup.Group("/auth")
+		{
+			authGroup.GET("/me", authMeHandler)
+			authGroup.POST("/password", SessionRequired(), authPasswordHandler)
+
+			tokenGroup := authGroup.Group("/token", SessionRequired())
+			{
+				tokenGroup.GET("/list", authTokenListHandler)
+				tokenGroup.POST("/create", authTokenCreateHandler)
+				tokenGroup.POST("/revoke", authTokenRevokeHandler)
+			}
+
+			userGroup := authGroup.Group("/user", SessionRequired(), AdminRequired())
+			{
+				userGroup.GET("/list", authUserListHandler)
+				userGroup.POST("/create", authUserCreateHandler)
+				userGroup.POST("/password", authUserPasswordHandler)
+				userGroup.POST("/delete", authUserDeleteHandler)
+			}
+
+			roleGroup := authGroup.Group("/role", SessionRequired(), AdminRequired())
+			{
+				roleGroup.GET("/list", authRoleListHandler)
+				roleGroup.POST("/save", authRoleSaveHandler)
+				roleGroup.POST("/delete", authRoleDeleteHandler)
+				roleGroup.GET("/binding", authRoleBindingGetHandler)
+				roleGroup.POST("/binding", authRoleBindingSetHandler)
+			}
+		}
+
+		auditGroup := apiGroup.Group("/audit", Require(PermAuditView))
+		{
+			auditGroup.GET("/list", auditListHandler)
+			auditGroup.GET("/export", auditExportHandler)
+		}
+
 		dockerGroup := apiGroup.Group("/docker")
 		{
-			dockerGroup.Any("/ping", dockerPingHandler)
+			dockerGroup.Any("/ping", Require(PermContainerView), dockerPingHandler)
 
 			imageGroup := dockerGroup.Group("/image")
 			{
-				imageGroup.POST("/pull", dockerImagePullHandler)
-				imageGroup.GET("/pull/status", dockerImagePullStatusHandler)
+				imageGroup.POST("/pull", Require(PermContainerAdmin), dockerImagePullHandler)
+				imageGroup.GET("/pull/status", Require(PermContainerAdmin), dockerImagePullStatusHandler)
 			}
 
 			containerGroup := dockerGroup.Group("/container")
 			{
-				containerGroup.GET("/list", dockerContainerListHandler)
-				containerGroup.POST("/create", dockerContainerCreateHandler)
-				containerGroup.POST("/start", dockerContainerStartHandler)
-				containerGroup.POST("/stop", dockerContainerStopHandler)
-				containerGroup.POST("/restart", dockerContainerRestartHandler)
-				containerGroup.POST("/remove", dockerContainerRemoveHandler)
+				containerGroup.GET("/list", Require(PermContainerView), dockerContainerListHandler)
+				containerGroup.GET("/inspect", RequireServer(PermContainerView), dockerContainerInspectHandler)
+				containerGroup.POST("/create", RequireServer(PermContainerAdmin), dockerContainerCreateHandler)
+				containerGroup.POST("/update", RequireServer(PermContainerAdmin), dockerContainerUpdateHandler)
+				containerGroup.POST("/start", RequireServer(PermContainerAdmin), dockerContainerStartHandler)
+				containerGroup.POST("/stop", RequireServer(PermContainerAdmin), dockerContainerStopHandler)
+				containerGroup.POST("/restart", RequireServer(PermContainerAdmin), dockerContainerRestartHandler)
+				containerGroup.POST("/remove", RequireServer(PermContainerAdmin), dockerContainerRemoveHandler)
+
+				importGroup := containerGroup.Group("/import")
+				{
+					importGroup.GET("/candidates", Require(PermContainerAdmin), dockerContainerImportCandidatesHandler)
+					importGroup.POST("", RequireServer(PermContainerAdmin), dockerContainerImportHandler)
+					importGroup.POST("/release", RequireServer(PermContainerAdmin), dockerContainerImportReleaseHandler)
+				}
 			}
 
 		}
@@ -35,37 +88,101 @@ func ServerSetRouter(router *gin.Engine) {
 		{
 			mapGroup := infoGroup.Group("/map")
 			{
-				mapGroup.POST("/update", infoMapUpdateHandler)
-				mapGroup.GET("/list", infoMapListHandler)
+				mapGroup.POST("/update", Require(PermCatalogManage), infoMapUpdateHandler)
+				mapGroup.GET("/list", Require(PermContainerView), infoMapListHandler)
+
+				groupGroup := mapGroup.Group("/group")
+				{
+					groupGroup.GET("/list", Require(PermContainerView), infoMapGroupListHandler)
+					groupGroup.POST("/save", Require(PermCatalogManage), infoMapGroupSaveHandler)
+					groupGroup.POST("/delete", Require(PermCatalogManage), infoMapGroupDeleteHandler)
+				}
 			}
 
 			networkGroup := infoGroup.Group("/network")
 			{
-				networkGroup.GET("/addr", infoNetworkAddrHandler)
-				networkGroup.GET("/gameport", infoNetworkGamePortHandler)
-				networkGroup.GET("/tvport", infoNetworkTVPortHandler)
-				networkGroup.GET("/gamepasswd", infoNetworkGamePasswdHandler)
-				networkGroup.GET("/tvpasswd", infoNetworkTVPasswdHandler)
+				networkGroup.GET("/addr", Require(PermContainerView), infoNetworkAddrHandler)
+				networkGroup.GET("/gameport", RequireServer(PermContainerView), infoNetworkGamePortHandler)
+				networkGroup.GET("/tvport", RequireServer(PermContainerView), infoNetworkTVPortHandler)
+				networkGroup.GET("/gamepasswd", RequireServer(PermContainerView), infoNetworkGamePasswdHandler)
+				networkGroup.GET("/tvpasswd", RequireServer(PermContainerView), infoNetworkTVPasswdHandler)
 			}
 		}
-		rconGroup := apiGroup.Group("/rcon")
+		workshopGroup := apiGroup.Group("/workshop")
 		{
-			rconGroup.POST("/exec", rconExecHandler)
+			workshopGroup.GET("/list", Require(PermContainerView), workshopListHandler)
+			workshopGroup.GET("/lookup", Require(PermContainerView), workshopLookupHandler)
+			workshopGroup.POST("/save", Require(PermCatalogManage), workshopSaveHandler)
+			workshopGroup.POST("/delete", Require(PermCatalogManage), workshopDeleteHandler)
+		}
+
+		fileGroup := apiGroup.Group("/file")
+		{
+			fileGroup.GET("/list", RequireServer(PermFileRead), fileListHandler)
+			fileGroup.GET("/read", RequireServer(PermFileRead), fileReadHandler)
+			fileGroup.GET("/download", RequireServer(PermFileRead), fileDownloadHandler)
+			fileGroup.POST("/write", RequireServer(PermFileWrite), fileWriteHandler)
+			fileGroup.POST("/upload", RequireServer(PermFileWrite), fileUploadHandler)
+		}
+
+		cfgGroup := apiGroup.Group("/cfg")
+		{
+			profileGroup := cfgGroup.Group("/profile")
+			{
+				profileGroup.GET("/list", Require(PermContainerView), profileListHandler)
+				profileGroup.GET("/get", Require(PermContainerView), profileGetHandler)
+				profileGroup.GET("/diff", Require(PermContainerView), profileDiffHandler)
+				profileGroup.POST("/save", Require(PermCatalogManage), profileSaveHandler)
+				profileGroup.POST("/delete", Require(PermCatalogManage), profileDeleteHandler)
+				profileGroup.POST("/push", RequireServer(PermFileWrite), profilePushHandler)
+				profileGroup.POST("/exec", RequireServer(PermFileWrite), profileExecHandler)
+				profileGroup.GET("/autoexec", RequireServer(PermFileRead), profileAutoexecGetHandler)
+				profileGroup.POST("/autoexec", RequireServer(PermFileWrite), profileAutoexecSetHandler)
+			}
+		}
+
+		rconGroup := apiGroup.Group("/rcon", RconRateLimit())
+		{
+			rconGroup.POST("/exec", RequireServer(PermRconExec), rconExecHandler)
+			rconGroup.POST("/password/rotate", RequireServer(PermContainerAdmin), rconPasswordRotateHandler)
+			rconGroup.POST("/policy/check", RequireServer(PermRconExec), rconPolicyCheckHandler)
+			policyGroup := rconGroup.Group("/policy", SessionRequired(), AdminRequired())
+			{
+				policyGroup.GET("/list", rconPolicyListHandler)
+				policyGroup.POST("/save", rconPolicySaveHandler)
+				policyGroup.POST("/delete", rconPolicyDeleteHandler)
+			}
 			gameGroup := rconGroup.Group("/game")
 			{
-				gameGroup.GET("/status", rconGameStatusHandler)
-				gameGroup.GET("/statusjson", rconGameStatusJSONHandler)
-				gameGroup.POST("/restart", rconGameRestartHandler)
-				gameGroup.POST("/mode", rconGameConfigModeHandler)
+				gameGroup.GET("/status", RequireServer(PermContainerView), rconGameStatusHandler)
+				gameGroup.GET("/statusjson", RequireServer(PermContainerView), rconGameStatusJSONHandler)
+				gameGroup.POST("/restart", RequireServer(PermGameControl), rconGameRestartHandler)
+				gameGroup.POST("/mode", RequireServer(PermGameControl), rconGameConfigModeHandler)
+
+				presetGroup := gameGroup.Group("/preset")
+				{
+					presetGroup.GET("/list", Require(PermContainerView), rconGamePresetListHandler)
+					presetGroup.POST("/apply", RequireServer(PermGameControl), rconGamePresetApplyHandler)
+					presetGroup.POST("/save", Require(PermCatalogManage), rconGamePresetSaveHandler)
+					presetGroup.POST("/delete", Require(PermCatalogManage), rconGamePresetDeleteHandler)
+				}
+
+				driftGroup := gameGroup.Group("/drift")
+				{
+					driftGroup.GET("", RequireServer(PermContainerView), rconGameDriftHandler)
+					driftGroup.GET("/baseline", RequireServer(PermContainerView), rconGameDriftBaselineGetHandler)
+					driftGroup.POST("/baseline", RequireServer(PermGameControl), rconGameDriftBaselineSetHandler)
+					driftGroup.POST("/reapply", RequireServer(PermGameControl), rconGameDriftReapplyHandler)
+				}
 
-				warmGroup := gameGroup.Group("/warm")
+				warmGroup := gameGroup.Group("/warm", RequireServer(PermGameControl))
 				{
 					warmGroup.POST("/start", rconGameWarmStartHandler)
 					warmGroup.POST("/end", rconGameWarmEndHandler)
 					warmGroup.POST("/time", rconGameWarmTimeHandler)
 					warmGroup.POST("/pause", rconGameWarmPauseHandler)
 				}
-				configGroup := gameGroup.Group("/config")
+				configGroup := gameGroup.Group("/config", RequireServer(PermGameConfig))
 				{
 					configGroup.POST("/gamemode", rconGameConfigGameModeHandler)
 					configGroup.POST("/gametype", rconGameConfigGameTypeHandler)
@@ -82,7 +199,7 @@ func ServerSetRouter(router *gin.Engine) {
 					configGroup.POST("/limitteams", rconGameConfigLimitTeamsHandler)
 					configGroup.POST("/c4timer", rconGameConfigC4TimerHandler)
 				}
-				userGroup := gameGroup.Group("/user")
+				userGroup := gameGroup.Group("/user", RequireServer(PermGameUser))
 				{
 					userGroup.POST("/kick", rconGameUserKickHandler)
 				}
@@ -90,12 +207,47 @@ func ServerSetRouter(router *gin.Engine) {
 			}
 			mapGroup := rconGroup.Group("/map")
 			{
-				mapGroup.GET("/now", rconMapNowHandler)
-				mapGroup.POST("/change", rconMapChangeHandler)
+				mapGroup.GET("/now", RequireServer(PermContainerView), rconMapNowHandler)
+				mapGroup.POST("/change", RequireServer(PermMapChange), rconMapChangeHandler)
+				mapGroup.GET("/rotation", RequireServer(PermContainerView), rconMapRotationGetHandler)
+				mapGroup.POST("/rotation", RequireServer(PermMapChange), rconMapRotationSetHandler)
+				mapGroup.POST("/rotation/next", RequireServer(PermMapChange), rconMapRotationNextHandler)
 			}
 		}
 
 	}
+
+	// v2 接口：以服务器为资源组织路径，服务器名称统一放在路径中；v1 接口在迁移期间继续可用
+	v2Group := router.Group("/api/v2", AuthRequired(), AuditLog())
+	{
+		serversGroup := v2Group.Group("/servers")
+		{
+			serversGroup.GET("", Require(PermContainerView), serverListHandler)
+			serversGroup.POST("", RequireServer(PermContainerAdmin), serverCreateHandler)
+			serversGroup.GET("/:name", RequireServer(PermContainerView), serverGetHandler)
+			serversGroup.PATCH("/:name", RequireServer(PermContainerAdmin), serverUpdateHandler)
+			serversGroup.DELETE("/:name", RequireServer(PermContainerAdmin), serverDeleteHandler)
+
+			actionGroup := serversGroup.Group("/:name/actions", RequireServer(PermContainerAdmin))
+			{
+				actionGroup.POST("/start", serverStartHandler)
+				actionGroup.POST("/stop", serverStopHandler)
+				actionGroup.POST("/restart", serverRestartHandler)
+			}
+
+			playerGroup := serversGroup.Group("/:name/players", RconRateLimit())
+			{
+				playerGroup.GET("", RequireServer(PermContainerView), serverPlayerListHandler)
+				playerGroup.DELETE("/:id", RequireServer(PermGameUser), serverPlayerKickHandler)
+			}
+
+			cvarGroup := serversGroup.Group("/:name/cvars", RconRateLimit())
+			{
+				cvarGroup.GET("/:cvar", RequireServer(PermContainerView), serverCvarGetHandler)
+				cvarGroup.PUT("/:cvar", RequireServer(PermGameConfig), serverCvarSetHandler)
+			}
+		}
+	}
 }
 
 // WebServerSetRouter 设置 Web 静态资源路由
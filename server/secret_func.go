@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/docker"
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/docker/docker/api/types/container"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// RedactedValue 隐去的敏感值在日志与 API 中的显示
+	RedactedValue = "******"
+	// masterKeyEnv 主密钥的环境变量，优先于配置文件
+	masterKeyEnv = "CS2PANEL_MASTER_KEY"
+	// masterKeyFile 未配置主密钥时生成的密钥文件
+	masterKeyFile = "master.key"
+	// secretFile 服务器密钥在面板数据目录下的文件名，值使用主密钥加密
+	secretFile = "server_secrets.json"
+	// secretCipherPrefix 加密值的格式版本前缀
+	secretCipherPrefix = "v1:"
+	// rconPasswordLength 生成的 RCON 密码长度（随机字节数）
+	rconPasswordLength = 18
+)
+
+// secretEnvKeys 容器环境变量中的敏感项
+var secretEnvKeys = map[string]bool{
+	"CS2_RCONPW":  true,
+	"CS2_PW":      true,
+	"CS2_TV_PW":   true,
+	"SRCDS_TOKEN": true,
+}
+
+// secretCommands 参数为密码的控制台命令，日志与审计中隐去参数
+var secretCommands = map[string]bool{
+	"rcon_password": true,
+	"sv_password":   true,
+	"tv_password":   true,
+}
+
+// ErrSecretDecrypt 密文无法用当前主密钥解密
+var ErrSecretDecrypt = errors.New("解密失败，主密钥可能已变更")
+
+// redactEnv 返回隐去敏感值的环境变量副本，空值保持为空以便区分是否设置
+func redactEnv(env map[string]string) map[string]string {
+	result := make(map[string]string, len(env))
+	for k, v := range env {
+		if secretEnvKeys[k] && v != "" {
+			v = RedactedValue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// redactCommand 隐去密码类命令的参数，多行脚本与 ; 连接的命令逐条检查
+// 有命令被隐去时按拆分后的命令以 ; 重新连接，否则原样返回
+func redactCommand(command string) string {
+	commands := SplitRconScript(command)
+	redacted := false
+	for i, cmd := range commands {
+		if name, args := splitRconCommand(cmd); args != "" && secretCommands[name] {
+			commands[i] = name + " " + RedactedValue
+			redacted = true
+		}
+	}
+	if !redacted {
+		return command
+	}
+	return strings.Join(commands, "; ")
+}
+
+// redactSecret 非空时返回隐去后的值
+func redactSecret(v string) string {
+	if v == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+var (
+	masterKeyOnce sync.Once
+	masterKeyData []byte
+	masterKeyErr  error
+)
+
+// masterKey 加密面板数据中密钥所用的 AES-256 密钥
+// 依次使用环境变量 CS2PANEL_MASTER_KEY、配置 server.master_key，都未设置时在面板数据目录生成 master.key
+func masterKey() ([]byte, error) {
+	masterKeyOnce.Do(func() {
+		source := util.DefaultIfEmpty(os.Getenv(masterKeyEnv), config.GlobalConfig.Server.MasterKey)
+		if source == "" {
+			source, masterKeyErr = loadOrCreateMasterKeyFile()
+			if masterKeyErr != nil {
+				return
+			}
+		}
+		sum := sha256.Sum256([]byte(source))
+		masterKeyData = sum[:]
+	})
+	return masterKeyData, masterKeyErr
+}
+
+// loadOrCreateMasterKeyFile 读取或生成面板数据目录下的主密钥文件
+func loadOrCreateMasterKeyFile() (string, error) {
+	path, err := panelDataPath(masterKeyFile)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("读取主密钥失败: %w", err)
+	}
+
+	key, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	if err := writePanelFile([]byte(key+"\n"), 0600, masterKeyFile); err != nil {
+		return "", err
+	}
+	util.Warn("未配置主密钥，已生成 " + path + "，请妥善备份或改用 " + masterKeyEnv + " 环境变量")
+	return key, nil
+}
+
+// encryptSecret 使用主密钥加密
+func encryptSecret(plain string) (string, error) {
+	key, err := masterKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return secretCipherPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret 使用主密钥解密
+func decryptSecret(enc string) (string, error) {
+	key, err := masterKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(enc, secretCipherPrefix))
+	if err != nil || !strings.HasPrefix(enc, secretCipherPrefix) {
+		return "", ErrSecretDecrypt
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", ErrSecretDecrypt
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrSecretDecrypt
+	}
+	return string(plain), nil
+}
+
+// ServerSecret 面板保存的服务器密钥，密码以密文保存
+type ServerSecret struct {
+	RconPassword string    `json:"rcon_password"` // 密文
+	RotatedAt    time.Time `json:"rotated_at"`
+	PendingEnv   bool      `json:"pending_env"` // 运行中的服务器已通过 RCON 使用新密码，容器环境变量尚未更新
+}
+
+// SecretStore 管理服务器密钥：服务器名称 -> 密钥
+type SecretStore struct {
+	mu sync.Mutex
+}
+
+// 全局服务器密钥存储
+var secretStore = &SecretStore{}
+
+// load 读取所有服务器密钥，调用方需持有锁
+func (s *SecretStore) load() (map[string]ServerSecret, error) {
+	secrets := make(map[string]ServerSecret)
+	if _, err := loadPanelJSON(&secrets, secretFile); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// save 保存所有服务器密钥，调用方需持有锁
+func (s *SecretStore) save(secrets map[string]ServerSecret) error {
+	data, err := marshalPanelJSON(secrets)
+	if err != nil {
+		return err
+	}
+	return writePanelFile(data, 0600, secretFile)
+}
+
+// Get 获取服务器密钥
+func (s *SecretStore) Get(server string) (ServerSecret, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return ServerSecret{}, false, err
+	}
+	secret, ok := secrets[server]
+	return secret, ok, nil
+}
+
+// SetRconPassword 加密保存服务器的 RCON 密码
+func (s *SecretStore) SetRconPassword(server, password string, pendingEnv bool) error {
+	enc, err := encryptSecret(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[server] = ServerSecret{RconPassword: enc, RotatedAt: time.Now(), PendingEnv: pendingEnv}
+	return s.save(secrets)
+}
+
+// Delete 删除服务器密钥，删除服务器时调用
+func (s *SecretStore) Delete(server string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[server]; !ok {
+		return nil
+	}
+	delete(secrets, server)
+	return s.save(secrets)
+}
+
+// rconPassword 连接服务器所用的 RCON 密码
+// 通常以容器环境变量为准；密码已在运行中轮换但环境变量尚未更新时使用面板保存的新密码，
+// 若容器在轮换之后被面板外部重启，服务器已恢复为环境变量中的旧密码
+func rconPassword(name string) (string, error) {
+	secret, ok, err := secretStore.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if ok && secret.PendingEnv {
+		ctr, err := ResolveContainer(context.Background(), name)
+		if err != nil {
+			return "", err
+		}
+		info, err := docker.Cli.ContainerInspect(context.Background(), ctr.ID)
+		if err != nil {
+			return "", err
+		}
+		startedAt, _ := time.Parse(time.RFC3339Nano, info.State.StartedAt)
+		if startedAt.Before(secret.RotatedAt) {
+			return decryptSecret(secret.RconPassword)
+		}
+	}
+	return GetEnvValue(name, "CS2_RCONPW")
+}
+
+// RconRotateResult RCON 密码轮换结果
+type RconRotateResult struct {
+	Live       bool   `json:"live"`        // 是否已通过 RCON 在运行中的服务器上生效
+	Recreated  bool   `json:"recreated"`   // 是否已重建容器更新环境变量
+	PendingEnv bool   `json:"pending_env"` // 环境变量是否等待下次通过面板启动或重启时更新
+	Password   string `json:"password,omitempty"`
+}
+
+// RotateRconPassword 为服务器设置新的 RCON 密码，password 为空时随机生成
+// 已停止的服务器或 restart 为 true 时直接重建容器更新环境变量；
+// 运行中的服务器默认先通过 RCON 修改 rcon_password 立即生效，环境变量在下次通过面板启动或重启时更新，避免中断对局
+func RotateRconPassword(ctx context.Context, server, password string, restart bool) (*RconRotateResult, error) {
+	if password == "" {
+		var err error
+		if password, err = randomToken(rconPasswordLength); err != nil {
+			return nil, err
+		}
+	}
+	if strings.ContainsAny(password, " \t\r\n\";") {
+		return nil, invalidf("RCON 密码不能包含空白、引号或分号")
+	}
+
+	ctr, err := ResolveContainer(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	result := &RconRotateResult{Password: password}
+
+	if ctr.State == "running" && !restart {
+		if _, err := ExecRconCommand(withoutRconPolicy(ctx), server, "rcon_password "+password); err != nil {
+			return nil, fmt.Errorf("在运行中的服务器上修改 RCON 密码失败: %w", err)
+		}
+		rconPool.Remove(server)
+		if err := secretStore.SetRconPassword(server, password, true); err != nil {
+			return nil, err
+		}
+		result.Live = true
+		result.PendingEnv = true
+		return result, nil
+	}
+
+	if err := recreateWithRconPassword(ctx, server, ctr.ID, password); err != nil {
+		return nil, err
+	}
+	result.Recreated = true
+	return result, nil
+}
+
+// recreateWithRconPassword 重建容器写入新的 RCON 密码并保存
+func recreateWithRconPassword(ctx context.Context, server, id, password string) error {
+	_, err := RecreateContainer(ctx, id, func(cfg *container.Config, _ *container.HostConfig) error {
+		cfg.Env = MergeEnv(cfg.Env, map[string]string{"CS2_RCONPW": password})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rconPool.Remove(server)
+	return secretStore.SetRconPassword(server, password, false)
+}
+
+// syncPendingSecrets 启动或重启服务器前把已轮换的 RCON 密码写入容器环境变量，返回是否重建了容器
+// 重建会保持容器原来的运行状态，因此重启时重建即完成了重启
+func syncPendingSecrets(ctx context.Context, server string) (bool, error) {
+	secret, ok, err := secretStore.Get(server)
+	if err != nil || !ok || !secret.PendingEnv {
+		return false, err
+	}
+	password, err := decryptSecret(secret.RconPassword)
+	if err != nil {
+		return false, err
+	}
+	ctr, err := ResolveContainer(ctx, server)
+	if err != nil {
+		return false, err
+	}
+	if err := recreateWithRconPassword(ctx, server, ctr.ID, password); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// revealSecrets 判断请求是否要求显示明文密码（reveal=true），需要服务器上的 secret.view 权限，
+// 显示时写入审计日志；没有权限时返回 403，ok 为 false
+func revealSecrets(c *gin.Context, server string) (reveal bool, ok bool) {
+	if c.Query("reveal") != "true" {
+		return false, true
+	}
+	if !serverAllowed(c, PermSecretView, server) {
+		abortForbidden(c, PermSecretView, []string{server})
+		return false, false
+	}
+
+	u, _ := currentUser(c)
+	record := AuditRecord{
+		Time: time.Now(), Actor: u.Username, IP: c.ClientIP(), Method: c.Request.Method,
+		Action: strings.TrimPrefix(c.FullPath(), "/api"), Servers: []string{server}, Outcome: AuditOutcomeSuccess,
+	}
+	if t, ok := currentToken(c); ok {
+		record.Token = t.Name
+	}
+	appendAudit(record)
+	return true, true
+}
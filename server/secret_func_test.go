@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// 服务器详情默认隐去密码类环境变量，reveal=true 时返回明文
+func TestInspectRedactsSecrets(t *testing.T) {
+	router := testRouter()
+	session := adminSession(t, router)
+	testDocker.setContainers(panelContainer("c1", "one", "running"))
+	testDocker.setEnv("c1", "CS2_RCONPW=rcon-secret", "CS2_PW=join-secret", "CS2_SERVERNAME=one")
+
+	for _, tc := range []struct {
+		path   string
+		secret bool
+	}{
+		{"/api/docker/container/inspect?name=one", false},
+		{"/api/v2/servers/one", false},
+		{"/api/v2/servers/one?reveal=true", true},
+	} {
+		w := serveJSON(router, http.MethodGet, tc.path, nil, session)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: 状态码 %d: %s", tc.path, w.Code, w.Body.String())
+		}
+		var resp ContainerInspectResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		for key, plain := range map[string]string{"CS2_RCONPW": "rcon-secret", "CS2_PW": "join-secret"} {
+			if shown := resp.Env[key] == plain; shown != tc.secret {
+				t.Errorf("%s: %s = %q", tc.path, key, resp.Env[key])
+			}
+		}
+		if resp.Env["CS2_SERVERNAME"] != "one" {
+			t.Errorf("%s: 非密码类变量不应隐去: %q", tc.path, resp.Env["CS2_SERVERNAME"])
+		}
+	}
+}
+
+func TestRedactCommand(t *testing.T) {
+	cases := []struct {
+		command, want string
+	}{
+		{"status", "status"},
+		{"sv_password", "sv_password"},
+		{"sv_password hunter2", "sv_password ******"},
+		{`"RCON_PASSWORD" "hunter2"`, "rcon_password ******"},
+		{"say hi; sv_password hunter2", "say hi; sv_password ******"},
+		{"say hi\ntv_password hunter2\nstatus", "say hi; tv_password ******; status"},
+		{`say "sv_password hunter2"`, `say "sv_password hunter2"`},
+	}
+	for _, tc := range cases {
+		if got := redactCommand(tc.command); got != tc.want {
+			t.Errorf("redactCommand(%q) = %q，应为 %q", tc.command, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RconPasswordRotateRequest 轮换服务器 RCON 密码的请求参数
+type RconPasswordRotateRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Password string `json:"password"`
+	Restart  bool   `json:"restart"` // 运行中的服务器是否立即重建容器，默认只通过 RCON 生效并在下次重启时更新环境变量
+}
+
+// RconPasswordRotateResponse 轮换 RCON 密码的响应
+type RconPasswordRotateResponse struct {
+	Message string            `json:"message"`
+	Result  *RconRotateResult `json:"result"`
+}
+
+// rconPasswordRotateHandler 处理轮换服务器 RCON 密码的请求
+// 未提供 password 时随机生成；新密码只返回给拥有 secret.view 权限的调用者
+func rconPasswordRotateHandler(c *gin.Context) {
+	var req RconPasswordRotateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	result, err := RotateRconPassword(c.Request.Context(), req.Name, req.Password, req.Restart)
+	if err != nil {
+		handleErrorResponse(c, "轮换 RCON 密码失败", err)
+		return
+	}
+	if !serverAllowed(c, PermSecretView, req.Name) {
+		result.Password = ""
+	}
+
+	c.JSON(http.StatusOK, RconPasswordRotateResponse{
+		Message: "轮换 RCON 密码成功",
+		Result:  result,
+	})
+
+	util.Info("轮换 RCON 密码成功 服务器: " + req.Name)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// v2 接口以服务器为资源组织路径，服务器名称统一放在路径中；v1 接口的处理函数与 v2 共用以下逻辑
+
+const (
+	// defaultPageLimit 列表接口默认每页返回的条数
+	defaultPageLimit = 50
+	// maxPageLimit 列表接口每页最多返回的条数
+	maxPageLimit = 500
+)
+
+// PageRequest 列表接口的分页参数
+type PageRequest struct {
+	Limit  int `form:"limit" binding:"omitempty,min=1,max=500"` // 每页条数，默认 50
+	Offset int `form:"offset" binding:"omitempty,min=0"`        // 跳过的条数
+}
+
+// Page 列表响应中的分页信息
+type Page struct {
+	Total  int `json:"total"` // 全部条数
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// paginate 按分页参数截取列表，结果不为 nil
+func paginate[T any](items []T, req PageRequest) ([]T, Page) {
+	limit := min(util.DefaultIfEmpty(req.Limit, defaultPageLimit), maxPageLimit)
+	start := min(req.Offset, len(items))
+	end := min(start+limit, len(items))
+	page := make([]T, 0, end-start)
+	return append(page, items[start:end]...), Page{Total: len(items), Limit: limit, Offset: req.Offset}
+}
+
+// gameConfigCvars 拥有 game.config 权限即可修改、拥有 container.view 权限即可读取的 cvar，与 rcon/game/config 下的接口对应
+// 其余 cvar 需要 rcon.exec 权限：读取 cvar 是把名称作为控制台命令发送，名称也可能是 mp_endmatch 等命令
+var gameConfigCvars = map[string]bool{
+	"game_mode":            true,
+	"game_type":            true,
+	"mp_maxrounds":         true,
+	"mp_timelimit":         true,
+	"mp_roundtime":         true,
+	"mp_roundtime_defuse":  true,
+	"mp_roundtime_hostage": true,
+	"mp_freezetime":        true,
+	"mp_buytime":           true,
+	"mp_buy_anywhere":      true,
+	"mp_startmoney":        true,
+	"mp_maxmoney":          true,
+	"mp_autoteambalance":   true,
+	"mp_autokick":          true,
+	"mp_limitteams":        true,
+	"mp_c4timer":           true,
+}
+
+// checkCvarRead 检查当前用户能否读取服务器上的 cvar，拒绝时已写入响应
+func checkCvarRead(c *gin.Context, server, cvar string) bool {
+	if gameConfigCvars[strings.ToLower(cvar)] {
+		return true
+	}
+	return requireServers(c, PermRconExec, []string{server})
+}
+
+// checkCvarWrite 检查当前用户能否修改服务器上的 cvar，拒绝时已写入响应
+// 密码类 cvar 不允许通过 cvar 接口修改，RCON 密码需要通过轮换接口修改才能同步到面板
+func checkCvarWrite(c *gin.Context, server, cvar string) bool {
+	if secretCommands[strings.ToLower(cvar)] {
+		handleErrorResponse(c, "修改 cvar 失败", invalidf("不能通过此接口修改 %s", cvar))
+		return false
+	}
+	if gameConfigCvars[strings.ToLower(cvar)] {
+		return true
+	}
+	return requireServers(c, PermRconExec, []string{server})
+}
+
+// serverActionError 单个服务器的启停结果失败时返回对应的错误，否则返回 nil
+func serverActionError(result BatchResult) error {
+	if result.Status != BatchStatusError {
+		return nil
+	}
+	return result.err
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ServerListResponse 服务器列表的响应
+type ServerListResponse struct {
+	Servers []PanelContainer `json:"servers"`
+	Page
+}
+
+// serverListHandler 分页列出当前用户有权查看的服务器
+func serverListHandler(c *gin.Context) {
+	var req PageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	containers, err := visibleContainers(c)
+	if err != nil {
+		handleErrorResponse(c, "获取服务器列表失败", err)
+		return
+	}
+
+	servers, page := paginate(containers, req)
+	c.JSON(http.StatusOK, ServerListResponse{
+		Servers: servers,
+		Page:    page,
+	})
+}
+
+// serverCreateHandler 创建服务器，创建后保持停止
+func serverCreateHandler(c *gin.Context) {
+	var req ContainerCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	resp, err := createServer(context.Background(), req)
+	if err != nil {
+		handleErrorResponse(c, "创建服务器失败", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ServerRevealRequest 读取服务器详情或 cvar 的请求参数
+type ServerRevealRequest struct {
+	Reveal bool `form:"reveal"` // 是否返回密码的明文，需要 secret.view 权限
+}
+
+// serverGetHandler 获取服务器详情
+func serverGetHandler(c *gin.Context) {
+	var req ServerRevealRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	name := c.Param("name")
+
+	reveal, ok := revealSecrets(c, name)
+	if !ok {
+		return
+	}
+	resp, err := inspectServer(context.Background(), name, reveal)
+	if err != nil {
+		handleErrorResponse(c, "获取服务器详情失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ServerUpdateRequest 修改服务器设置的请求参数：env 与 resources 至少提供其一
+type ServerUpdateRequest struct {
+	Env       map[string]string   `json:"env"`       // 需要修改的环境变量，例如 {"CS2_MAXPLAYERS": "12"}
+	Resources *ContainerResources `json:"resources"` // 需要修改的资源限制与重启策略
+}
+
+// serverUpdateHandler 修改服务器设置，通过重建容器生效
+func serverUpdateHandler(c *gin.Context) {
+	var req ServerUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	name := c.Param("name")
+
+	id, err := updateServer(context.Background(), name, req.Env, req.Resources)
+	if err != nil {
+		handleErrorResponse(c, fmt.Sprintf("更新服务器 %s 失败", name), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ContainerResponse{
+		Message:     "服务器更新成功",
+		ContainerID: id,
+	})
+}
+
+// ServerActionResponse 对单个服务器执行启动、停止、重启或删除的响应
+type ServerActionResponse struct {
+	Message   string   `json:"message"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`              // success 或 already（已处于目标状态）
+	Responses []string `json:"responses,omitempty"` // 启动后执行命令的响应
+}
+
+// respondServerAction 返回单个服务器的操作结果，失败时按错误类型返回对应的状态码
+func respondServerAction(c *gin.Context, result BatchResult, message, failMessage string) {
+	if err := serverActionError(result); err != nil {
+		handleErrorResponse(c, failMessage, err)
+		return
+	}
+	c.JSON(http.StatusOK, ServerActionResponse{
+		Message:   message,
+		Name:      result.Name,
+		Status:    result.Status,
+		Responses: result.Responses,
+	})
+}
+
+// serverDeleteHandler 删除服务器，运行中时先停止，服务器不存在时视为已删除
+func serverDeleteHandler(c *gin.Context) {
+	respondServerAction(c, removeServer(c.Param("name")), "服务器删除成功", "删除服务器失败")
+}
+
+// ServerStartRequest 启动服务器的请求参数，请求体可以为空
+type ServerStartRequest struct {
+	Cmds []string `json:"cmds"` // 启动后执行的命令
+}
+
+// serverStartHandler 启动服务器，并可选地执行命令
+func serverStartHandler(c *gin.Context) {
+	var req ServerStartRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			handleErrorResponse(c, "无效的请求参数", badRequest(err))
+			return
+		}
+	}
+
+	name := c.Param("name")
+	// 启动后执行的命令是任意 RCON 命令，还需要 rcon.exec 权限
+	if len(req.Cmds) > 0 && !requireServers(c, PermRconExec, []string{name}) {
+		return
+	}
+
+	result := startServer(c.Request.Context(), name, req.Cmds)
+	respondServerAction(c, result, "服务器启动成功", "启动服务器失败")
+}
+
+// serverStopHandler 停止服务器
+func serverStopHandler(c *gin.Context) {
+	respondServerAction(c, stopServer(c.Param("name")), "服务器停止成功", "停止服务器失败")
+}
+
+// serverRestartHandler 重启服务器
+func serverRestartHandler(c *gin.Context) {
+	respondServerAction(c, restartServer(c.Param("name")), "服务器重启成功", "重启服务器失败")
+}
+
+// ServerPlayerListResponse 在线玩家列表的响应
+type ServerPlayerListResponse struct {
+	Players []PlayerInfo `json:"players"`
+	Page
+}
+
+// serverPlayerListHandler 分页列出服务器上的玩家
+func serverPlayerListHandler(c *gin.Context) {
+	var req PageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	status, err := GetServerStatus(c.Param("name"))
+	if err != nil {
+		handleErrorResponse(c, "获取玩家列表失败", err)
+		return
+	}
+
+	players, page := paginate(status.PlayerList, req)
+	c.JSON(http.StatusOK, ServerPlayerListResponse{
+		Players: players,
+		Page:    page,
+	})
+}
+
+// serverPlayerKickHandler 按玩家 ID（status 中的 id）踢出玩家
+func serverPlayerKickHandler(c *gin.Context) {
+	name := c.Param("name")
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 0 {
+		handleErrorResponse(c, "无效的请求参数", invalidf("无效的玩家 ID %q", c.Param("id")))
+		return
+	}
+
+	command := "kickid " + strconv.Itoa(id)
+	response, err := ExecRconCommand(c.Request.Context(), name, command)
+	if err != nil {
+		handleErrorResponse(c, "踢出玩家失败", err)
+		return
+	}
+	util.Info("执行命令成功 服务器: " + name + " 命令: " + command + " 响应: " + response)
+
+	c.JSON(http.StatusOK, RconResponse{
+		Message:  "踢出玩家成功",
+		Response: response,
+	})
+}
+
+// ServerCvarResponse 读取或修改 cvar 的响应
+type ServerCvarResponse struct {
+	Cvar  string `json:"cvar"`
+	Value string `json:"value"` // 密码类 cvar 默认隐去
+}
+
+// serverCvarGetHandler 读取服务器上 cvar 的当前值
+// rcon/game/config 对应的 cvar 需要 container.view 权限，其余 cvar 还需要 rcon.exec 权限
+func serverCvarGetHandler(c *gin.Context) {
+	var req ServerRevealRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	name, cvar := c.Param("name"), c.Param("cvar")
+	if err := validateCvars(map[string]string{cvar: ""}); err != nil {
+		handleErrorResponse(c, "无效的请求参数", err)
+		return
+	}
+	if !checkCvarRead(c, name, cvar) {
+		return
+	}
+
+	reveal, ok := revealSecrets(c, name)
+	if !ok {
+		return
+	}
+	response, err := ExecRconCommand(c.Request.Context(), name, cvar)
+	if err != nil {
+		handleErrorResponse(c, "读取 cvar 失败", err)
+		return
+	}
+	value, err := ParseCvarValue(cvar, response)
+	if err != nil {
+		handleErrorResponse(c, "读取 cvar 失败", upstreamf("解析响应失败: %w", err))
+		return
+	}
+	if !reveal && secretCommands[strings.ToLower(cvar)] {
+		value = redactSecret(value)
+	}
+
+	c.JSON(http.StatusOK, ServerCvarResponse{
+		Cvar:  cvar,
+		Value: value,
+	})
+}
+
+// ServerCvarSetRequest 修改 cvar 的请求参数
+type ServerCvarSetRequest struct {
+	Value *string `json:"value" binding:"required"` // 新的值，可以为空字符串
+}
+
+// serverCvarSetHandler 修改服务器上 cvar 的值
+// rcon/game/config 对应的 cvar 需要 game.config 权限，其余 cvar 还需要 rcon.exec 权限
+func serverCvarSetHandler(c *gin.Context) {
+	var req ServerCvarSetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+	name, cvar := c.Param("name"), c.Param("cvar")
+	if err := validateCvars(map[string]string{cvar: *req.Value}); err != nil {
+		handleErrorResponse(c, "无效的请求参数", err)
+		return
+	}
+	if !checkCvarWrite(c, name, cvar) {
+		return
+	}
+
+	command := CvarCommand(cvar, *req.Value)
+	response, err := ExecRconCommand(c.Request.Context(), name, command)
+	if err != nil {
+		handleErrorResponse(c, "修改 cvar 失败", err)
+		return
+	}
+	util.Info("执行命令成功 服务器: " + name + " 命令: " + command + " 响应: " + response)
+
+	c.JSON(http.StatusOK, ServerCvarResponse{
+		Cvar:  cvar,
+		Value: *req.Value,
+	})
+}
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// tokenFile API 令牌在面板数据目录下的文件名，只保存令牌的哈希
+	tokenFile = "api_tokens.json"
+	// tokenPrefix 令牌前缀，便于在日志与密钥扫描中识别
+	tokenPrefix = "cs2p_"
+	// tokenTouchInterval 最近使用时间的落盘间隔，避免每个请求都写文件
+	tokenTouchInterval = time.Minute
+	// defaultTokenTTLDays 未指定有效期时令牌的有效天数
+	defaultTokenTTLDays = 90
+	// contextTokenKey gin.Context 中保存当前 API 令牌的键
+	contextTokenKey = "api_token"
+)
+
+// ErrTokenNotFound 令牌不存在
+var ErrTokenNotFound = errors.New("令牌不存在")
+
+// APIToken 供脚本与机器人使用的长期令牌，权限为所属用户权限与令牌范围的交集
+type APIToken struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Owner       string    `json:"owner"`       // 所属用户
+	Hash        string    `json:"hash"`        // 令牌的 SHA-256
+	Hint        string    `json:"hint"`        // 令牌末尾几位，用于辨认
+	Permissions []string  `json:"permissions"` // 权限范围，* 表示与所属用户相同
+	Servers     []string  `json:"servers"`     // 服务器白名单（支持通配符），为空表示不限制
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+// APITokenInfo 返回给客户端的令牌信息，不含哈希
+type APITokenInfo struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Owner       string     `json:"owner"`
+	Hint        string     `json:"hint"`
+	Permissions []string   `json:"permissions"`
+	Servers     []string   `json:"servers"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	Expired     bool       `json:"expired"`
+}
+
+// Info 令牌的公开信息
+func (t APIToken) Info() APITokenInfo {
+	info := APITokenInfo{
+		ID: t.ID, Name: t.Name, Owner: t.Owner, Hint: t.Hint, Permissions: t.Permissions, Servers: t.Servers,
+		CreatedAt: t.CreatedAt, ExpiresAt: t.ExpiresAt, Expired: time.Now().After(t.ExpiresAt),
+	}
+	if !t.LastUsedAt.IsZero() {
+		lastUsed := t.LastUsedAt
+		info.LastUsedAt = &lastUsed
+	}
+	return info
+}
+
+// Allows 令牌范围是否包含服务器上的权限；server 为空时不检查白名单
+func (t APIToken) Allows(perm, server string) bool {
+	if !slices.Contains(t.Permissions, PermAll) && !slices.Contains(t.Permissions, perm) {
+		return false
+	}
+	if server == "" || len(t.Servers) == 0 {
+		return true
+	}
+	for _, pattern := range t.Servers {
+		if ok, _ := path.Match(pattern, server); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenStore 管理 API 令牌：ID -> 令牌，内存中缓存并持久化到面板数据目录
+type TokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]APIToken
+	synced map[string]time.Time // 最近使用时间上次落盘时的值
+}
+
+// 全局令牌存储
+var tokenStore = &TokenStore{}
+
+// loadLocked 首次使用时从文件加载令牌，调用方需持有锁
+func (s *TokenStore) loadLocked() error {
+	if s.tokens != nil {
+		return nil
+	}
+	tokens := make(map[string]APIToken)
+	if _, err := loadPanelJSON(&tokens, tokenFile); err != nil {
+		return err
+	}
+	s.tokens = tokens
+	s.synced = make(map[string]time.Time)
+	for id, t := range tokens {
+		s.synced[id] = t.LastUsedAt
+	}
+	return nil
+}
+
+// saveLocked 保存所有令牌，调用方需持有锁
+func (s *TokenStore) saveLocked() error {
+	data, err := marshalPanelJSON(s.tokens)
+	if err != nil {
+		return err
+	}
+	if err := writePanelFile(data, 0600, tokenFile); err != nil {
+		return err
+	}
+	for id, t := range s.tokens {
+		s.synced[id] = t.LastUsedAt
+	}
+	return nil
+}
+
+// List 列出令牌，owner 为空时列出所有用户的令牌
+func (s *TokenStore) List(owner string) ([]APITokenInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return nil, err
+	}
+	result := make([]APITokenInfo, 0, len(s.tokens))
+	for _, t := range s.tokens {
+		if owner == "" || t.Owner == owner {
+			result = append(result, t.Info())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	return result, nil
+}
+
+// Get 按 ID 获取令牌
+func (s *TokenStore) Get(id string) (APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return APIToken{}, err
+	}
+	t, ok := s.tokens[id]
+	if !ok {
+		return APIToken{}, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	return t, nil
+}
+
+// Create 创建令牌，返回只出现这一次的明文令牌
+func (s *TokenStore) Create(owner, name string, permissions, servers []string, ttl time.Duration) (string, APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", APIToken{}, invalidf("令牌名称不能为空")
+	}
+	if len(permissions) == 0 {
+		return "", APIToken{}, invalidf("令牌至少需要一个权限")
+	}
+	for _, perm := range permissions {
+		if perm != PermAll && !slices.Contains(AllPermissions, perm) {
+			return "", APIToken{}, invalidf("未知的权限 %q", perm)
+		}
+	}
+	for _, pattern := range servers {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", APIToken{}, invalidf("无效的服务器通配符 %q", pattern)
+		}
+	}
+
+	id, err := randomToken(9)
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	token := tokenPrefix + secret
+
+	now := time.Now()
+	t := APIToken{
+		ID: id, Name: name, Owner: owner, Hash: hashToken(token), Hint: token[len(token)-4:],
+		Permissions: permissions, Servers: servers, CreatedAt: now, ExpiresAt: now.Add(ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return "", APIToken{}, err
+	}
+	s.tokens[id] = t
+	return token, t, s.saveLocked()
+}
+
+// Revoke 吊销令牌
+func (s *TokenStore) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	if _, ok := s.tokens[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	delete(s.tokens, id)
+	return s.saveLocked()
+}
+
+// DeleteOwner 删除用户的所有令牌，删除用户时调用
+func (s *TokenStore) DeleteOwner(owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return err
+	}
+	for id, t := range s.tokens {
+		if t.Owner == owner {
+			delete(s.tokens, id)
+		}
+	}
+	return s.saveLocked()
+}
+
+// Authenticate 查找未过期的令牌并记录使用时间
+func (s *TokenStore) Authenticate(token string) (APIToken, bool) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return APIToken{}, false
+	}
+	hash := hashToken(token)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadLocked(); err != nil {
+		return APIToken{}, false
+	}
+	for id, t := range s.tokens {
+		if t.Hash != hash {
+			continue
+		}
+		now := time.Now()
+		if now.After(t.ExpiresAt) {
+			return APIToken{}, false
+		}
+		t.LastUsedAt = now
+		s.tokens[id] = t
+		if now.Sub(s.synced[id]) >= tokenTouchInterval {
+			// 最近使用时间只用于展示，落盘失败不影响本次请求
+			_ = s.saveLocked()
+		}
+		return t, true
+	}
+	return APIToken{}, false
+}
+
+// bearerToken 从 Authorization 头读取 Bearer 令牌
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// currentToken 获取当前请求使用的 API 令牌，使用会话登录时返回 false
+func currentToken(c *gin.Context) (APIToken, bool) {
+	v, ok := c.Get(contextTokenKey)
+	if !ok {
+		return APIToken{}, false
+	}
+	t, ok := v.(APIToken)
+	return t, ok
+}
+
+// SessionRequired 只允许通过会话登录访问的中间件，用于账户与令牌管理，防止令牌自行扩权
+func SessionRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := currentToken(c); ok {
+			abortWithError(c, "该接口不允许使用 API 令牌访问", ErrForbidden, nil)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthTokenListRequest 获取 API 令牌列表的请求参数
+type AuthTokenListRequest struct {
+	All bool `form:"all"`
+}
+
+// AuthTokenListResponse API 令牌列表的响应
+type AuthTokenListResponse struct {
+	Tokens []APITokenInfo `json:"tokens"`
+}
+
+// authTokenListHandler 处理获取 API 令牌列表的请求，管理员可通过 all=true 查看所有用户的令牌
+func authTokenListHandler(c *gin.Context) {
+	var req AuthTokenListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	u, _ := currentUser(c)
+	owner := u.Username
+	if req.All && u.Admin {
+		owner = ""
+	}
+	tokens, err := tokenStore.List(owner)
+	if err != nil {
+		handleErrorResponse(c, "获取令牌列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthTokenListResponse{
+		Tokens: tokens,
+	})
+}
+
+// AuthTokenCreateRequest 创建 API 令牌的请求参数
+type AuthTokenCreateRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Permissions []string `json:"permissions" binding:"required"`
+	Servers     []string `json:"servers"`
+	ExpiresIn   int      `json:"expires_in" binding:"omitempty,min=1,max=3650"` // 有效天数，默认 90 天
+}
+
+// AuthTokenCreateResponse 创建 API 令牌的响应
+type AuthTokenCreateResponse struct {
+	Message string       `json:"message"`
+	Token   string       `json:"token"` // 明文令牌，只返回这一次
+	Info    APITokenInfo `json:"info"`
+}
+
+// authTokenCreateHandler 处理创建 API 令牌的请求，明文令牌只在响应中返回这一次
+func authTokenCreateHandler(c *gin.Context) {
+	var req AuthTokenCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	u, _ := currentUser(c)
+	days := util.DefaultIfEmpty(req.ExpiresIn, defaultTokenTTLDays)
+	token, t, err := tokenStore.Create(u.Username, req.Name, req.Permissions, req.Servers, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		handleErrorResponse(c, "创建令牌失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthTokenCreateResponse{
+		Message: "创建令牌成功，请立即保存，令牌不会再次显示",
+		Token:   token,
+		Info:    t.Info(),
+	})
+
+	util.Info("创建令牌成功 用户: " + u.Username + " 令牌: " + t.Name + " ID: " + t.ID)
+}
+
+// AuthTokenRevokeRequest 吊销 API 令牌的请求参数
+type AuthTokenRevokeRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+// authTokenRevokeHandler 处理吊销 API 令牌的请求，只能吊销自己的令牌，管理员可吊销任意令牌
+func authTokenRevokeHandler(c *gin.Context) {
+	var req AuthTokenRevokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	u, _ := currentUser(c)
+	t, err := tokenStore.Get(req.ID)
+	if err != nil || (t.Owner != u.Username && !u.Admin) {
+		handleErrorResponse(c, "吊销令牌失败", fmt.Errorf("%w: %s", ErrTokenNotFound, req.ID))
+		return
+	}
+	if err := tokenStore.Revoke(req.ID); err != nil {
+		handleErrorResponse(c, "吊销令牌失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "吊销令牌成功",
+	})
+
+	util.Info("吊销令牌成功 用户: " + u.Username + " 令牌: " + t.Name + " ID: " + t.ID)
+}
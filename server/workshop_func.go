@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+	"github.com/VanVodkaer/CS2Panel/util"
+)
+
+const (
+	// WorkshopTypeMap 单张创意工坊地图
+	WorkshopTypeMap = "map"
+	// WorkshopTypeCollection 创意工坊合集
+	WorkshopTypeCollection = "collection"
+
+	// workshopFile 创意工坊登记表在面板数据目录下的文件名
+	workshopFile = "workshop.json"
+	// defaultSteamAPIURL 未配置时使用的 Steam Web API 地址
+	defaultSteamAPIURL = "https://api.steampowered.com"
+	// steamLookupTimeout 查询创意工坊信息的超时时间
+	steamLookupTimeout = 15 * time.Second
+)
+
+// ErrWorkshopNotFound 创意工坊物品未登记
+var ErrWorkshopNotFound = errors.New("创意工坊物品未登记")
+
+// workshopIDRegex 创意工坊物品 ID 格式
+var workshopIDRegex = regexp.MustCompile(`^[0-9]{1,20}$`)
+
+// WorkshopItem 登记的创意工坊地图或合集
+type WorkshopItem struct {
+	ID       string    `json:"id"`                 // 创意工坊物品 ID
+	Name     string    `json:"name"`               // 名称（唯一标识），可代替 ID 用于切换地图
+	Label    string    `json:"label"`              // 显示名称，未填写时使用创意工坊标题
+	Type     string    `json:"type"`               // map 或 collection
+	Title    string    `json:"title"`              // 创意工坊标题
+	Children []string  `json:"children,omitempty"` // 合集包含的物品 ID
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// DisplayName 显示名称
+func (w WorkshopItem) DisplayName() string {
+	if w.Label != "" {
+		return w.Label
+	}
+	if w.Title != "" {
+		return w.Title
+	}
+	return w.Name
+}
+
+// Command 切换到该物品的 RCON 命令
+func (w WorkshopItem) Command() string {
+	if w.Type == WorkshopTypeCollection {
+		return "host_workshop_collection " + w.ID
+	}
+	return "host_workshop_map " + w.ID
+}
+
+// WorkshopStore 管理登记的创意工坊物品：ID -> 物品
+type WorkshopStore struct {
+	mu sync.Mutex
+}
+
+// 全局创意工坊登记表
+var workshopStore = &WorkshopStore{}
+
+// load 读取登记表，调用方需持有锁
+func (s *WorkshopStore) load() (map[string]WorkshopItem, error) {
+	items := make(map[string]WorkshopItem)
+	if _, err := loadPanelJSON(&items, workshopFile); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// List 列出所有登记的物品，按名称排序
+func (s *WorkshopStore) List() ([]WorkshopItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]WorkshopItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Find 按 ID 或名称查找物品
+func (s *WorkshopStore) Find(key string) (WorkshopItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.load()
+	if err != nil {
+		return WorkshopItem{}, err
+	}
+	if item, ok := items[key]; ok {
+		return item, nil
+	}
+	for _, item := range items {
+		if item.Name == key {
+			return item, nil
+		}
+	}
+	return WorkshopItem{}, fmt.Errorf("%w: %s", ErrWorkshopNotFound, key)
+}
+
+// Save 保存物品，名称不能与其他物品重复
+func (s *WorkshopStore) Save(item WorkshopItem) error {
+	if !workshopIDRegex.MatchString(item.ID) {
+		return invalidf("无效的创意工坊 ID %q", item.ID)
+	}
+	if !profileNameRegex.MatchString(item.Name) {
+		return invalidf("无效的名称 %q，只允许小写字母、数字与下划线", item.Name)
+	}
+	if item.Type != WorkshopTypeMap && item.Type != WorkshopTypeCollection {
+		return invalidf("无效的类型 %q", item.Type)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.load()
+	if err != nil {
+		return err
+	}
+	for id, other := range items {
+		if id != item.ID && other.Name == item.Name {
+			return conflictf("名称 %s 已被创意工坊物品 %s 使用", item.Name, id)
+		}
+	}
+	if old, ok := items[item.ID]; ok && item.AddedAt.IsZero() {
+		item.AddedAt = old.AddedAt
+	}
+	if item.AddedAt.IsZero() {
+		item.AddedAt = time.Now()
+	}
+	items[item.ID] = item
+	return savePanelJSON(items, workshopFile)
+}
+
+// Delete 删除登记的物品
+func (s *WorkshopStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := items[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrWorkshopNotFound, id)
+	}
+	delete(items, id)
+	return savePanelJSON(items, workshopFile)
+}
+
+// steamAPIURL Steam Web API 地址，可在配置中指向本地替身服务用于测试
+func steamAPIURL() string {
+	if u := config.GlobalConfig.Game.SteamAPIURL; u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return defaultSteamAPIURL
+}
+
+// steamAPIPost 向 Steam Web API 提交表单并解析 JSON 响应
+func steamAPIPost(ctx context.Context, method string, form url.Values, v any) error {
+	ctx, cancel := context.WithTimeout(ctx, steamLookupTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, steamAPIURL()+"/ISteamRemoteStorage/"+method+"/v1/", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("创建请求失败：%w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return upstreamf("请求 Steam API 失败：%w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return upstreamf("Steam API 返回 %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return upstreamf("解析 Steam API 响应失败：%w", err)
+	}
+	return nil
+}
+
+// LookupWorkshopItem 通过 Steam API 查询创意工坊物品的标题，并判断是否为合集
+func LookupWorkshopItem(ctx context.Context, id string) (WorkshopItem, error) {
+	if !workshopIDRegex.MatchString(id) {
+		return WorkshopItem{}, invalidf("无效的创意工坊 ID %q", id)
+	}
+
+	var details struct {
+		Response struct {
+			PublishedFileDetails []struct {
+				PublishedFileID string `json:"publishedfileid"`
+				Result          int    `json:"result"`
+				Title           string `json:"title"`
+				ConsumerAppID   int    `json:"consumer_app_id"`
+			} `json:"publishedfiledetails"`
+		} `json:"response"`
+	}
+	if err := steamAPIPost(ctx, "GetPublishedFileDetails", url.Values{
+		"itemcount":           {"1"},
+		"publishedfileids[0]": {id},
+	}, &details); err != nil {
+		return WorkshopItem{}, err
+	}
+	files := details.Response.PublishedFileDetails
+	if len(files) == 0 || files[0].Result != 1 {
+		return WorkshopItem{}, invalidf("创意工坊物品 %s 不存在或不可见", id)
+	}
+	if files[0].ConsumerAppID != 0 && files[0].ConsumerAppID != 730 {
+		return WorkshopItem{}, invalidf("创意工坊物品 %s 不属于 CS2", id)
+	}
+	item := WorkshopItem{ID: id, Type: WorkshopTypeMap, Title: files[0].Title}
+
+	var collection struct {
+		Response struct {
+			CollectionDetails []struct {
+				Result   int `json:"result"`
+				Children []struct {
+					PublishedFileID string `json:"publishedfileid"`
+				} `json:"children"`
+			} `json:"collectiondetails"`
+		} `json:"response"`
+	}
+	if err := steamAPIPost(ctx, "GetCollectionDetails", url.Values{
+		"collectioncount":     {"1"},
+		"publishedfileids[0]": {id},
+	}, &collection); err != nil {
+		return WorkshopItem{}, err
+	}
+	if cd := collection.Response.CollectionDetails; len(cd) > 0 && cd[0].Result == 1 && len(cd[0].Children) > 0 {
+		item.Type = WorkshopTypeCollection
+		for _, child := range cd[0].Children {
+			item.Children = append(item.Children, child.PublishedFileID)
+		}
+	}
+	return item, nil
+}
+
+// ResolveWorkshopItem 将地图名解析为创意工坊物品：依次匹配登记的 ID 或名称、已安装的创意工坊地图
+func ResolveWorkshopItem(ctx context.Context, key string) (WorkshopItem, bool, error) {
+	item, err := workshopStore.Find(key)
+	if err == nil {
+		return item, true, nil
+	}
+	if !errors.Is(err, ErrWorkshopNotFound) {
+		return WorkshopItem{}, false, err
+	}
+
+	// 未登记的纯数字按创意工坊地图 ID 处理
+	if workshopIDRegex.MatchString(key) {
+		return WorkshopItem{ID: key, Name: key, Type: WorkshopTypeMap}, true, nil
+	}
+
+	installed, err := ScanInstalledMaps(ctx, false)
+	if err != nil {
+		// 扫描失败时只能按普通地图处理
+		return WorkshopItem{}, false, nil
+	}
+	for _, m := range installed {
+		if m.WorkshopID != "" && m.InternalName == key {
+			return WorkshopItem{ID: m.WorkshopID, Name: key, Type: WorkshopTypeMap}, true, nil
+		}
+	}
+	return WorkshopItem{}, false, nil
+}
+
+// CheckWorkshopType 确认创意工坊物品的类型为 want（地图或合集）
+// 未登记的 ID 无法确定类型，需要通过 Steam API 查询；Steam API 不可用时不阻止使用
+func CheckWorkshopType(ctx context.Context, item WorkshopItem, want string) error {
+	if _, err := workshopStore.Find(item.ID); err != nil {
+		if !errors.Is(err, ErrWorkshopNotFound) {
+			return err
+		}
+		looked, err := LookupWorkshopItem(ctx, item.ID)
+		switch classifyError(err) {
+		case CodeUpstreamError, CodeUpstreamTimeout:
+			util.Warn(fmt.Sprintf("查询创意工坊物品 %s 的类型失败: %v", item.ID, err))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		item = looked
+	}
+
+	if item.Type == want {
+		return nil
+	}
+	if want == WorkshopTypeMap {
+		return invalidf("创意工坊物品 %s 是合集，不能作为地图使用", item.ID)
+	}
+	return invalidf("创意工坊物品 %s 是地图，不能作为合集使用", item.ID)
+}
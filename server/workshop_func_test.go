@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/VanVodkaer/CS2Panel/config"
+)
+
+// steamItem Steam API 替身中的创意工坊物品
+type steamItem struct {
+	title    string
+	appID    int
+	children []string // 非空时为合集
+}
+
+// fakeSteamAPI 实现 ISteamRemoteStorage 的 GetPublishedFileDetails 与 GetCollectionDetails
+func fakeSteamAPI(items map[string]steamItem) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id := r.PostFormValue("publishedfileids[0]")
+		item, ok := items[id]
+		result := 9
+		if ok {
+			result = 1
+		}
+
+		switch r.URL.Path {
+		case "/ISteamRemoteStorage/GetPublishedFileDetails/v1/":
+			file := map[string]any{"publishedfileid": id, "result": result}
+			if ok {
+				file["title"] = item.title
+				file["consumer_app_id"] = item.appID
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"publishedfiledetails": []any{file}}})
+		case "/ISteamRemoteStorage/GetCollectionDetails/v1/":
+			detail := map[string]any{"publishedfileid": id, "result": 9}
+			if len(item.children) > 0 {
+				children := make([]map[string]string, 0, len(item.children))
+				for _, child := range item.children {
+					children = append(children, map[string]string{"publishedfileid": child})
+				}
+				detail["result"] = 1
+				detail["children"] = children
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"response": map[string]any{"collectiondetails": []any{detail}}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+// useSteamAPI 在测试期间把 steam_api_url 指向替身服务
+func useSteamAPI(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	old := config.GlobalConfig.Game.SteamAPIURL
+	config.GlobalConfig.Game.SteamAPIURL = srv.URL
+	t.Cleanup(func() {
+		config.GlobalConfig.Game.SteamAPIURL = old
+		srv.Close()
+	})
+	return srv
+}
+
+func TestLookupWorkshopItem(t *testing.T) {
+	useSteamAPI(t, fakeSteamAPI(map[string]steamItem{
+		"100": {title: "Test Map", appID: 730},
+		"200": {title: "Test Collection", appID: 730, children: []string{"100", "101"}},
+		"300": {title: "Dota Item", appID: 570},
+	}))
+	ctx := context.Background()
+
+	item, err := LookupWorkshopItem(ctx, "100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Type != WorkshopTypeMap || item.Title != "Test Map" || item.Children != nil {
+		t.Errorf("地图的查询结果不正确: %+v", item)
+	}
+
+	item, err = LookupWorkshopItem(ctx, "200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Type != WorkshopTypeCollection || !reflect.DeepEqual(item.Children, []string{"100", "101"}) {
+		t.Errorf("合集的查询结果不正确: %+v", item)
+	}
+
+	for _, id := range []string{"300", "404", "abc"} {
+		if _, err := LookupWorkshopItem(ctx, id); classifyError(err) != CodeInvalidRequest {
+			t.Errorf("%s 应返回参数错误，实际 %v", id, err)
+		}
+	}
+}
+
+// 创建服务器时合集不能作为创意工坊地图，地图也不能作为合集
+func TestCreateServerWorkshopType(t *testing.T) {
+	useSteamAPI(t, fakeSteamAPI(map[string]steamItem{
+		"100": {title: "Test Map", appID: 730},
+		"200": {title: "Test Collection", appID: 730, children: []string{"100"}},
+	}))
+	testDocker.setContainers()
+	if err := workshopStore.Save(WorkshopItem{ID: "200", Name: "pool", Type: WorkshopTypeCollection}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = workshopStore.Delete("200") })
+
+	cases := []struct {
+		name string
+		req  ContainerCreateRequest
+	}{
+		{"unregistered collection as map", ContainerCreateRequest{Name: "ws1", CS2_HOST_WORKSHOP_MAP: "200"}},
+		{"registered collection as map", ContainerCreateRequest{Name: "ws2", CS2_HOST_WORKSHOP_MAP: "pool"}},
+		{"map as collection", ContainerCreateRequest{Name: "ws3", CS2_HOST_WORKSHOP_COLLECTION: "100"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := createServer(context.Background(), tc.req); classifyError(err) != CodeInvalidRequest {
+				t.Errorf("应返回参数错误，实际 %v", err)
+			}
+		})
+	}
+}
+
+// Steam API 不可用时无法确认未登记 ID 的类型，不阻止使用
+func TestCheckWorkshopTypeSteamUnavailable(t *testing.T) {
+	useSteamAPI(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	if err := CheckWorkshopType(context.Background(), WorkshopItem{ID: "100", Type: WorkshopTypeMap}, WorkshopTypeMap); err != nil {
+		t.Errorf("Steam API 不可用时不应报错: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/VanVodkaer/CS2Panel/util"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkshopListResponse 登记的创意工坊物品列表的响应
+type WorkshopListResponse struct {
+	Items []WorkshopItem `json:"items"`
+}
+
+// WorkshopItemResponse 单个创意工坊物品的响应
+type WorkshopItemResponse struct {
+	Message string       `json:"message,omitempty"`
+	Item    WorkshopItem `json:"item"`
+}
+
+// workshopListHandler 处理获取登记的创意工坊物品列表的请求
+func workshopListHandler(c *gin.Context) {
+	items, err := workshopStore.List()
+	if err != nil {
+		handleErrorResponse(c, "获取创意工坊列表失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkshopListResponse{
+		Items: items,
+	})
+}
+
+// WorkshopLookupRequest 查询创意工坊物品信息的请求参数
+type WorkshopLookupRequest struct {
+	ID string `form:"id" binding:"required"`
+}
+
+// workshopLookupHandler 处理查询创意工坊物品信息的请求，不写入登记表
+func workshopLookupHandler(c *gin.Context) {
+	var req WorkshopLookupRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	item, err := LookupWorkshopItem(c.Request.Context(), req.ID)
+	if err != nil {
+		handleErrorResponse(c, "查询创意工坊物品失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkshopItemResponse{
+		Item: item,
+	})
+}
+
+// WorkshopSaveRequest 登记创意工坊地图或合集的请求参数
+type WorkshopSaveRequest struct {
+	ID         string `json:"id" binding:"required"`
+	Name       string `json:"name" binding:"required"`
+	Label      string `json:"label"`
+	Type       string `json:"type" binding:"omitempty,oneof=map collection"`
+	SkipLookup bool   `json:"skip_lookup"`
+}
+
+// workshopSaveHandler 处理登记创意工坊地图或合集的请求
+// 默认通过 Steam API 查询标题与类型，skip_lookup 为 true 时直接使用请求中的类型
+func workshopSaveHandler(c *gin.Context) {
+	var req WorkshopSaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	item := WorkshopItem{ID: req.ID, Type: util.DefaultIfEmpty(req.Type, WorkshopTypeMap)}
+	if !req.SkipLookup {
+		found, err := LookupWorkshopItem(c.Request.Context(), req.ID)
+		if err != nil {
+			handleErrorResponse(c, "查询创意工坊物品失败", err)
+			return
+		}
+		item = found
+	}
+	item.Name = req.Name
+	item.Label = req.Label
+
+	if err := workshopStore.Save(item); err != nil {
+		handleErrorResponse(c, "登记创意工坊物品失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, WorkshopItemResponse{
+		Message: "登记创意工坊物品成功",
+		Item:    item,
+	})
+
+	util.Info("登记创意工坊物品成功 ID: " + item.ID + " 名称: " + item.Name + " 类型: " + item.Type)
+}
+
+// WorkshopDeleteRequest 删除登记的创意工坊物品的请求参数
+type WorkshopDeleteRequest struct {
+	ID string `json:"id" binding:"required"`
+}
+
+// workshopDeleteHandler 处理删除登记的创意工坊物品的请求
+func workshopDeleteHandler(c *gin.Context) {
+	var req WorkshopDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleErrorResponse(c, "无效的请求参数", badRequest(err))
+		return
+	}
+
+	if err := workshopStore.Delete(req.ID); err != nil {
+		handleErrorResponse(c, "删除创意工坊物品失败", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageResponse{
+		Message: "删除创意工坊物品成功",
+	})
+
+	util.Info("删除创意工坊物品成功 ID: " + req.ID)
+}
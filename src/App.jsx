@@ -3,17 +3,26 @@ import { Routes, Route } from "react-router-dom";
 import Home from "./pages/Dashboard";
 import CreateContainer from "./pages/CreateContainer";
 import ServerDetailsPage from "./pages/ServerDetailsPage";
+import Login from "./pages/Login";
 
 const App = () => {
   return (
-    <MainLayout>
-      <Routes>
-        <Route path="/" element={<Home />} />
-        <Route path="/container/create" element={<CreateContainer />} />
-        <Route path="/container/detail/:name" element={<ServerDetailsPage />} />
-        {/* 其他页面路由 */}
-      </Routes>
-    </MainLayout>
+    <Routes>
+      <Route path="/login" element={<Login />} />
+      <Route
+        path="*"
+        element={
+          <MainLayout>
+            <Routes>
+              <Route path="/" element={<Home />} />
+              <Route path="/container/create" element={<CreateContainer />} />
+              <Route path="/container/detail/:name" element={<ServerDetailsPage />} />
+              {/* 其他页面路由 */}
+            </Routes>
+          </MainLayout>
+        }
+      />
+    </Routes>
   );
 };
 
@@ -8,6 +8,7 @@ const API_BASE = import.meta.env.VITE_API_BASE_URL;
 const api = axios.create({
   baseURL: API_BASE + "/api",
   timeout: 10000, // 根据需要设置超时时间
+  withCredentials: true, // 携带会话 Cookie
   headers: {
     "Content-Type": "application/json",
   },
@@ -26,6 +27,17 @@ const api = axios.create({
 //   (error) => Promise.reject(error)
 // );
 
+// 响应拦截器：会话失效时跳转到登录页
+api.interceptors.response.use(
+  (response) => response,
+  (error) => {
+    if (error.response?.status === 401 && window.location.pathname !== "/login") {
+      window.location.href = "/login";
+    }
+    return Promise.reject(error);
+  }
+);
+
 // 响应拦截器
 // api.interceptors.response.use(
 //   (response) => response.data,
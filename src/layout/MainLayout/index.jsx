@@ -1,13 +1,32 @@
 import "./index.less";
 
-import { Layout, Menu } from "antd";
-import { DashboardOutlined, SettingOutlined } from "@ant-design/icons";
+import { useEffect, useState } from "react";
+import { Button, Layout, Menu, Space } from "antd";
+import { DashboardOutlined, LogoutOutlined, SettingOutlined, UserOutlined } from "@ant-design/icons";
 import { useNavigate } from "react-router-dom";
+import api from "../../config/axiosConfig";
 
 const { Header, Sider, Content } = Layout;
 
 const MainLayout = ({ children }) => {
   const navigate = useNavigate();
+  const [user, setUser] = useState(null);
+
+  // 获取当前登录用户，未登录时由 axios 拦截器跳转到登录页
+  useEffect(() => {
+    api
+      .get("/auth/me")
+      .then((res) => setUser(res.data.user))
+      .catch(() => {});
+  }, []);
+
+  const handleLogout = async () => {
+    try {
+      await api.post("/auth/logout");
+    } finally {
+      navigate("/login");
+    }
+  };
 
   const menuItems = [
     { key: "/", icon: <DashboardOutlined />, label: "服务器状态" },
@@ -25,7 +44,16 @@ const MainLayout = ({ children }) => {
         <Menu theme="dark" mode="inline" items={menuItems} onClick={handleMenuClick} />
       </Sider>
       <Layout>
-        <Header className="layout-header">{/* 顶部导航栏区域 */}</Header>
+        <Header className="layout-header">
+          {/* 顶部导航栏区域 */}
+          <Space className="header-user">
+            <UserOutlined />
+            <span>{user?.username}</span>
+            <Button type="link" icon={<LogoutOutlined />} onClick={handleLogout}>
+              退出登录
+            </Button>
+          </Space>
+        </Header>
         <Content className="layout-content">{children}</Content>
       </Layout>
     </Layout>
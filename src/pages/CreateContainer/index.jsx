@@ -2,6 +2,7 @@ import React, { useState, useEffect, useCallback } from "react";
 import { Button, Checkbox, Form, Input, InputNumber, message, Select, Typography, Space } from "antd";
 import { useNavigate } from "react-router-dom";
 import api from "../../config/axiosConfig";
+import { mapLabel } from "../../util/mapLabel";
 
 const { Option } = Select;
 const { Text } = Typography;
@@ -31,6 +32,7 @@ const CreateContainer = () => {
   const navigate = useNavigate();
 
   const [mapOptions, setMapOptions] = useState([]);
+  const [mapGroups, setMapGroups] = useState({ builtin: [], groups: [] });
   const [availableModes, setAvailableModes] = useState([]);
   const [presetDesc, setPresetDesc] = useState("");
 
@@ -77,8 +79,19 @@ const CreateContainer = () => {
     }
   };
 
+  // 获取地图组列表
+  const fetchMapGroups = async () => {
+    try {
+      const resp = await api.get("/info/map/group/list");
+      setMapGroups(resp.data);
+    } catch (err) {
+      console.error("获取地图组列表时出错:", err);
+    }
+  };
+
   useEffect(() => {
     fetchMapList();
+    fetchMapGroups();
   }, []);
 
   // 初始化默认值
@@ -119,6 +132,14 @@ const CreateContainer = () => {
       values[k] = values[k] ? "1" : "0";
     });
 
+    // 创意工坊选择按类型写入对应的启动参数
+    const workshop = mapOptions.find((m) => m.workshop && m.workshop_id === values.workshop);
+    delete values.workshop;
+    if (workshop) {
+      const key = workshop.workshop_type === "collection" ? "cs2_host_workshop_collection" : "cs2_host_workshop_map";
+      values[key] = workshop.workshop_id;
+    }
+
     api
       .post("/docker/container/create", values)
       .then((res) => {
@@ -210,14 +231,43 @@ const CreateContainer = () => {
 
       <Form.Item label="开始地图" name="cs2_startmap">
         <Select onChange={handleMapChange}>
-          {mapOptions.map((m) => (
-            <Option key={m.internal_name} value={m.internal_name}>
-              {m.name}
+          {mapOptions
+            .filter((m) => !m.workshop)
+            .map((m) => (
+              <Option key={m.internal_name} value={m.internal_name}>
+                {mapLabel(m)}
+              </Option>
+            ))}
+        </Select>
+      </Form.Item>
+
+      <Form.Item label="地图组" name="cs2_mapgroup">
+        <Select allowClear placeholder="mg_active">
+          {mapGroups.builtin.map((g) => (
+            <Option key={g} value={g}>
+              {g}
+            </Option>
+          ))}
+          {mapGroups.groups.map((g) => (
+            <Option key={g.name} value={g.name}>
+              {g.label ? `${g.label}（${g.name}）` : g.name}
             </Option>
           ))}
         </Select>
       </Form.Item>
 
+      <Form.Item label="创意工坊地图" name="workshop" extra="设置后服务器启动时加载该创意工坊地图或合集">
+        <Select allowClear placeholder="不使用创意工坊">
+          {mapOptions
+            .filter((m) => m.workshop && m.workshop_id)
+            .map((m) => (
+              <Option key={m.workshop_id} value={m.workshop_id}>
+                {m.workshop_type === "collection" ? `${m.name}（合集）` : m.name}
+              </Option>
+            ))}
+        </Select>
+      </Form.Item>
+
       {availableModes.length > 0 && (
         <Form.Item label="可用模式">
           <Text>{availableModes.join(" / ")}</Text>
@@ -19,7 +19,7 @@ const Home = () => {
   const [deletingName, setDeletingName] = useState(null);
   const [stoppingName, setStoppingName] = useState(null);
 
-  // 选中行（存储 trimmedName）
+  // 选中行（存储服务器名称）
   const [selectedRowKeys, setSelectedRowKeys] = useState([]);
 
   // 批量操作加载状态
@@ -29,9 +29,13 @@ const Home = () => {
 
   const navigate = useNavigate();
 
-  // 从完整名称提取 trimmedName
-  const getTrimmedName = (fullName) =>
-    fullName.includes("-") ? fullName.substring(fullName.indexOf("-") + 1) : fullName;
+  // 批量操作部分失败时（HTTP 207）提示失败的服务器，返回是否存在失败
+  const reportPartialFailure = (res) => {
+    if (res.status !== 207) return false;
+    const failed = (res.data.results || []).filter((r) => r.status === "error");
+    message.warning(`${res.data.message}：${failed.map((r) => r.name).join(", ")}`);
+    return true;
+  };
 
   // 拉取容器列表
   const fetchContainers = () => {
@@ -111,8 +115,8 @@ const Home = () => {
     setBatchStartLoading(true);
     api
       .post("/docker/container/start", { names: selectedRowKeys }, { timeout: 60000 })
-      .then(() => {
-        message.success("批量启动成功");
+      .then((res) => {
+        if (!reportPartialFailure(res)) message.success("批量启动成功");
         setSelectedRowKeys([]);
         fetchContainers();
       })
@@ -131,8 +135,8 @@ const Home = () => {
     setBatchStopLoading(true);
     api
       .post("/docker/container/stop", { names: selectedRowKeys }, { timeout: 60000 })
-      .then(() => {
-        message.success("批量停止成功");
+      .then((res) => {
+        if (!reportPartialFailure(res)) message.success("批量停止成功");
         setSelectedRowKeys([]);
         setTimeout(fetchContainers, 500);
       })
@@ -151,8 +155,8 @@ const Home = () => {
     setBatchRemoveLoading(true);
     api
       .post("/docker/container/remove", { names: selectedRowKeys }, { timeout: 60000 })
-      .then(() => {
-        message.success("批量删除成功");
+      .then((res) => {
+        if (!reportPartialFailure(res)) message.success("批量删除成功");
         setSelectedRowKeys([]);
         setTimeout(fetchContainers, 500);
       })
@@ -195,9 +199,8 @@ const Home = () => {
   const columns = [
     {
       title: "容器名称",
-      dataIndex: "Names",
+      dataIndex: "server_name",
       key: "name",
-      render: (names) => getTrimmedName(names[0]),
     },
     {
       title: "状态",
@@ -208,7 +211,7 @@ const Home = () => {
       title: "操作",
       key: "action",
       render: (_, record) => {
-        const trimmed = getTrimmedName(record.Names[0]);
+        const trimmed = record.server_name;
         return (
           <Space size="small">
             <Button
@@ -240,7 +243,7 @@ const Home = () => {
       title: "复制信息",
       key: "copy",
       render: (_, record) => {
-        const trimmed = getTrimmedName(record.Names[0]);
+        const trimmed = record.server_name;
         return (
           <Space direction="vertical" size="small">
             <Space size="small">
@@ -301,7 +304,7 @@ const Home = () => {
       {headerButtons}
       <Table
         // rowKey 使用 trimmedName
-        rowKey={(record) => getTrimmedName(record.Names[0])}
+        rowKey={(record) => record.server_name}
         columns={columns}
         dataSource={containers}
         loading={loading}
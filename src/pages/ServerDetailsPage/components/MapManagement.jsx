@@ -1,10 +1,12 @@
 import { useState, useEffect } from "react";
-import { Typography, Form, AutoComplete, Button, message } from "antd";
+import { Typography, Form, AutoComplete, Button, Checkbox, message } from "antd";
 import api from "../../../config/axiosConfig";
+import { mapLabel } from "../../../util/mapLabel";
 
 function MapManagement({ name, status, fetchStatus, withLoading }) {
   const [newMap, setNewMap] = useState("");
   const [mapOptions, setMapOptions] = useState([]);
+  const [switchPreset, setSwitchPreset] = useState(false);
 
   useEffect(() => {
     fetchMapList();
@@ -22,11 +24,16 @@ function MapManagement({ name, status, fetchStatus, withLoading }) {
   const changeMap = () =>
     withLoading(() => {
       if (!newMap) return message.error("请输入地图 internal_name");
-      return api.post("/rcon/map/change", { name, map: newMap }).then(() => {
-        message.success("地图切换成功");
-        setNewMap("");
-        fetchStatus();
-      });
+      return api
+        .post("/rcon/map/change", { name, map: newMap, switch_preset: switchPreset }, { timeout: 120000 })
+        .then(() => {
+          message.success("地图切换成功");
+          setNewMap("");
+          fetchStatus();
+        })
+        .catch((err) => {
+          message.error(`地图切换失败: ${err.response?.data?.details || err.response?.data?.message || err.message}`);
+        });
     });
 
   const getMapName = () => status.spawngroups?.[0]?.path;
@@ -41,13 +48,18 @@ function MapManagement({ name, status, fetchStatus, withLoading }) {
         <Form.Item label="开始地图">
           <AutoComplete
             className="map-input"
-            options={mapOptions.map((m) => ({ value: m.internal_name, label: m.name }))}
+            options={mapOptions.map((m) => ({ value: m.internal_name, label: mapLabel(m) }))}
             placeholder="输入地图 internal_name"
             value={newMap}
             onChange={setNewMap}
             filterOption={(input, option) => option.value.includes(input) || option.label.includes(input)}
           />
         </Form.Item>
+        <Form.Item>
+          <Checkbox checked={switchPreset} onChange={(e) => setSwitchPreset(e.target.checked)}>
+            模式不兼容时自动切换
+          </Checkbox>
+        </Form.Item>
         <Form.Item>
           <Button type="primary" onClick={changeMap}>
             切换地图
@@ -0,0 +1,2 @@
+// mapLabel 地图选项显示名，标注创意工坊与未安装的地图
+export const mapLabel = (m) => `${m.name}${m.workshop ? "（创意工坊）" : ""}${m.installed ? "" : "（未安装）"}`;
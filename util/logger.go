@@ -11,11 +11,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// Logger 全局日志记录器
+// Logger 全局日志记录器，调用 InitLogger 之前为 nil，日志输出到标准错误
 var Logger *logrus.Logger
 
-// 初始化日志记录器
-func init() {
+// InitLogger 按全局配置初始化日志记录器，需要在 config.Init 之后调用
+func InitLogger() {
 
 	// 获取日志配置
 	logDir := config.GlobalConfig.Util.LogDir